@@ -0,0 +1,175 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: checkconditions.proto
+
+package checkconditionsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	CheckConditionsService_Scan_FullMethodName           = "/checkconditions.v1.CheckConditionsService/Scan"
+	CheckConditionsService_StreamFindings_FullMethodName = "/checkconditions.v1.CheckConditionsService/StreamFindings"
+)
+
+// CheckConditionsServiceClient is the client API for CheckConditionsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CheckConditionsServiceClient interface {
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error)
+	StreamFindings(ctx context.Context, in *StreamFindingsRequest, opts ...grpc.CallOption) (CheckConditionsService_StreamFindingsClient, error)
+}
+
+type checkConditionsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCheckConditionsServiceClient(cc grpc.ClientConnInterface) CheckConditionsServiceClient {
+	return &checkConditionsServiceClient{cc}
+}
+
+func (c *checkConditionsServiceClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error) {
+	out := new(ScanResponse)
+	err := c.cc.Invoke(ctx, CheckConditionsService_Scan_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *checkConditionsServiceClient) StreamFindings(ctx context.Context, in *StreamFindingsRequest, opts ...grpc.CallOption) (CheckConditionsService_StreamFindingsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CheckConditionsService_ServiceDesc.Streams[0], CheckConditionsService_StreamFindings_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &checkConditionsServiceStreamFindingsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CheckConditionsService_StreamFindingsClient interface {
+	Recv() (*StreamFindingsResponse, error)
+	grpc.ClientStream
+}
+
+type checkConditionsServiceStreamFindingsClient struct {
+	grpc.ClientStream
+}
+
+func (x *checkConditionsServiceStreamFindingsClient) Recv() (*StreamFindingsResponse, error) {
+	m := new(StreamFindingsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CheckConditionsServiceServer is the server API for CheckConditionsService service.
+// All implementations must embed UnimplementedCheckConditionsServiceServer
+// for forward compatibility
+type CheckConditionsServiceServer interface {
+	Scan(context.Context, *ScanRequest) (*ScanResponse, error)
+	StreamFindings(*StreamFindingsRequest, CheckConditionsService_StreamFindingsServer) error
+	mustEmbedUnimplementedCheckConditionsServiceServer()
+}
+
+// UnimplementedCheckConditionsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCheckConditionsServiceServer struct {
+}
+
+func (UnimplementedCheckConditionsServiceServer) Scan(context.Context, *ScanRequest) (*ScanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedCheckConditionsServiceServer) StreamFindings(*StreamFindingsRequest, CheckConditionsService_StreamFindingsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamFindings not implemented")
+}
+func (UnimplementedCheckConditionsServiceServer) mustEmbedUnimplementedCheckConditionsServiceServer() {
+}
+
+// UnsafeCheckConditionsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CheckConditionsServiceServer will
+// result in compilation errors.
+type UnsafeCheckConditionsServiceServer interface {
+	mustEmbedUnimplementedCheckConditionsServiceServer()
+}
+
+func RegisterCheckConditionsServiceServer(s grpc.ServiceRegistrar, srv CheckConditionsServiceServer) {
+	s.RegisterService(&CheckConditionsService_ServiceDesc, srv)
+}
+
+func _CheckConditionsService_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckConditionsServiceServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CheckConditionsService_Scan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckConditionsServiceServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CheckConditionsService_StreamFindings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamFindingsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CheckConditionsServiceServer).StreamFindings(m, &checkConditionsServiceStreamFindingsServer{stream})
+}
+
+type CheckConditionsService_StreamFindingsServer interface {
+	Send(*StreamFindingsResponse) error
+	grpc.ServerStream
+}
+
+type checkConditionsServiceStreamFindingsServer struct {
+	grpc.ServerStream
+}
+
+func (x *checkConditionsServiceStreamFindingsServer) Send(m *StreamFindingsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// CheckConditionsService_ServiceDesc is the grpc.ServiceDesc for CheckConditionsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CheckConditionsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "checkconditions.v1.CheckConditionsService",
+	HandlerType: (*CheckConditionsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Scan",
+			Handler:    _CheckConditionsService_Scan_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamFindings",
+			Handler:       _CheckConditionsService_StreamFindings_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "checkconditions.proto",
+}
@@ -6,10 +6,15 @@ import (
 )
 
 var allCmd = &cobra.Command{
-	Use:   "all",
-	Short: "Check all conditions of all api-resources",
-	Long:  `...`,
+	Use:         "all [RESOURCE...]",
+	Annotations: scanAnnotations,
+	Short:       "Check all conditions of all api-resources",
+	Long: `Check all conditions of all api-resources.
+
+Resource types can be given as arguments to check only these. Like kubectl, resource names, kinds
+and short names are supported: "deploy", "Deployment", "deployments.apps", "deployments.v1.apps".`,
 	Run: func(cmd *cobra.Command, args []string) {
+		arguments.Resources = args
 		checkconditions.RunAll(arguments)
 	},
 }
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/guettli/check-conditions/pkg/checkconditions"
+	"github.com/spf13/cobra"
+)
+
+var checksCmd = &cobra.Command{
+	Use:   "checks",
+	Short: "Commands for the built-in checks",
+}
+
+var checksListCmd = &cobra.Command{
+	Use:         "list",
+	Annotations: scanAnnotations,
+	Short:       "List all built-in checks",
+	Long: `List all built-in checks with ID, description, default severity and the kinds they apply to.
+
+The column ENABLED takes --enable-checks and --disable-checks into account.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkconditions.RunChecksList(arguments)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checksCmd)
+	checksCmd.AddCommand(checksListCmd)
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/guettli/check-conditions/pkg/checkconditions"
+	"github.com/spf13/cobra"
+)
+
+var conditionsCmd = &cobra.Command{
+	Use:   "conditions",
+	Short: "Commands to look at the conditions of the cluster",
+}
+
+var conditionsInventoryCmd = &cobra.Command{
+	Use:         "inventory",
+	Annotations: scanAnnotations,
+	Short:       "Print each distinct pair of kind and condition type with the number of True/False/Unknown conditions",
+	Long: `Print each distinct pair of kind and condition type with the number of True/False/Unknown conditions.
+
+The conditions do not get judged. This is the raw material to write rules for a new environment.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkconditions.RunInventory(arguments)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(conditionsCmd)
+	conditionsCmd.AddCommand(conditionsInventoryCmd)
+	conditionsInventoryCmd.Flags().StringVar(&arguments.WriteConfig, "write-config", "",
+		"Write suggested conditionRules for all unknown condition types to this file (yaml), with guessed meaning and TODO comments")
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/guettli/check-conditions/pkg/checkconditions"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:         "doctor",
+	Annotations: scanAnnotations,
+	Short:       "Check all conditions and print a health score (0-100) with the top problems",
+	Long: `Check all conditions and print a health score (0-100) with the top problems.
+
+Each finding gets a penalty, depending on its severity (critical 10, warning 3). Findings of
+nodes count three times. A score of 100 means no findings, 50 means a penalty of 100.
+The weights can be changed in the "score" block of the config file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkconditions.RunDoctor(arguments)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/guettli/check-conditions/pkg/checkconditions"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Commands for the history file (--history-file)",
+}
+
+var historyCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Remove events older than --history-retention from the history file",
+	Long: `Remove events older than --history-retention from the history file.
+
+The events which found the open findings are kept. Example:
+
+check-conditions history compact --history-file history.jsonl --history-retention 90d`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkconditions.RunHistoryCompact(arguments)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyCompactCmd)
+}
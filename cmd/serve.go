@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/guettli/check-conditions/pkg/checkconditions"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:         "serve",
+	Annotations: scanAnnotations,
+	Short:       "Check all conditions periodically and serve the results via HTTP",
+	Long: `Check all conditions periodically and serve the results via HTTP.
+
+Listeners, interval, textfile and remote-write can be configured in the "serve" block of the
+config file. Command-line flags take precedence.
+
+Endpoints:
+
+  GET  /                      Dashboard (HTML) with the findings of the last periodic scan
+  GET  /metrics               Metrics of the last periodic scan in the Prometheus format
+  POST /scan                  Run a scan now. Optional JSON body: {"namespace": "...", "labelSelector": "..."}
+  GET  /findings              Findings of the last periodic scan
+  GET  /findings/{namespace}  Findings of the last periodic scan in one namespace
+  GET  /healthz/namespace/{name}?severity=critical
+                              200 if the last periodic scan found nothing in the namespace, otherwise 503
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkconditions.RunServe(arguments)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&arguments.Listen, "listen", "",
+		`Address of the HTTP server (default ":8080"). Without --http-token-file or --http-client-ca only localhost is allowed, and ":8080" means "localhost:8080"`)
+	serveCmd.Flags().StringVar(&arguments.HTTPTLSCert, "http-tls-cert", "", "File of the TLS certificate of the HTTP server")
+	serveCmd.Flags().StringVar(&arguments.HTTPTLSKey, "http-tls-key", "", "File of the TLS key of the HTTP server")
+	serveCmd.Flags().StringVar(&arguments.HTTPClientCA, "http-client-ca", "",
+		"File of the CA which signs the client certificates. HTTP clients need a client certificate then (mTLS). Needs --http-tls-cert")
+	serveCmd.Flags().StringVar(&arguments.HTTPTokenFile, "http-token-file", "",
+		`File with a token. HTTP clients need the header "Authorization: Bearer TOKEN" then. Needs --http-tls-cert, unless --listen is on localhost`)
+	serveCmd.Flags().StringVar(&arguments.ConfigResource, "config-resource", "",
+		"Name of a ClusterCheckConfig custom resource, which is read and watched instead of --config. See deploy/clustercheckconfigs.yaml")
+	serveCmd.Flags().StringVar(&arguments.GRPCListen, "grpc-listen", "",
+		"Address of the gRPC server. See api/checkconditions/v1/checkconditions.proto. Empty means no gRPC server. Without --grpc-token-file or --grpc-client-ca only localhost is allowed, and \":9090\" means \"localhost:9090\"")
+	serveCmd.Flags().StringVar(&arguments.GRPCTLSCert, "grpc-tls-cert", "", "File of the TLS certificate of the gRPC server")
+	serveCmd.Flags().StringVar(&arguments.GRPCTLSKey, "grpc-tls-key", "", "File of the TLS key of the gRPC server")
+	serveCmd.Flags().StringVar(&arguments.GRPCClientCA, "grpc-client-ca", "",
+		"File of the CA which signs the client certificates. gRPC clients need a client certificate then (mTLS). Needs --grpc-tls-cert")
+	serveCmd.Flags().StringVar(&arguments.GRPCTokenFile, "grpc-token-file", "",
+		`File with a token. gRPC clients need the metadata "authorization: Bearer TOKEN" then. Needs --grpc-tls-cert, unless --grpc-listen is on localhost`)
+	serveCmd.Flags().DurationVar(&arguments.Interval, "interval", 0, "Time between two periodic scans (default 5m)")
+	serveCmd.Flags().StringVar(&arguments.Schedule, "schedule", "",
+		`Cron expression for the periodic scans, for example "*/10 * * * *". Replaces --interval`)
+	serveCmd.Flags().DurationVar(&arguments.Jitter, "jitter", 0,
+		"Delay each periodic scan by a random duration up to this value. Avoids synchronized scans of many clusters")
+	serveCmd.Flags().DurationVar(&arguments.RenotifyInterval, "renotify-interval", 0,
+		"Send unchanged findings to the sinks again after this duration. 0 means never: only new, changed and resolved findings get sent")
+	serveCmd.Flags().BoolVar(&arguments.Informers, "informers", false,
+		"Watch the resource objects instead of listing them for each scan. Needs more memory, but reduces the load of the api-server. Changes trigger a new scan")
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/guettli/check-conditions/pkg/checkconditions"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:         "snapshot",
+	Annotations: scanAnnotations,
+	Short:       "Save the listed objects and the findings to a tar.gz file",
+	Long: `Save the listed objects and the findings to a tar.gz file.
+
+The snapshot can be checked again later, with different rules, without access to the cluster:
+
+check-conditions snapshot --out dump.tar.gz
+mkdir dump && tar -xzf dump.tar.gz -C dump
+check-conditions all --from-dir dump --config other-rules.yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkconditions.RunSnapshot(arguments)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.Flags().StringVar(&arguments.SnapshotOut, "out", "snapshot.tar.gz", "The tar.gz file of the snapshot")
+	snapshotCmd.Flags().BoolVar(&arguments.SnapshotRedact, "redact", true,
+		"Remove the data of Secrets and ConfigMaps, the last-applied-configuration annotation and the managedFields")
+}
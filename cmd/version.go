@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/guettli/check-conditions/pkg/checkconditions"
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version, git commit, build date, Go version and the version of client-go",
+	Run: func(cmd *cobra.Command, args []string) {
+		checkconditions.RunVersion()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
@@ -10,10 +10,11 @@ import (
 )
 
 var whileCmd = &cobra.Command{
-	Use:   "while your-regex",
-	Short: "Check all conditions of all api-resources, repeat until regex does not match anymore.",
-	Long:  `Check conditions until the give regex does not match anymore.`,
-	Args:  cobra.MatchAll(cobra.MaximumNArgs(1)),
+	Use:         "while your-regex",
+	Annotations: scanAnnotations,
+	Short:       "Check all conditions of all api-resources, repeat until regex does not match anymore.",
+	Long:        `Check conditions until the give regex does not match anymore.`,
+	Args:        cobra.MatchAll(cobra.MaximumNArgs(1)),
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) > 0 {
 			r, err := regexp.Compile(args[0])
@@ -31,4 +32,8 @@ var whileCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(whileCmd)
+	whileCmd.Flags().BoolVar(&arguments.OnlyChanges, "only-changes", false,
+		"Print a condition only if it is new, if it changed or if it is resolved")
+	whileCmd.Flags().DurationVar(&arguments.RenotifyInterval, "renotify-interval", 0,
+		"Print unchanged conditions again after this duration. Only used with --only-changes. 0 means never")
 }
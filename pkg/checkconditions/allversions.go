@@ -0,0 +1,219 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+// crdGVR is the resource of CustomResourceDefinitions.
+var crdGVR = schema.GroupVersionResource{Group: crdGroupKind.Group, Version: "v1", Resource: "customresourcedefinitions"}
+
+// scanAllVersions reports per resource of the groups of --all-versions, whether deprecated API
+// versions are still in use. Every object is served under every version, so listing the objects
+// per version tells nothing. Instead:
+//
+//   - For custom resources status.storedVersions of the CRD tells which versions were used to store
+//     objects in etcd. Deprecated or unserved stored versions need a storage migration.
+//   - For built-in groups the api-server sends a deprecation warning, if a deprecated version gets
+//     used. Each non-preferred version gets listed with a limit of one object.
+//
+// The CRDs get reused from the scan. They only get listed, if the scan did not list them.
+// See needsCRDs.
+func scanAllVersions(args *Arguments, counter *Counter, config *restclient.Config,
+	clientset *kubernetes.Clientset,
+) ([]Finding, []scanError) {
+	groups, err := clientset.Discovery().ServerGroups()
+	if err != nil {
+		return nil, []scanError{newScanError(schema.GroupVersionResource{}, err)}
+	}
+	crds := counter.crds
+	if !counter.listedKinds[crdGroupKind] && args.crds != nil {
+		crds = args.crds
+	} else if !counter.listedKinds[crdGroupKind] {
+		crds, err = listCRDs(config)
+		if err != nil {
+			return nil, []scanError{newScanError(crdGVR, err)}
+		}
+	}
+	var findings []Finding
+	var errs []scanError
+	for _, group := range groups.Groups {
+		if !slices.Contains(args.AllVersions, group.Name) {
+			continue
+		}
+		customResources := false
+		for gr, crd := range crds {
+			if gr.Group != group.Name {
+				continue
+			}
+			customResources = true
+			if f := deprecatedStoredVersionsFinding(crd); f != nil {
+				findings = append(findings, *f)
+			}
+		}
+		if customResources {
+			continue
+		}
+		f, e := deprecatedVersionWarnings(args, config, clientset, group)
+		findings = append(findings, f...)
+		errs = append(errs, e...)
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].ID() < findings[j].ID()
+	})
+	return findings, errs
+}
+
+// listCRDs lists the CustomResourceDefinitions. See collectCRD.
+func listCRDs(config *restclient.Config) (map[schema.GroupResource]*crdInfo, error) {
+	dynClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	list, err := dynClient.Resource(crdGVR).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	output := handleResourceTypeOutput{}
+	for i := range list.Items {
+		collectCRD(crdGVR, &list.Items[i], &output)
+	}
+	return output.crds, nil
+}
+
+// deprecatedStoredVersionsFinding returns a finding for the CRD, if objects may still be stored in
+// deprecated or unserved versions.
+func deprecatedStoredVersionsFinding(crd *crdInfo) *Finding {
+	deprecated := crd.deprecatedStoredVersions()
+	if len(deprecated) == 0 {
+		return nil
+	}
+	message := fmt.Sprintf("status.storedVersions contains deprecated or unserved versions: %s. Migrate the stored objects, then remove the versions from storedVersions",
+		strings.Join(deprecated, ", "))
+	return &Finding{
+		Group:              crdGroupKind.Group,
+		Version:            crdGVR.Version,
+		Resource:           crdGVR.Resource,
+		Kind:               crdGroupKind.Kind,
+		Name:               crd.name,
+		UID:                crd.uid,
+		ConditionType:      "StoredVersions",
+		ConditionStatus:    "Deprecated",
+		ConditionReason:    "DeprecatedStoredVersions",
+		ConditionMessage:   message,
+		Severity:           SeverityWarning,
+		MessageFingerprint: messageFingerprint(message),
+		Code:               CodeDeprecatedStoredVersions,
+		Check:              checkDeprecatedVersions,
+	}
+}
+
+// warningCollector collects the warning headers of the api-server, like "apps/v1beta1 Deployment
+// is deprecated in v1.9+, unavailable in v1.16+".
+type warningCollector struct {
+	mutex    sync.Mutex
+	warnings []string
+}
+
+func (c *warningCollector) HandleWarningHeader(_ int, _ string, text string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.warnings = append(c.warnings, text)
+}
+
+// take returns the collected warnings and forgets them.
+func (c *warningCollector) take() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	warnings := c.warnings
+	c.warnings = nil
+	return warnings
+}
+
+// deprecatedVersionWarnings lists the resources of the non-preferred versions of a built-in group
+// with a limit of one object, and returns a finding per resource, if the api-server warns that
+// the version is deprecated and objects exist.
+func deprecatedVersionWarnings(args *Arguments, config *restclient.Config, clientset *kubernetes.Clientset,
+	group metav1.APIGroup,
+) ([]Finding, []scanError) {
+	collector := &warningCollector{}
+	config = restclient.CopyConfig(config)
+	config.WarningHandler = collector
+	dynClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, []scanError{newScanError(schema.GroupVersionResource{Group: group.Name}, err)}
+	}
+	namespaces := args.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{args.Namespace}
+	}
+	var findings []Finding
+	var errs []scanError
+	for _, gv := range group.Versions {
+		if gv.Version == group.PreferredVersion.Version {
+			continue
+		}
+		resources, err := clientset.Discovery().ServerResourcesForGroupVersion(gv.GroupVersion)
+		if err != nil {
+			errs = append(errs, newScanError(schema.GroupVersionResource{Group: group.Name, Version: gv.Version}, err))
+			continue
+		}
+		for _, r := range resources.APIResources {
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "list") {
+				continue
+			}
+			gvr := schema.GroupVersionResource{Group: group.Name, Version: gv.Version, Resource: r.Name}
+			var warnings []string
+			exist := false
+			for _, namespace := range namespaces {
+				collector.take()
+				list, err := dynClient.Resource(gvr).Namespace(namespace).List(context.TODO(),
+					metav1.ListOptions{Limit: 1, LabelSelector: args.LabelSelector})
+				warnings = append(warnings, collector.take()...)
+				if err != nil {
+					scanErr := newScanError(gvr, err)
+					scanErr.Namespace = namespace
+					errs = append(errs, scanErr)
+					continue
+				}
+				if len(list.Items) > 0 {
+					exist = true
+					break
+				}
+			}
+			if len(warnings) == 0 || !exist {
+				continue
+			}
+			slices.Sort(warnings)
+			warnings = slices.Compact(warnings)
+			message := fmt.Sprintf("objects exist and version %s is deprecated: %s. Use %s in manifests and clients",
+				gv.Version, strings.Join(warnings, "; "), group.PreferredVersion.GroupVersion)
+			findings = append(findings, Finding{
+				Group:              group.Name,
+				Version:            gv.Version,
+				Resource:           r.Name,
+				Kind:               r.Kind,
+				Name:               gv.Version,
+				ConditionType:      "APIVersion",
+				ConditionStatus:    "Deprecated",
+				ConditionReason:    "DeprecatedVersion",
+				ConditionMessage:   message,
+				Severity:           SeverityWarning,
+				MessageFingerprint: messageFingerprint(message),
+				Code:               CodeDeprecatedAPIVersion,
+				Check:              checkDeprecatedVersions,
+			})
+		}
+	}
+	return findings, errs
+}
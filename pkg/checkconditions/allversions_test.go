@@ -0,0 +1,151 @@
+package checkconditions
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+// testCRD returns a CustomResourceDefinition of the group example.com. versions are "name" or
+// "name:deprecated" or "name:unserved".
+func testCRD(plural string, storedVersions []string, versions ...string) *unstructured.Unstructured {
+	specVersions := make([]interface{}, 0, len(versions))
+	for _, v := range versions {
+		name, state, _ := strings.Cut(v, ":")
+		specVersions = append(specVersions, map[string]interface{}{
+			"name": name, "served": state != "unserved", "deprecated": state == "deprecated",
+		})
+	}
+	stored := make([]interface{}, 0, len(storedVersions))
+	for _, v := range storedVersions {
+		stored = append(stored, v)
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "CustomResourceDefinition",
+		"spec": map[string]interface{}{
+			"group":    "example.com",
+			"names":    map[string]interface{}{"plural": plural, "kind": "Widget"},
+			"versions": specVersions,
+		},
+		"status": map[string]interface{}{"storedVersions": stored},
+	}}
+	obj.SetName(plural + ".example.com")
+	return obj
+}
+
+func TestDeprecatedStoredVersions(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		stored []string
+		want   string
+	}{
+		{"current version", []string{"v1"}, ""},
+		{"deprecated version", []string{"v1alpha1", "v1"},
+			"status.storedVersions contains deprecated or unserved versions: v1alpha1. Migrate the stored objects, then remove the versions from storedVersions"},
+		{"unserved version", []string{"v1beta1"},
+			"status.storedVersions contains deprecated or unserved versions: v1beta1. Migrate the stored objects, then remove the versions from storedVersions"},
+		{"removed version", []string{"v0", "v1alpha1"},
+			"status.storedVersions contains deprecated or unserved versions: v0, v1alpha1. Migrate the stored objects, then remove the versions from storedVersions"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			output := &handleResourceTypeOutput{}
+			collectCRD(crdGVR, testCRD("widgets", tc.stored, "v1", "v1alpha1:deprecated", "v1beta1:unserved"), output)
+			f := deprecatedStoredVersionsFinding(output.crds[schema.GroupResource{Group: "example.com", Resource: "widgets"}])
+			switch {
+			case tc.want == "" && f != nil:
+				t.Errorf("unexpected finding %+v", f)
+			case tc.want == "":
+			case f == nil:
+				t.Errorf("no finding, want %q", tc.want)
+			case f.ConditionMessage != tc.want || f.Name != "widgets.example.com" || f.Code != CodeDeprecatedStoredVersions ||
+				f.Check != checkDeprecatedVersions:
+				t.Errorf("got %+v, want message %q", f, tc.want)
+			}
+		})
+	}
+}
+
+// testAllVersionsServer serves the discovery of the group batch with the preferred version v1 and
+// the deprecated version v1beta1, and the group example.com of a CRD. Listing the CronJobs of
+// v1beta1 returns a deprecation warning.
+func testAllVersionsServer(t *testing.T) *httptest.Server {
+	responses := map[string]string{
+		"/api": `{"kind":"APIVersions","versions":["v1"]}`,
+		"/apis": `{"kind":"APIGroupList","groups":[
+			{"name":"batch","versions":[{"groupVersion":"batch/v1","version":"v1"},{"groupVersion":"batch/v1beta1","version":"v1beta1"}],
+			 "preferredVersion":{"groupVersion":"batch/v1","version":"v1"}},
+			{"name":"example.com","versions":[{"groupVersion":"example.com/v1","version":"v1"}],
+			 "preferredVersion":{"groupVersion":"example.com/v1","version":"v1"}}]}`,
+		"/apis/batch/v1beta1": `{"kind":"APIResourceList","groupVersion":"batch/v1beta1","resources":[
+			{"name":"cronjobs","namespaced":true,"kind":"CronJob","verbs":["get","list"]},
+			{"name":"cronjobs/status","namespaced":true,"kind":"CronJob","verbs":["get"]}]}`,
+		"/apis/batch/v1beta1/cronjobs": `{"kind":"CronJobList","apiVersion":"batch/v1beta1","metadata":{},
+			"items":[{"metadata":{"name":"backup","namespace":"default"}}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/apis/batch/v1beta1/cronjobs" {
+			if r.URL.Query().Get("limit") != "1" {
+				t.Errorf("CronJobs listed with limit %q, want 1", r.URL.Query().Get("limit"))
+			}
+			w.Header().Set("Warning", `299 - "batch/v1beta1 CronJob is deprecated in v1.21+, unavailable in v1.25+; use batch/v1 CronJob"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScanAllVersions(t *testing.T) {
+	server := testAllVersionsServer(t)
+	config := &restclient.Config{Host: server.URL}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := &handleResourceTypeOutput{}
+	collectCRD(crdGVR, testCRD("widgets", []string{"v1alpha1", "v1"}, "v1", "v1alpha1:deprecated"), output)
+	counter := &Counter{crds: output.crds, listedKinds: map[schema.GroupKind]bool{crdGroupKind: true}}
+
+	for _, tc := range []struct {
+		name        string
+		allVersions []string
+		want        map[string]string
+	}{
+		{"built-in group", []string{"batch"}, map[string]string{
+			"CronJob v1beta1": "DeprecatedVersion: objects exist and version v1beta1 is deprecated: " +
+				"batch/v1beta1 CronJob is deprecated in v1.21+, unavailable in v1.25+; use batch/v1 CronJob. Use batch/v1 in manifests and clients",
+		}},
+		{"custom resources", []string{"example.com"}, map[string]string{
+			"CustomResourceDefinition widgets.example.com": "DeprecatedStoredVersions: status.storedVersions contains deprecated or unserved versions: " +
+				"v1alpha1. Migrate the stored objects, then remove the versions from storedVersions",
+		}},
+		{"other group", []string{"apps"}, map[string]string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, errs := scanAllVersions(&Arguments{AllVersions: tc.allVersions}, counter, config, clientset)
+			if len(errs) != 0 {
+				t.Errorf("unexpected scan errors %+v", errs)
+			}
+			got := make(map[string]string)
+			for _, f := range findings {
+				got[f.Kind+" "+f.Name] = f.ConditionReason + ": " + f.ConditionMessage
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("got\n%v\nwant\n%v", got, tc.want)
+			}
+		})
+	}
+}
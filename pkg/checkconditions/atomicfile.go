@@ -0,0 +1,51 @@
+package checkconditions
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes the data to a temporary file in the same directory, which gets synced
+// and renamed at the end. So a consumer watching the file never reads a half-written file, and
+// after a crash there is either the old or the new content. The mode of an existing file is kept,
+// new files get 0644.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644) //nolint:gomnd
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return writeFileAtomicMode(path, data, mode)
+}
+
+// writeFileAtomicMode is writeFileAtomic with the mode of the file, also if the file exists.
+// The temporary file has the mode 0600 (see os.CreateTemp) until the data is written.
+func writeFileAtomicMode(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// Sync the directory, so that the rename is durable. Not all platforms support this.
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package checkconditions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/user"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// auditEntry is one line of the audit log. It documents who scanned what and when.
+type auditEntry struct {
+	Time            time.Time `json:"time"`
+	StartTime       time.Time `json:"startTime"`
+	DurationSeconds float64   `json:"durationSeconds"`
+
+	User        string   `json:"user"`
+	KubeUser    string   `json:"kubeUser"`
+	KubeContext string   `json:"kubeContext"`
+	Server      string   `json:"server"`
+	Command     []string `json:"command"`
+
+	// Trigger and RemoteAddr are set for scans requested via the API of serve, like "POST /scan".
+	Trigger    string `json:"trigger,omitempty"`
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+
+	Namespace     string   `json:"namespace,omitempty"`
+	Namespaces    []string `json:"namespaces,omitempty"`
+	LabelSelector string   `json:"labelSelector,omitempty"`
+	Team          string   `json:"team,omitempty"`
+
+	CheckedResourceTypes int32 `json:"checkedResourceTypes"`
+	CheckedResources     int32 `json:"checkedResources"`
+	CheckedConditions    int32 `json:"checkedConditions"`
+	Findings             int   `json:"findings"`
+	CriticalFindings     int   `json:"criticalFindings"`
+
+	ConfigFile string `json:"configFile,omitempty"`
+	ConfigHash string `json:"configHash,omitempty"`
+
+	// ReadOnly is true, if all requests which could change the cluster were rejected (--read-only).
+	ReadOnly bool `json:"readOnly"`
+
+	// NoWrite is true, if nothing was written to other systems (--no-write).
+	NoWrite bool `json:"noWrite,omitempty"`
+}
+
+// hashConfig returns the sha256 of the content of the config file.
+func hashConfig(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// scanTrigger is the API request of serve, which started a scan. It gets written to the audit log.
+type scanTrigger struct {
+	name       string
+	remoteAddr string
+}
+
+// writeAuditLog appends one JSON line for the scan to args.AuditLog.
+func writeAuditLog(args *Arguments, counter *Counter, now time.Time) error {
+	entry := auditEntry{
+		Time:                 now,
+		StartTime:            counter.startTime,
+		DurationSeconds:      now.Sub(counter.startTime).Seconds(),
+		Command:              os.Args,
+		Trigger:              args.trigger.name,
+		RemoteAddr:           args.trigger.remoteAddr,
+		Namespace:            args.Namespace,
+		Namespaces:           args.Namespaces,
+		LabelSelector:        args.LabelSelector,
+		Team:                 args.Team,
+		CheckedResourceTypes: counter.checkedResourceTypes,
+		CheckedResources:     counter.checkedResources,
+		CheckedConditions:    counter.checkedConditions,
+		Findings:             len(counter.findings),
+		ConfigFile:           args.ConfigFile,
+		ConfigHash:           args.configHash,
+		ReadOnly:             args.ReadOnly,
+		NoWrite:              args.NoWrite,
+	}
+	for i := range counter.findings {
+		if counter.findings[i].Severity == SeverityCritical {
+			entry.CriticalFindings++
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		entry.User = u.Username
+	}
+	// The kubeconfig gets read again, so that the audit log contains the current context.
+	rawConfig, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	if err == nil {
+		entry.KubeContext = rawConfig.CurrentContext
+		if kubeContext, ok := rawConfig.Contexts[rawConfig.CurrentContext]; ok {
+			entry.KubeUser = kubeContext.AuthInfo
+			if cluster, ok := rawConfig.Clusters[kubeContext.Cluster]; ok {
+				entry.Server = cluster.Server
+			}
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(args.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gomnd
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,67 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"k8s.io/client-go/discovery"
+	restclient "k8s.io/client-go/rest"
+)
+
+// checkExecPlugin returns a clear error, if the credential plugin of the kubeconfig (like aws,
+// gke-gcloud-auth-plugin or kubelogin) is not installed, or if a removed auth provider is used.
+func checkExecPlugin(config *restclient.Config) error {
+	if p := config.AuthProvider; p != nil {
+		switch p.Name {
+		case "gcp":
+			return fmt.Errorf("the gcp auth provider was removed from client-go. Install gke-gcloud-auth-plugin and update the kubeconfig: gcloud container clusters get-credentials")
+		case "azure":
+			return fmt.Errorf("the azure auth provider was removed from client-go. Install kubelogin and convert the kubeconfig: kubelogin convert-kubeconfig")
+		}
+	}
+	if config.ExecProvider == nil {
+		return nil
+	}
+	if _, err := exec.LookPath(config.ExecProvider.Command); err != nil {
+		msg := fmt.Sprintf("the credential plugin %q of the kubeconfig was not found: %s", config.ExecProvider.Command, err.Error())
+		if config.ExecProvider.InstallHint != "" {
+			msg += "\n" + config.ExecProvider.InstallHint
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
+
+// checkAuth sends a request to the api-server and fails, if it does not finish within the timeout.
+// client-go runs credential plugins without timeout, so a hanging plugin would stall the scan silently.
+// The request gets canceled after the timeout. A hanging plugin can not be canceled, but the
+// channel is buffered, so the goroutine ends as soon as the plugin returns.
+func checkAuth(config *restclient.Config, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- client.RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+	}()
+	select {
+	case err := <-done:
+		if ctx.Err() == nil {
+			return err
+		}
+	case <-ctx.Done():
+	}
+	if config.ExecProvider != nil {
+		return fmt.Errorf("authentication did not finish within --auth-timeout %s. The credential plugin %q hangs",
+			timeout, config.ExecProvider.Command)
+	}
+	return fmt.Errorf("the api-server did not answer within --auth-timeout %s", timeout)
+}
@@ -0,0 +1,199 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+const (
+	minAutoWorkers = 4
+	maxAutoWorkers = 32
+
+	// slowProbe is the latency of the probe request, above which the api-server is considered busy.
+	slowProbe = time.Second
+)
+
+// tuneConcurrency returns the number of workers and a copy of the config with QPS and Burst.
+// Without --workers and --qps they get derived from the size of the cluster (number of nodes and
+// resource types) and from the latency of a probe request.
+func (args *Arguments) tuneConcurrency(config *restclient.Config, clientset *kubernetes.Clientset,
+	resourceTypes int,
+) (int, *restclient.Config) {
+	workers := args.Workers
+	if workers <= 0 {
+		workers = autoWorkers(clientset, resourceTypes, args.verboseLog())
+	}
+	qps := args.QPS
+	if qps <= 0 {
+		qps = float32(workers * 20) //nolint:gomnd
+	}
+	config = restclient.CopyConfig(config)
+	config.QPS = qps
+	config.Burst = int(qps) * 2
+	if args.Verbose {
+		fmt.Fprintf(args.diagnostics(), "Using %d workers, QPS %v\n", workers, qps)
+	}
+	return workers, config
+}
+
+// autoWorkers sizes the worker pool: small clusters get few workers, so that they do not get
+// overloaded. Big clusters (many nodes means many objects) get more workers. The details get
+// written to log, if it is not nil.
+func autoWorkers(clientset *kubernetes.Clientset, resourceTypes int, log io.Writer) int {
+	start := time.Now()
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{Limit: 1})
+	latency := time.Since(start)
+	if err != nil {
+		// No permission to list nodes: only use the resource types.
+		nodes = nil
+	}
+	nodeCount := int64(0)
+	if nodes != nil {
+		nodeCount = int64(len(nodes.Items))
+		if nodes.RemainingItemCount != nil {
+			nodeCount += *nodes.RemainingItemCount
+		}
+	}
+	workers := minAutoWorkers + resourceTypes/20 + int(nodeCount/50) //nolint:gomnd
+	if latency > slowProbe {
+		workers /= 2
+	}
+	if workers < minAutoWorkers {
+		workers = minAutoWorkers
+	}
+	if workers > maxAutoWorkers {
+		workers = maxAutoWorkers
+	}
+	if log != nil {
+		fmt.Fprintf(log, "Auto-tuning: %d nodes, %d resource types, probe latency %s\n", nodeCount, resourceTypes,
+			latency.Round(time.Millisecond))
+	}
+	return workers
+}
+
+// throttleCooldown is the minimum time between two reductions of the limit, so that a burst of
+// 429 responses only halves the limit once. After a reduction the limit does not grow for that time.
+const throttleCooldown = 2 * time.Second
+
+// concurrencyLimiter limits the number of workers which handle a resource type at the same time.
+// The limit follows AIMD: it gets halved if the api-server answers with 429 (Too Many Requests),
+// and it grows by one after limit successful responses, up to the initial limit.
+type concurrencyLimiter struct {
+	mutex        sync.Mutex
+	cond         *sync.Cond
+	limit        int
+	maxLimit     int
+	active       int
+	successes    int
+	lastThrottle time.Time
+	// log receives the changes of the limit. Nil means no messages.
+	log io.Writer
+}
+
+func newConcurrencyLimiter(limit int, log io.Writer) *concurrencyLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	l := &concurrencyLimiter{limit: limit, maxLimit: limit, log: log}
+	l.cond = sync.NewCond(&l.mutex)
+	return l
+}
+
+func (l *concurrencyLimiter) acquire() {
+	l.mutex.Lock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+	l.mutex.Unlock()
+}
+
+// tryAcquire is like acquire, but it does not wait. It returns true if a slot was acquired.
+// A nil limiter does not limit.
+func (l *concurrencyLimiter) tryAcquire() bool {
+	if l == nil {
+		return true
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.active >= l.limit {
+		return false
+	}
+	l.active++
+	return true
+}
+
+func (l *concurrencyLimiter) release() {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	l.active--
+	l.mutex.Unlock()
+	l.cond.Signal()
+}
+
+// throttle halves the limit (multiplicative decrease). The limit does not get smaller than one.
+func (l *concurrencyLimiter) throttle(now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.successes = 0
+	if l.limit <= 1 || now.Sub(l.lastThrottle) < throttleCooldown {
+		return
+	}
+	l.lastThrottle = now
+	l.limit /= 2
+	if l.log != nil {
+		fmt.Fprintf(l.log, "api-server answered 429, reducing concurrency to %d\n", l.limit)
+	}
+}
+
+// succeeded increases the limit by one after limit successful responses (additive increase).
+func (l *concurrencyLimiter) succeeded(now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.limit >= l.maxLimit || now.Sub(l.lastThrottle) < throttleCooldown {
+		return
+	}
+	l.successes++
+	if l.successes < l.limit {
+		return
+	}
+	l.successes = 0
+	l.limit++
+	if l.log != nil {
+		fmt.Fprintf(l.log, "api-server recovered, increasing concurrency to %d\n", l.limit)
+	}
+	l.cond.Broadcast()
+}
+
+// wrapTransport returns a WrapTransport func, which throttles the limiter on 429 responses, and
+// lets it recover on successful responses.
+func (l *concurrencyLimiter) wrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return throttlingRoundTripper{rt: rt, limiter: l}
+}
+
+type throttlingRoundTripper struct {
+	rt      http.RoundTripper
+	limiter *concurrencyLimiter
+}
+
+func (t throttlingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+	switch {
+	case err != nil:
+	case resp.StatusCode == http.StatusTooManyRequests:
+		t.limiter.throttle(time.Now())
+	case resp.StatusCode < http.StatusBadRequest:
+		t.limiter.succeeded(time.Now())
+	}
+	return resp, err
+}
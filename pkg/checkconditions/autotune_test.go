@@ -0,0 +1,108 @@
+package checkconditions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	type step struct {
+		// after is the time since start. throttle is a 429, otherwise n successful responses.
+		after    time.Duration
+		throttle bool
+		n        int
+		want     int
+	}
+	tests := []struct {
+		name  string
+		limit int
+		steps []step
+	}{
+		{
+			name:  "429 halves the limit",
+			limit: 16,
+			steps: []step{{after: 0, throttle: true, want: 8}},
+		},
+		{
+			name:  "429 within the cooldown counts once",
+			limit: 16,
+			steps: []step{
+				{after: 0, throttle: true, want: 8},
+				{after: time.Second, throttle: true, want: 8},
+				{after: throttleCooldown, throttle: true, want: 4},
+			},
+		},
+		{
+			name:  "limit does not get smaller than one",
+			limit: 2,
+			steps: []step{
+				{after: 0, throttle: true, want: 1},
+				{after: throttleCooldown, throttle: true, want: 1},
+			},
+		},
+		{
+			name:  "no increase above the initial limit",
+			limit: 4,
+			steps: []step{{after: 0, n: 100, want: 4}},
+		},
+		{
+			name:  "recovers by one after limit successes",
+			limit: 16,
+			steps: []step{
+				{after: 0, throttle: true, want: 8},
+				{after: time.Second, n: 100, want: 8},
+				{after: throttleCooldown, n: 7, want: 8},
+				{after: throttleCooldown, n: 1, want: 9},
+				{after: throttleCooldown, n: 9, want: 10},
+			},
+		},
+		{
+			name:  "429 resets the successes",
+			limit: 16,
+			steps: []step{
+				{after: 0, throttle: true, want: 8},
+				{after: throttleCooldown, n: 7, want: 8},
+				{after: throttleCooldown, throttle: true, want: 4},
+				{after: 2 * throttleCooldown, n: 3, want: 4},
+				{after: 2 * throttleCooldown, n: 1, want: 5},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newConcurrencyLimiter(tt.limit, nil)
+			for i, s := range tt.steps {
+				now := start.Add(s.after)
+				if s.throttle {
+					l.throttle(now)
+				}
+				for j := 0; j < s.n; j++ {
+					l.succeeded(now)
+				}
+				if l.limit != s.want {
+					t.Fatalf("step %d: limit %d, want %d", i, l.limit, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterTryAcquire(t *testing.T) {
+	var nilLimiter *concurrencyLimiter
+	if !nilLimiter.tryAcquire() {
+		t.Fatal("a nil limiter does not limit")
+	}
+	nilLimiter.release()
+
+	l := newConcurrencyLimiter(2, nil)
+	for i, want := range []bool{true, true, false} {
+		if got := l.tryAcquire(); got != want {
+			t.Fatalf("tryAcquire %d = %t, want %t", i, got, want)
+		}
+	}
+	l.release()
+	if !l.tryAcquire() {
+		t.Fatal("tryAcquire after release failed")
+	}
+}
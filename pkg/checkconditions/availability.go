@@ -0,0 +1,164 @@
+package checkconditions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// availabilityWindows are the time windows of the availability. The longest window must be last,
+// because scans older than it get dropped from memory.
+var availabilityWindows = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// scanRecord is a scan of the history. critical contains the availability keys
+// (see availabilityKey) which had at least one critical finding. partial is true, if the scan did
+// not check all objects of the cluster.
+type scanRecord struct {
+	time     time.Time
+	critical map[string]bool
+	partial  bool
+}
+
+// availability is the ratio of the time without critical findings in a namespace or of a kind.
+type availability struct {
+	Scope  string // "namespace" or "kind"
+	Name   string
+	Window string
+	Ratio  float64
+}
+
+func availabilityKey(scope, name string) string {
+	return scope + "/" + name
+}
+
+// criticalKeys returns the availability keys of the critical findings.
+func criticalKeys(findings []Finding) []string {
+	keys := make(map[string]bool)
+	for i := range findings {
+		f := &findings[i]
+		if f.Severity != SeverityCritical {
+			continue
+		}
+		if f.Namespace != "" {
+			keys[availabilityKey("namespace", f.Namespace)] = true
+		}
+		keys[availabilityKey("kind", f.Kind)] = true
+	}
+	result := make([]string, 0, len(keys))
+	for k := range keys {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// availabilities returns the percentage of the time without critical findings per namespace and per
+// kind. The result of a scan is valid until the next scan, so each scan is weighted by its interval:
+// a scan via POST /scan shortly after a scheduled scan does not count as much as the scheduled
+// scan. Partial scans (--namespace, --kind, scan errors, ...) are ignored, since they did not look
+// at all namespaces and kinds. The interval of the scan before them lasts until the next full scan.
+// Only namespaces and kinds which had critical findings in the longest window are returned. All
+// others have an availability of 100%.
+func (h *history) availabilities(now time.Time) []availability {
+	var scans []scanRecord
+	for _, scan := range h.scans {
+		if !scan.partial {
+			scans = append(scans, scan)
+		}
+	}
+	keys := make(map[string]bool)
+	for _, scan := range scans {
+		for k := range scan.critical {
+			keys[k] = true
+		}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+	var result []availability
+	for _, k := range sortedKeys {
+		scope, name, _ := strings.Cut(k, "/")
+		for _, window := range availabilityWindows {
+			if ratio, ok := availabilityRatio(scans, k, now.Add(-window.duration), now); ok {
+				result = append(result, availability{Scope: scope, Name: name, Window: window.name, Ratio: ratio})
+			}
+		}
+	}
+	return result
+}
+
+// availabilityRatio returns the part of the time between start and end, in which the key had no
+// critical findings. The scans must be sorted by time. If all scans in the window are at its end
+// (the first scan), the result of the last scan is returned. ok is false without scans in the window.
+func availabilityRatio(scans []scanRecord, key string, start, end time.Time) (ratio float64, ok bool) {
+	var total, good time.Duration
+	last := -1
+	for i, scan := range scans {
+		if scan.time.After(end) {
+			break
+		}
+		until := end
+		if i+1 < len(scans) && scans[i+1].time.Before(end) {
+			until = scans[i+1].time
+		}
+		from := scan.time
+		if from.Before(start) {
+			from = start
+		}
+		if !until.After(from) {
+			if !scan.time.Before(start) {
+				last = i
+			}
+			continue
+		}
+		last = i
+		total += until.Sub(from)
+		if !scan.critical[key] {
+			good += until.Sub(from)
+		}
+	}
+	switch {
+	case last < 0:
+		return 0, false
+	case total == 0:
+		if scans[last].critical[key] {
+			return 0, true
+		}
+		return 1, true
+	}
+	return float64(good) / float64(total), true
+}
+
+// pruneScans drops the scans which are older than the longest availability window. The last of
+// them is kept, since its interval reaches into the window.
+func (h *history) pruneScans(now time.Time) {
+	longest := availabilityWindows[len(availabilityWindows)-1].duration
+	i := 0
+	for i+1 < len(h.scans) && now.Sub(h.scans[i+1].time) > longest {
+		i++
+	}
+	h.scans = h.scans[i:]
+}
+
+// availabilityLines returns the availability report, one line per namespace and kind.
+func availabilityLines(availabilities []availability) []string {
+	var lines []string
+	for i := 0; i < len(availabilities); {
+		a := availabilities[i]
+		parts := []string{}
+		for ; i < len(availabilities) && availabilities[i].Scope == a.Scope && availabilities[i].Name == a.Name; i++ {
+			parts = append(parts, fmt.Sprintf("%s=%.2f%%", availabilities[i].Window, availabilities[i].Ratio*100)) //nolint:gomnd
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s %s", a.Scope, a.Name, strings.Join(parts, " ")))
+	}
+	return lines
+}
@@ -0,0 +1,88 @@
+package checkconditions
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAvailabilities(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	type scan struct {
+		ago      time.Duration
+		critical bool
+		partial  bool
+	}
+	tests := []struct {
+		name  string
+		scans []scan
+		want  map[string]float64 // window -> ratio of namespace a; missing windows have no ratio
+	}{
+		{
+			name:  "first scan",
+			scans: []scan{{ago: 0, critical: true}},
+			want:  map[string]float64{"7d": 0, "30d": 0},
+		},
+		{
+			name:  "critical for the last quarter",
+			scans: []scan{{ago: 4 * time.Hour}, {ago: time.Hour, critical: true}},
+			want:  map[string]float64{"7d": 0.75, "30d": 0.75},
+		},
+		{
+			// Two ad-hoc scans right after the critical scan do not outweigh the hours without findings.
+			name: "weighted by interval",
+			scans: []scan{
+				{ago: 4 * time.Hour, critical: true}, {ago: 4*time.Hour - time.Minute}, {ago: 4*time.Hour - 2*time.Minute},
+			},
+			want: map[string]float64{"7d": 1 - 1.0/240, "30d": 1 - 1.0/240},
+		},
+		{
+			// A partial scan did not look at namespace a, the critical finding of the full scan lasts.
+			name:  "partial scans are ignored",
+			scans: []scan{{ago: 2 * time.Hour, critical: true}, {ago: time.Hour, partial: true}},
+			want:  map[string]float64{"7d": 0, "30d": 0},
+		},
+		{
+			name:  "critical finding of a partial scan only",
+			scans: []scan{{ago: 2 * time.Hour}, {ago: time.Hour, critical: true, partial: true}},
+			want:  map[string]float64{},
+		},
+		{
+			// The scan before the 7d window lasts 1 day into it.
+			name:  "scan before the window",
+			scans: []scan{{ago: 8 * 24 * time.Hour, critical: true}, {ago: 6 * 24 * time.Hour}},
+			want:  map[string]float64{"7d": 6.0 / 7, "30d": 6.0 / 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := loadHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.scans {
+				var findings []Finding
+				if s.critical {
+					findings = []Finding{{Namespace: "a", Kind: "Pod", Name: "p1", ConditionType: "Ready", Severity: SeverityCritical}}
+				}
+				if _, err := h.update(findings, 0, now.Add(-s.ago), s.partial, func(*Finding) bool { return !s.partial }); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := make(map[string]float64)
+			for _, a := range h.availabilities(now) {
+				if a.Scope == "namespace" && a.Name == "a" {
+					got[a.Window] = a.Ratio
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for window, want := range tt.want {
+				if ratio, ok := got[window]; !ok || ratio < want-1e-9 || ratio > want+1e-9 {
+					t.Errorf("window %s: ratio %v, want %v", window, got[window], want)
+				}
+			}
+		})
+	}
+}
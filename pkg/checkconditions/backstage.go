@@ -0,0 +1,214 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// BackstageConfig configures pushing the health of Backstage entities to Backstage.
+type BackstageConfig struct {
+	// URL is the endpoint which receives the health status, for example a Backstage backend plugin
+	// or a route of the Backstage proxy: https://backstage.example.com/api/proxy/check-conditions/health
+	URL string `json:"url"`
+	// TokenEnv is the name of the environment variable containing the bearer token. Defaults to BACKSTAGE_TOKEN.
+	TokenEnv string `json:"tokenEnv"`
+	// Label is the label (or annotation) of objects and namespaces which contains the entity.
+	// Defaults to backstage.io/kubernetes-id, which is used by the Backstage Kubernetes plugin.
+	Label string `json:"label"`
+	// Kind and Namespace of the entities, if the label contains only the name. Defaults to component and default.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	// Cluster is sent with the status, so that several clusters can push to one Backstage.
+	Cluster string `json:"cluster"`
+}
+
+// backstageHealthy is the status of entities without findings.
+const backstageHealthy = "healthy"
+
+// backstageEntityStatus is the health of one entity. The status is "healthy" or the highest severity of its findings.
+type backstageEntityStatus struct {
+	EntityRef string    `json:"entityRef"`
+	Status    string    `json:"status"`
+	Findings  []Finding `json:"findings"`
+}
+
+// backstageStatus is the body which gets posted to BackstageConfig.URL after each scan.
+type backstageStatus struct {
+	Cluster  string                  `json:"cluster,omitempty"`
+	ScanTime time.Time               `json:"scanTime"`
+	Entities []backstageEntityStatus `json:"entities"`
+}
+
+type backstageSink struct {
+	config *BackstageConfig
+	header http.Header
+}
+
+func newBackstageSink(config *BackstageConfig) *backstageSink {
+	if config.TokenEnv == "" {
+		config.TokenEnv = "BACKSTAGE_TOKEN"
+	}
+	header := make(http.Header)
+	if token := os.Getenv(config.TokenEnv); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return &backstageSink{config: config, header: header}
+}
+
+// applyDefaults gets called when the config file is read, since the label is needed during the scan.
+func (c *BackstageConfig) applyDefaults() {
+	if c.Label == "" {
+		c.Label = "backstage.io/kubernetes-id"
+	}
+	if c.Kind == "" {
+		c.Kind = "component"
+	}
+	if c.Namespace == "" {
+		c.Namespace = "default"
+	}
+}
+
+func (s *backstageSink) name() string {
+	return "Backstage"
+}
+
+// backstageObject is an object with the label of an entity.
+type backstageObject struct {
+	id        string
+	namespace string
+}
+
+// send posts the status of all entities which were found in the cluster. Entities without
+// findings are healthy, so that Backstage shows when a problem is resolved. After a partial scan
+// only the entities with findings are sent, since the others are not necessarily healthy. The
+// entities in the namespaces of a team with backstageURL get posted to that URL.
+func (s *backstageSink) send(ctx context.Context, args *Arguments, counter *Counter) error {
+	entities := map[string]map[string]*backstageEntityStatus{s.config.URL: {}}
+	for i := range args.Config.Teams {
+		if url := args.Config.Teams[i].BackstageURL; url != "" {
+			entities[url] = make(map[string]*backstageEntityStatus)
+		}
+	}
+	entity := func(namespace, id string) *backstageEntityStatus {
+		url := s.config.URL
+		if team := teamConfigOf(args.Config, counter.namespaces, namespace); team != nil && team.BackstageURL != "" {
+			url = team.BackstageURL
+		}
+		ref := s.config.entityRef(id)
+		e, ok := entities[url][ref]
+		if !ok {
+			e = &backstageEntityStatus{EntityRef: ref, Status: backstageHealthy, Findings: []Finding{}}
+			entities[url][ref] = e
+		}
+		return e
+	}
+	if closeResolved(args, counter, s.name()) {
+		for _, obj := range counter.backstageIDs {
+			entity(obj.namespace, obj.id)
+		}
+		for name, ns := range counter.namespaces {
+			if id := s.config.namespaceEntity(ns); id != "" {
+				entity(name, id)
+			}
+		}
+	}
+	for i := range counter.findings {
+		f := &counter.findings[i]
+		if f.Maintenance != "" {
+			continue
+		}
+		id := backstageEntityOf(s.config, counter, f)
+		if id == "" {
+			continue
+		}
+		e := entity(f.Namespace, id)
+		e.Findings = append(e.Findings, *f)
+		if e.Status == backstageHealthy || severityRank[f.Severity] > severityRank[e.Status] {
+			e.Status = f.Severity
+		}
+	}
+	urls := make([]string, 0, len(entities))
+	for url := range entities {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	for _, url := range urls {
+		status := backstageStatus{
+			Cluster:  s.config.Cluster,
+			ScanTime: counter.startTime,
+			Entities: []backstageEntityStatus{},
+		}
+		for _, e := range entities[url] {
+			status.Entities = append(status.Entities, *e)
+		}
+		sort.Slice(status.Entities, func(i, j int) bool {
+			return status.Entities[i].EntityRef < status.Entities[j].EntityRef
+		})
+		if err := doJSON(ctx, http.MethodPost, url, s.header, status, nil); err != nil {
+			return err
+		}
+		fmt.Fprintf(args.diagnostics(), "Sent the status of %d entities to Backstage %s\n", len(status.Entities), url)
+	}
+	return nil
+}
+
+// entityRef returns the Backstage entity reference (kind:namespace/name) of the label value.
+// The label value can be a name, or a complete entity reference.
+func (c *BackstageConfig) entityRef(id string) string {
+	kind, rest := c.Kind, id
+	if i := strings.Index(rest, ":"); i >= 0 {
+		kind, rest = rest[:i], rest[i+1:]
+	}
+	namespace, name := c.Namespace, rest
+	if i := strings.Index(rest, "/"); i >= 0 {
+		namespace, name = rest[:i], rest[i+1:]
+	}
+	return strings.ToLower(kind) + ":" + namespace + "/" + name
+}
+
+// namespaceEntity returns the entity of the namespace, or "".
+func (c *BackstageConfig) namespaceEntity(ns namespaceMeta) string {
+	if id := ns.labels[c.Label]; id != "" {
+		return id
+	}
+	return ns.annotations[c.Label]
+}
+
+// backstageEntityOf returns the entity of the finding: the label of the object, of one of its owners,
+// or of its namespace. "" means the finding does not belong to an entity.
+func backstageEntityOf(config *BackstageConfig, counter *Counter, f *Finding) string {
+	uid := f.UID
+	seen := make(map[types.UID]bool)
+	for uid != "" && !seen[uid] {
+		seen[uid] = true
+		if obj, ok := counter.backstageIDs[uid]; ok {
+			return obj.id
+		}
+		node, ok := counter.owners[uid]
+		if !ok || node.owner == nil {
+			break
+		}
+		uid = node.owner.UID
+	}
+	return config.namespaceEntity(counter.namespaces[f.Namespace])
+}
+
+// collectBackstageID stores the entity of the object, if it has the label.
+func collectBackstageID(args *Arguments, obj *unstructured.Unstructured, output *handleResourceTypeOutput) {
+	config := args.Config.Backstage
+	id := obj.GetLabels()[config.Label]
+	if id == "" {
+		id = obj.GetAnnotations()[config.Label]
+	}
+	if id != "" {
+		output.backstageIDs[obj.GetUID()] = backstageObject{id: id, namespace: obj.GetNamespace()}
+	}
+}
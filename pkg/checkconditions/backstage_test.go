@@ -0,0 +1,126 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestBackstageEntityRef(t *testing.T) {
+	config := &BackstageConfig{}
+	config.applyDefaults()
+	for id, want := range map[string]string{
+		"web":                      "component:default/web",
+		"shop/web":                 "component:shop/web",
+		"Resource:shop/db":         "resource:shop/db",
+		"system:platform":          "system:default/platform",
+		"component:default/search": "component:default/search",
+	} {
+		if got := config.entityRef(id); got != want {
+			t.Errorf("entityRef(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+// testBackstageCounter returns a counter with a critical and a warning finding of pods of the
+// Deployment web (entity "web"), a warning of a pod in the namespace of the entity "shop", and the
+// entity "search" without findings.
+func testBackstageCounter() *Counter {
+	controller := true
+	owners := ownerIndex{
+		"uid-deploy": {Kind: "Deployment", Namespace: "shop", Name: "web"},
+		"uid-web-1":  {Kind: "Pod", Namespace: "shop", Name: "web-1", owner: &metav1.OwnerReference{UID: "uid-deploy", Controller: &controller}},
+		"uid-web-2":  {Kind: "Pod", Namespace: "shop", Name: "web-2", owner: &metav1.OwnerReference{UID: "uid-deploy", Controller: &controller}},
+	}
+	finding := func(name string, uid types.UID, severity string) Finding {
+		return Finding{
+			Namespace: "shop", Version: "v1", Resource: "pods", Kind: "Pod", Name: name, UID: uid,
+			ConditionType: "Ready", ConditionStatus: "False", Severity: severity,
+		}
+	}
+	return &Counter{
+		owners: owners,
+		backstageIDs: map[types.UID]backstageObject{
+			"uid-deploy": {id: "web", namespace: "shop"},
+			"uid-search": {id: "search", namespace: "shop"},
+		},
+		namespaces: map[string]namespaceMeta{"shop": {labels: map[string]string{"backstage.io/kubernetes-id": "shop"}}},
+		findings: []Finding{
+			finding("web-1", "uid-web-1", SeverityWarning),
+			finding("web-2", "uid-web-2", SeverityCritical),
+			finding("debug", "uid-debug", SeverityWarning),
+		},
+	}
+}
+
+func TestBackstageSink(t *testing.T) {
+	server, posts := recordingServer(t, func(string) string { return `` })
+	config := &Config{Backstage: &BackstageConfig{URL: server.URL + "/health", Cluster: "prod"}}
+	config.Backstage.applyDefaults()
+	sink := newBackstageSink(config.Backstage)
+
+	send := func(args *Arguments) map[string]backstageEntityStatus {
+		t.Helper()
+		before := len(posts()["POST /health"])
+		if err := sink.send(t.Context(), args, testBackstageCounter()); err != nil {
+			t.Fatal(err)
+		}
+		got := posts()["POST /health"]
+		if len(got) != before+1 {
+			t.Fatalf("got %d requests, want 1", len(got)-before)
+		}
+		var status backstageStatus
+		if err := json.Unmarshal([]byte(got[len(got)-1]), &status); err != nil {
+			t.Fatal(err)
+		}
+		if status.Cluster != "prod" {
+			t.Errorf("cluster %q, want prod", status.Cluster)
+		}
+		entities := make(map[string]backstageEntityStatus)
+		for _, e := range status.Entities {
+			entities[e.EntityRef] = e
+		}
+		return entities
+	}
+
+	entities := send(&Arguments{Config: config})
+	want := map[string]string{
+		"component:default/web":    SeverityCritical,
+		"component:default/shop":   SeverityWarning,
+		"component:default/search": backstageHealthy,
+	}
+	if len(entities) != len(want) {
+		t.Errorf("got entities %+v, want %v", entities, want)
+	}
+	for ref, status := range want {
+		if entities[ref].Status != status {
+			t.Errorf("%s: status %q, want %q", ref, entities[ref].Status, status)
+		}
+	}
+	if n := len(entities["component:default/web"].Findings); n != 2 {
+		t.Errorf("entity web has %d findings, want the findings of both pods", n)
+	}
+
+	// After a partial scan, entities without findings are not necessarily healthy.
+	entities = send(&Arguments{Config: config, Namespace: "shop"})
+	if _, ok := entities["component:default/search"]; ok || len(entities) != 2 {
+		t.Errorf("partial scan sent %+v, want only the entities with findings", entities)
+	}
+}
+
+func TestBackstageSinkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "backend unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	config := &Config{Backstage: &BackstageConfig{URL: server.URL}}
+	config.Backstage.applyDefaults()
+	err := newBackstageSink(config.Backstage).send(t.Context(), &Arguments{Config: config}, testBackstageCounter())
+	if err == nil {
+		t.Fatal("no error, although Backstage returned 503")
+	}
+}
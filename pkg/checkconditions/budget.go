@@ -0,0 +1,116 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// ExitCodeMaxObjects is the exit code, if the scan was stopped because --max-objects was reached.
+const ExitCodeMaxObjects = 3
+
+// skipReasonMaxObjects is the reason for resource types which were not (completely) listed because of --max-objects.
+const skipReasonMaxObjects = "max-objects reached"
+
+// skipReasonSlow is the reason for resource types which were not listed within --per-type-timeout.
+const skipReasonSlow = "slow"
+
+// skipReasonMaxDuration is the reason for resource types which were not listed because of --max-duration.
+const skipReasonMaxDuration = "max-duration reached"
+
+// pageSize is the number of objects per LIST call, if the objects are fetched in pages.
+const pageSize = 500
+
+// objectBudget counts the fetched objects of one scan. All workers share it.
+type objectBudget struct {
+	max     int64
+	fetched atomic.Int64
+}
+
+// newObjectBudget returns nil if max is zero (no limit).
+func newObjectBudget(max int64) *objectBudget {
+	if max <= 0 {
+		return nil
+	}
+	return &objectBudget{max: max}
+}
+
+func (b *objectBudget) exhausted() bool {
+	return b != nil && b.fetched.Load() >= b.max
+}
+
+func (b *objectBudget) add(n int) {
+	if b != nil {
+		b.fetched.Add(int64(n))
+	}
+}
+
+// skippedResourceType is a resource type which was not (completely) checked.
+type skippedResourceType struct {
+	gvr    schema.GroupVersionResource
+	reason string
+}
+
+// listPages lists the objects. With a budget, the objects get fetched in pages, and no further
+// page gets fetched if the budget is exhausted. Then the reason is returned, together with the
+// objects fetched so far.
+func listPages(ctx context.Context, client dynamic.ResourceInterface, args *Arguments) (*unstructured.UnstructuredList, string, error) {
+	opts := metav1.ListOptions{LabelSelector: args.LabelSelector}
+	if args.budget == nil {
+		list, err := client.List(ctx, opts)
+		return list, "", err
+	}
+	opts.Limit = pageSize
+	result := &unstructured.UnstructuredList{}
+	for {
+		if args.budget.exhausted() {
+			return result, skipReasonMaxObjects, nil
+		}
+		page, err := client.List(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		result.Items = append(result.Items, page.Items...)
+		args.budget.add(len(page.Items))
+		if page.GetContinue() == "" {
+			return result, "", nil
+		}
+		opts.Continue = page.GetContinue()
+	}
+}
+
+func sortSkipped(skipped []skippedResourceType) {
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].gvr.String() < skipped[j].gvr.String()
+	})
+}
+
+// printSkipped prints the resource types which were not (completely) checked.
+func printSkipped(w io.Writer, skipped []skippedResourceType) {
+	for _, s := range skipped {
+		fmt.Fprintf(w, "  skipped %s %s %s: %s\n", s.gvr.Resource, s.gvr.Group, s.gvr.Version, s.reason)
+	}
+}
+
+// maxObjectsReached returns true if resource types were skipped because of --max-objects.
+func (c *Counter) maxObjectsReached() bool {
+	return c.skippedCount(skipReasonMaxObjects) > 0
+}
+
+// skippedCount returns the number of resource types which were skipped for the reason.
+func (c *Counter) skippedCount(reason string) int {
+	n := 0
+	for _, s := range c.skipped {
+		if s.reason == reason {
+			n++
+		}
+	}
+	return n
+}
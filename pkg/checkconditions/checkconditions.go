@@ -2,7 +2,10 @@ package checkconditions
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"regexp"
 	"strings"
@@ -12,7 +15,9 @@ import (
 	"golang.org/x/exp/slices"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -21,10 +26,198 @@ import (
 )
 
 type Arguments struct {
-	Verbose      bool
-	WhileRegex   *regexp.Regexp
-	WhileForever bool
-	StartTime    time.Time
+	Verbose          bool
+	WhileRegex       *regexp.Regexp
+	WhileForever     bool
+	StartTime        time.Time
+	OnlyChanges      bool
+	RenotifyInterval time.Duration
+	GroupBy          string
+	OwnerChain       bool
+	AttachEvents     bool
+	Sections         bool
+	Dedup            bool
+	SortBy           []string
+	NoColor          bool
+	ConfigFile       string
+	// ConfigResource is the name of a ClusterCheckConfig, which serve reads instead of the config file.
+	ConfigResource   string
+	Config           *Config
+	Team             string
+	Textfile         string
+	HistoryFile      string
+	HistoryRetention time.Duration
+	AuditLog         string
+	ErrorsFile       string
+	Output           string
+	OutputFile       string
+	OutputFileFormat string
+	Quiet            bool
+	GracePeriod      time.Duration
+	Plan             bool
+	MaxObjects       int64
+	PerTypeTimeout   time.Duration
+	MaxDuration      time.Duration
+	MaxListsPerGroup int
+	Workers          int
+	PerType          bool
+	QPS              float32
+	Profile          string
+	Strict           bool
+	ExitPolicy       string
+	SummaryJSON      bool
+	SummaryFile      string
+	ReadOnly         bool
+	NoWrite          bool
+	FromDir          string
+	WriteConfig      string
+
+	// FlagChanged returns true if the command-line flag was set explicitly. Profiles only change
+	// settings whose flags were not set. Nil means no flag was set.
+	FlagChanged func(name string) bool
+
+	// Resources restricts the scan to these resource types. Resource names, kinds and short names
+	// are resolved like kubectl does: "deploy", "Deployment", "deployments.apps", "deployments.v1.apps".
+	Resources []string
+
+	// AllVersions contains API groups, whose resources get checked for deprecated versions in use.
+	// See scanAllVersions.
+	AllVersions []string
+
+	// EnableChecks and DisableChecks contain IDs or globs of checks. See checkDefinitions.
+	EnableChecks  []string
+	DisableChecks []string
+
+	// EmptyStatusAge, StaleReconcileAge and StaleLeaseAge are the thresholds of the checks emptystatus,
+	// stalereconcile and staleleases.
+	EmptyStatusAge    time.Duration
+	StaleReconcileAge time.Duration
+	StaleLeaseAge     time.Duration
+
+	// TerminatingMargin is the time a pod may need longer than its grace period. See check terminatingpods.
+	TerminatingMargin time.Duration
+
+	// PodThresholds maps Failed, Evicted and Completed to the maximum number of these pods per namespace.
+	// See check podaccumulation.
+	PodThresholds map[string]int
+
+	// ObjectSizeThreshold is the size in bytes of the serialized object. See check objectsize.
+	ObjectSizeThreshold int
+
+	// UnownedSecretPatterns are globs for names of Secrets, which get reported if they are large
+	// and have no owner. See check orphanedsecrets.
+	UnownedSecretPatterns []string
+
+	// CertificateAuthority, InsecureSkipTLSVerify and ProxyURL override the values of the kubeconfig.
+	CertificateAuthority  string
+	InsecureSkipTLSVerify bool
+	ProxyURL              string
+
+	// TokenFile contains the bearer token. It gets re-read, so rotated service-account tokens work.
+	// TokenAudiences are the audiences, the token needs to be issued for.
+	TokenFile      string
+	TokenAudiences []string
+
+	// AuthTimeout limits the time for authentication. Credential plugins are run without timeout by client-go.
+	AuthTimeout time.Duration
+
+	RemoteWriteURL             string
+	RemoteWriteBearerTokenFile string
+	RemoteWriteHeaders         []string
+
+	// OTLPEndpoint is the base URL of an OpenTelemetry collector (OTLP/HTTP). See exportOTLP.
+	OTLPEndpoint string
+
+	// PushGateway is the URL of the Prometheus Pushgateway. The metrics get pushed for the job PushGatewayJob.
+	PushGateway    string
+	PushGatewayJob string
+
+	// PushGatewayObjectNames adds the name of the object as label to the findings pushed to the Pushgateway.
+	PushGatewayObjectNames bool
+
+	// Listen, GRPCListen, Interval, Schedule and Jitter are used by the serve command.
+	// Schedule is a cron expression. It replaces Interval.
+	Listen     string
+	GRPCListen string
+	Interval   time.Duration
+	Schedule   string
+	Jitter     time.Duration
+	Informers  bool
+
+	// GRPCTLSCert and GRPCTLSKey enable TLS for the gRPC server. GRPCClientCA requires client
+	// certificates signed by the CA, GRPCTokenFile a bearer token. See grpcServerOptions.
+	GRPCTLSCert   string
+	GRPCTLSKey    string
+	GRPCClientCA  string
+	GRPCTokenFile string
+
+	// HTTPTLSCert, HTTPTLSKey, HTTPClientCA and HTTPTokenFile are the same for the HTTP server.
+	// See httpHandler.
+	HTTPTLSCert   string
+	HTTPTLSKey    string
+	HTTPClientCA  string
+	HTTPTokenFile string
+
+	// Namespace limits the scan to one namespace. Cluster-scoped resources are skipped then.
+	// AllNamespaces scans all namespaces, like without Namespace. It must not be combined with it.
+	Namespace     string
+	AllNamespaces bool
+	LabelSelector string
+
+	// Namespaces limits the scan to several namespaces. Cluster-scoped resources are skipped then.
+	// Each namespace gets listed on its own, at most NamespaceConcurrency at once. See listNamespaces.
+	Namespaces           []string
+	NamespaceConcurrency int
+
+	// SnapshotOut is the tar.gz file of the snapshot command. SnapshotRedact removes the data of
+	// Secrets and ConfigMaps from it.
+	SnapshotOut    string
+	SnapshotRedact bool
+
+	configHash string
+	// rules are the condition rules of the config file. See conditionRules.
+	rules *conditionRuleSet
+	// clusterName is the name of the scanned cluster. See newRestConfig.
+	clusterName string
+	notifier    *notifier
+	// sinkNotifiers contain a notifier per sink (by name), so that the sinks only send new and
+	// changed findings. Used by serve and by --only-changes. Nil means all findings get sent.
+	sinkNotifiers map[string]*notifier
+	history       *history
+	sinks         []sink
+	grafana       *grafanaAnnotator
+	informers     *informerCache
+	budget        *objectBudget
+	groupLimit    *groupLimiter
+	limiter       *concurrencyLimiter
+	snapshot      *snapshotCollector
+
+	// crds are listed at the start of the scan, if needsCRDs returns true.
+	crds map[schema.GroupResource]*crdInfo
+
+	// trigger is set for the scans of the API of serve. See writeAuditLog.
+	trigger scanTrigger
+
+	// streamNamespaces contains the namespaces for the filters of streamFindings.
+	streamNamespaces map[string]namespaceMeta
+
+	// color is true, if the text output gets colored. See useColor.
+	color bool
+
+	// stdout receives the report: the text output, or the structured output (--output json, ...)
+	// if there is no --output-file. stderr receives warnings and other messages. See reportWriter
+	// and diagnostics.
+	stdout io.Writer
+	stderr io.Writer
+
+	// deadline is set from MaxDuration at the start of a scan. Resource types get skipped after it.
+	deadline time.Time
+
+	// inventory enables counting all conditions. See RunInventory.
+	inventory bool
+
+	// enabledChecks is set by Validate. Nil means the checks which are enabled by default.
+	enabledChecks map[string]bool
 }
 
 var resourcesToSkip = []string{
@@ -44,21 +237,258 @@ type Counter struct {
 	checkedResourceTypes int32
 	startTime            time.Time
 	checkAgain           bool
-	lines                []string
+	findings             []Finding
+	owners               ownerIndex
+	namespaces           map[string]namespaceMeta
+	backstageIDs         map[types.UID]backstageObject
+	nodeTopology         map[string]nodeTopology
+	podNodes             map[types.UID]string
+	skipped              []skippedResourceType
+	errors               []scanError
+	listedKinds          map[schema.GroupKind]bool
+	ownerRefs            []ownerRefCandidate
+	staleObjects         []staleCandidate
+
+	// podsOnNodes counts the pods per node, which are not finished.
+	podsOnNodes map[string]int
+
+	// terminatingPods and nodeReady are used by the check terminatingpods.
+	terminatingPods []terminatingPod
+	nodeReady       map[string]string
+
+	// finishedPods counts the failed, evicted and completed pods per namespace.
+	finishedPods map[podCategoryKey]int
+
+	// orphanCandidates and orphanOwners are used by the check orphanedsecrets. orphanOwners
+	// contains "GroupKind namespace/name" of Helm releases and Certificates.
+	orphanCandidates []orphanCandidate
+	orphanOwners     []string
+
+	// statefulSets, pvcs and usedPVCs ("namespace/name") are used by the check orphanedpvcs.
+	statefulSets []statefulSetInfo
+	pvcs         []pvcInfo
+	usedPVCs     []string
+
+	// configObjects and configRefs ("Kind namespace/name") are used by the check unreferenced.
+	configObjects []configObject
+	configRefs    []string
+
+	// hpas and replicas ("Kind.group namespace/name") are used by the check hpatargets.
+	hpas     []hpaInfo
+	replicas map[string]int64
+
+	// networkPolicies and podLabels (per namespace) are used by the check networkpolicies.
+	networkPolicies []networkPolicyInfo
+	podLabels       map[string][]labels.Set
+
+	// classes ("Kind.group name") and classRefs are used by the check classrefs.
+	classes   []string
+	classRefs []classReference
+
+	// imagePullFailures is used by the check imagepulls.
+	imagePullFailures []imagePullFailure
+
+	// duplicateConditions counts the custom resources with duplicate conditions per CRD.
+	duplicateConditions map[schema.GroupResource]int
+
+	// crds contains the CustomResourceDefinitions by the resource they define. See collectCRD.
+	crds map[schema.GroupResource]*crdInfo
+
+	// spans contain the work per resource type. They are used by --per-type and by exportOTLP.
+	spans []resourceTypeSpan
+
+	// clusterID is the UID of the namespace kube-system. See reportScope. clusterIDError is set, if
+	// it could not be read.
+	clusterID      types.UID
+	clusterIDError string
+
+	// unknownConditionTypes counts the conditions of unknown types. Only set with --strict.
+	unknownConditionTypes map[string]int
+
+	// inventory counts all conditions. See RunInventory.
+	inventory inventory
+
+	// pending are the findings which are held back, because of --grace-period.
+	pending []Finding
+
+	// score is the health score of the findings. See healthScore.
+	score int
+
+	// availabilities is set after the scan, if there is a history file.
+	availabilities []availability
 }
 
 func (c *Counter) add(o handleResourceTypeOutput) {
 	c.checkedResources += o.checkedResources
 	c.checkedConditions += o.checkedConditions
 	c.checkedResourceTypes += o.checkedResourceTypes
-	c.lines = append(c.lines, o.lines...)
+	c.findings = append(c.findings, o.findings...)
+	c.skipped = append(c.skipped, o.skipped...)
+	c.errors = append(c.errors, o.errors...)
+	c.ownerRefs = append(c.ownerRefs, o.ownerRefs...)
+	c.staleObjects = append(c.staleObjects, o.staleObjects...)
+	c.terminatingPods = append(c.terminatingPods, o.terminatingPods...)
+	c.orphanCandidates = append(c.orphanCandidates, o.orphanCandidates...)
+	c.orphanOwners = append(c.orphanOwners, o.orphanOwners...)
+	c.statefulSets = append(c.statefulSets, o.statefulSets...)
+	c.pvcs = append(c.pvcs, o.pvcs...)
+	c.usedPVCs = append(c.usedPVCs, o.usedPVCs...)
+	c.configObjects = append(c.configObjects, o.configObjects...)
+	c.configRefs = append(c.configRefs, o.configRefs...)
+	c.hpas = append(c.hpas, o.hpas...)
+	c.networkPolicies = append(c.networkPolicies, o.networkPolicies...)
+	c.classes = append(c.classes, o.classes...)
+	c.classRefs = append(c.classRefs, o.classRefs...)
+	c.imagePullFailures = append(c.imagePullFailures, o.imagePullFailures...)
+	c.spans = append(c.spans, o.spans...)
+	if o.clusterID != "" {
+		c.clusterID = o.clusterID
+	}
+	for gr, n := range o.duplicateConditions {
+		c.duplicateConditions[gr] += n
+	}
+	for gr, crd := range o.crds {
+		c.crds[gr] = crd
+	}
+	for ns, sets := range o.podLabels {
+		c.podLabels[ns] = append(c.podLabels[ns], sets...)
+	}
+	for key, n := range o.replicas {
+		c.replicas[key] = n
+	}
+	for node, status := range o.nodeReady {
+		c.nodeReady[node] = status
+	}
+	for key, n := range o.finishedPods {
+		c.finishedPods[key] += n
+	}
+	for node, n := range o.podsOnNodes {
+		c.podsOnNodes[node] += n
+	}
+	c.inventory.merge(o.inventory)
+	for _, t := range o.unknownConditionTypes {
+		c.unknownConditionTypes[t]++
+	}
+	if o.listedKind != nil {
+		c.listedKinds[*o.listedKind] = true
+	}
+	for uid, node := range o.owners {
+		c.owners[uid] = node
+	}
+	for name, ns := range o.namespaces {
+		c.namespaces[name] = ns
+	}
+	for uid, obj := range o.backstageIDs {
+		c.backstageIDs[uid] = obj
+	}
+	for node, t := range o.nodeTopology {
+		c.nodeTopology[node] = t
+	}
+	for uid, node := range o.podNodes {
+		c.podNodes[uid] = node
+	}
 	if o.checkAgain {
 		c.checkAgain = true
 	}
 }
 
+// Validate checks the arguments which were set via command-line flags.
+func (args *Arguments) Validate() error {
+	if err := args.applyProfile(); err != nil {
+		return err
+	}
+	if err := args.validateNoWrite(); err != nil {
+		return err
+	}
+	if args.GroupBy != "" && !slices.Contains(GroupByValues, args.GroupBy) {
+		return fmt.Errorf("invalid value for --group-by: %q. Valid values: %s", args.GroupBy,
+			strings.Join(GroupByValues, ", "))
+	}
+	if args.AllNamespaces && args.namespaceLimited() {
+		return fmt.Errorf("--all-namespaces can not be combined with --namespace or --namespaces")
+	}
+	if args.Namespace != "" && len(args.Namespaces) > 0 {
+		return fmt.Errorf("--namespace can not be combined with --namespaces")
+	}
+	if args.ExitPolicy != "" && args.Config.exitPolicy(args.ExitPolicy) == nil {
+		return fmt.Errorf("invalid value for --exit-policy: %q is not in exitPolicies of the config file, and not one of %s",
+			args.ExitPolicy, strings.Join(builtinExitPolicyNames(), ", "))
+	}
+	if err := validateSortBy(args.SortBy); err != nil {
+		return err
+	}
+	if args.Output != "" && !slices.Contains(OutputValues, args.Output) {
+		return fmt.Errorf("invalid value for --output: %q. Valid values: %s", args.Output,
+			strings.Join(OutputValues, ", "))
+	}
+	if args.OutputFileFormat != "" && (!slices.Contains(OutputValues, args.OutputFileFormat) ||
+		args.OutputFileFormat == OutputText || args.OutputFileFormat == OutputWide) {
+		return fmt.Errorf("invalid value for --output-file-format: %q. Valid values: %s", args.OutputFileFormat,
+			strings.Join(OutputValues[2:], ", "))
+	}
+	for _, h := range args.RemoteWriteHeaders {
+		if err := validateRemoteWriteHeader(h); err != nil {
+			return fmt.Errorf("invalid value for --remote-write-header: %w", err)
+		}
+	}
+	if rw := args.Config.Serve.RemoteWrite; rw != nil {
+		for _, h := range rw.Headers {
+			if err := validateRemoteWriteHeader(h); err != nil {
+				return fmt.Errorf("%s: serve.remoteWrite.headers: %w", args.configSource(), err)
+			}
+		}
+	}
+	if args.PushGateway != "" {
+		if u, err := url.Parse(args.PushGateway); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid value for --push-gateway: %q. Example: http://pushgateway:9091", args.PushGateway)
+		}
+	}
+	if args.ProxyURL != "" {
+		if u, err := url.Parse(args.ProxyURL); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid value for --proxy-url: %q. Example: http://proxy.example.com:3128", args.ProxyURL)
+		}
+	}
+	if err := args.resolveChecks(); err != nil {
+		return err
+	}
+	if err := args.resolvePodThresholds(); err != nil {
+		return err
+	}
+	if args.Team != "" && args.Config.team(args.Team) == nil && args.Team != noTeam {
+		return fmt.Errorf("unknown team %q. Teams need to be defined in the config file", args.Team)
+	}
+	return nil
+}
+
+// needsOwnerIndex returns true if the ownerReferences of all resource objects need to be collected.
+// The Backstage sink uses the owners to find the entity of a finding.
+func (args *Arguments) needsOwnerIndex() bool {
+	return args.GroupBy == GroupByOwner || args.OwnerChain || args.checkEnabled(checkOwnerRefs) ||
+		(args.Config != nil && args.Config.Backstage != nil)
+}
+
 func RunAll(args Arguments) {
+	if args.Plan {
+		RunPlan(args)
+		return
+	}
 	args.StartTime = time.Now()
+	args.color = args.useColor()
+	if args.Config == nil {
+		args.Config = &Config{}
+	}
+	// Only the findings go to stdout, so that a structured output can be piped to jq.
+	// Warnings and other messages go to stderr.
+	args.stdout, args.stderr = os.Stdout, os.Stderr
+	if args.OnlyChanges {
+		args.notifier = newNotifier(args.RenotifyInterval)
+		args.sinkNotifiers = make(map[string]*notifier)
+	}
+	if err := args.loadHistory(); err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	args.createWriters(args.diagnostics())
 	for {
 		if RunAllOnce(args) {
 			continue
@@ -69,25 +499,14 @@ func RunAll(args Arguments) {
 
 // RunAllOnce returns true if command should run again.
 func RunAllOnce(args Arguments) bool {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
-	kubeconfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-
-	config, err := kubeconfig.ClientConfig()
+	config, err := newRestConfig(&args)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(args.diagnostics(), err.Error())
 		os.Exit(1)
 	}
-
-	// 80 concurrent requests were served in roughly 200ms
-	// This means 400 requests in one second (to local kind cluster)
-	// But why reduce this? I don't want people with better hardware
-	// to wait for getting results from an api-server running at localhost
-	config.QPS = 1000
-	config.Burst = 1000
 	checkAgain, err := RunCheckAllConditions(config, args)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(args.diagnostics(), err.Error())
 		os.Exit(1)
 	}
 	durationInt := int(time.Since(args.StartTime).Seconds())
@@ -95,11 +514,11 @@ func RunAllOnce(args Arguments) bool {
 	durationStr := time.Duration(durationInt * int(time.Second)).String()
 	if !(args.WhileForever || checkAgain) {
 		if args.WhileRegex != nil {
-			fmt.Printf("Regex %q did not match. Stopping\n", args.WhileRegex.String())
+			fmt.Fprintf(args.diagnostics(), "Regex %q did not match. Stopping\n", args.WhileRegex.String())
 		}
 
 		if durationInt > 5 { //nolint:gomnd
-			fmt.Printf("Stopping after %s\n", durationStr)
+			fmt.Fprintf(args.diagnostics(), "Stopping after %s\n", durationStr)
 		}
 		return false
 	}
@@ -108,7 +527,7 @@ func RunAllOnce(args Arguments) bool {
 	if args.WhileRegex != nil {
 		pre = fmt.Sprintf("Regex %q did match. ", args.WhileRegex.String())
 	}
-	fmt.Printf("%sWaiting %d seconds, then checking again. %s (%s).\n\n",
+	fmt.Fprintf(args.diagnostics(), "%sWaiting %d seconds, then checking again. %s (%s).\n\n",
 		pre,
 		sleepSeconds,
 		time.Now().Format("2006-01-02 15:04:05 -0700 MST"),
@@ -117,28 +536,184 @@ func RunAllOnce(args Arguments) bool {
 	return true
 }
 
+func newRestConfig(args *Arguments) (*restclient.Config, error) {
+	if args.FromDir != "" {
+		return snapshotRestConfig(args)
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+	configOverrides.ClusterInfo.CertificateAuthority = args.CertificateAuthority
+	configOverrides.ClusterInfo.InsecureSkipTLSVerify = args.InsecureSkipTLSVerify
+	// Without ProxyURL, HTTPS_PROXY, HTTP_PROXY and NO_PROXY of the environment are used.
+	configOverrides.ClusterInfo.ProxyURL = args.ProxyURL
+	kubeconfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+
+	config, err := kubeconfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	args.clusterName = kubeconfigClusterName(kubeconfig)
+	if args.Config != nil && args.Config.Cluster != "" {
+		args.clusterName = args.Config.Cluster
+	}
+	if args.TokenFile != "" {
+		// client-go re-reads the file every minute, and after the api-server responded with 401.
+		config.BearerToken = ""
+		config.BearerTokenFile = args.TokenFile
+		config.ExecProvider = nil
+		config.AuthProvider = nil
+	}
+	if err := checkExecPlugin(config); err != nil {
+		return nil, err
+	}
+	if len(args.TokenAudiences) > 0 {
+		if config.BearerTokenFile == "" {
+			return nil, fmt.Errorf("--token-audience needs --token-file, or a kubeconfig with tokenFile")
+		}
+		if err := checkTokenAudience(config.BearerTokenFile, args.TokenAudiences); err != nil {
+			return nil, err
+		}
+	}
+
+	// 80 concurrent requests were served in roughly 200ms
+	// This means 400 requests in one second (to local kind cluster)
+	// But why reduce this? I don't want people with better hardware
+	// to wait for getting results from an api-server running at localhost
+	config.QPS = 1000
+	config.Burst = 1000
+	if args.ReadOnly {
+		config.Wrap(wrapReadOnly)
+	}
+	return config, nil
+}
+
 func RunCheckAllConditions(config *restclient.Config, args Arguments) (bool, error) {
-	clientset, err := kubernetes.NewForConfig(config)
+	if args.grafana != nil {
+		args.grafana.start(time.Now())
+	}
+	counter, err := scan(config, &args)
 	if err != nil {
-		panic(err.Error())
+		return false, err
+	}
+	// The history gets updated first, so that the findings contain firstSeen and lastSeen.
+	historyResolved := updateHistory(&args, counter)
+	findings := counter.findings
+	// The exit policy counts all findings, even if --only-changes does not print them again.
+	policyFindings := findings
+	var resolved []string
+	if args.notifier != nil {
+		findings, resolved = args.notifier.filter(findings, time.Now(), counter.resolvable(&args))
+	}
+	if args.notifier == nil {
+		resolved = historyResolved
+	}
+	slices.Sort(resolved)
+	if !args.structuredStdout() {
+		printText(args.reportWriter(), &args, findings, resolved, counter)
+	}
+	afterScan(&args, counter)
+	if args.structuredStdout() && args.Output != OutputNDJSON {
+		// ndjson was streamed during the scan.
+		if err := writeReport(args.reportWriter(), args.Output, newReport(&args, counter, findings)); err != nil {
+			return false, err
+		}
+	}
+	if args.OutputFile != "" {
+		if err := writeOutputFile(args.OutputFile, args.fileFormat(), newReport(&args, counter, findings)); err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: %s\n", err.Error())
+		}
+	}
+	if !args.Quiet {
+		fmt.Fprintf(args.diagnostics(), "Checked %d conditions of %d resources of %d types. Duration: %s\n",
+			counter.checkedConditions, counter.checkedResources, counter.checkedResourceTypes, time.Since(counter.startTime).Round(time.Millisecond))
 	}
+	if n := counter.skippedCount(skipReasonMaxDuration); n > 0 {
+		fmt.Fprintf(args.diagnostics(), "Partial result: --max-duration %s was reached, %d resource types were skipped\n", args.MaxDuration, n)
+	}
+	exitCode := 0
+	if counter.maxObjectsReached() {
+		fmt.Fprintf(args.diagnostics(), "Stopping: --max-objects %d was reached\n", args.MaxObjects)
+		exitCode = ExitCodeMaxObjects
+	} else if !(args.WhileForever || counter.checkAgain) {
+		exitCode = exitPolicyCode(&args, policyFindings, counter)
+	}
+	writeSummary(&args, counter, policyFindings, exitCode)
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+	return counter.checkAgain, nil
+}
 
-	dynClient, err := dynamic.NewForConfig(config)
+// printText prints the findings, the resolved findings and the details of the scan as text.
+// With --quiet only the findings get printed.
+func printText(w io.Writer, args *Arguments, findings []Finding, resolved []string, counter *Counter) {
+	findings, ephemeral := splitEphemeral(findings)
+	if args.Sections {
+		printSections(w, args, findings, resolved, counter)
+	} else {
+		printFindings(w, args, findings, counter)
+		if args.Quiet {
+			resolved = nil
+		}
+		for _, line := range resolved {
+			fmt.Fprintln(w, line)
+		}
+	}
+	if len(ephemeral) > 0 {
+		fmt.Fprintf(w, "== Ephemeral namespaces (%d)\n", len(ephemeral))
+		printFindings(w, args, ephemeral, counter)
+	}
+	if args.Quiet {
+		return
+	}
+	if args.PerType {
+		printPerType(w, counter.spans)
+	}
+	if len(counter.availabilities) > 0 {
+		fmt.Fprintln(w, "Time without critical findings:")
+		for _, line := range availabilityLines(counter.availabilities) {
+			fmt.Fprintln(w, line)
+		}
+	}
+	if !args.Sections {
+		printSkipped(w, counter.skipped)
+	}
+	printUnknownConditionTypes(w, counter.unknownConditionTypes)
+	if len(counter.pending) > 0 && !args.Sections {
+		fmt.Fprintf(w, "%d findings are pending, because their condition changed less than %s ago\n", len(counter.pending), args.GracePeriod)
+		if args.Verbose {
+			for _, line := range findingsLines(args, counter.pending, counter.owners, "  ") {
+				fmt.Fprintln(w, line)
+			}
+		}
+	}
+}
+
+// scan checks all resource objects and returns the result.
+func scan(config *restclient.Config, args *Arguments) (*Counter, error) {
+	if err := checkAuth(config, args.AuthTimeout); err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		panic(err.Error())
+		return nil, err
 	}
 
-	discoveryClient := clientset.Discovery()
+	serverResources, discoveryErrors, err := discoverResources(clientset, args.Resources, args.diagnostics())
+	if err != nil {
+		return nil, err
+	}
 
-	// Get the list of all API resources available
-	serverResources, err := discoveryClient.ServerPreferredResources()
+	resourceTypes := 0
+	for _, list := range serverResources {
+		resourceTypes += len(list.APIResources)
+	}
+	workers, config := args.tuneConcurrency(config, clientset, resourceTypes)
+	limiter := newConcurrencyLimiter(workers, args.verboseLog())
+	config.Wrap(limiter.wrapTransport)
+	dynClient, err := dynamic.NewForConfig(config)
 	if err != nil {
-		if discovery.IsGroupDiscoveryFailedError(err) {
-			fmt.Printf("WARNING: The Kubernetes server has an orphaned API service. Server reports: %s\n", err.Error())
-			fmt.Printf("WARNING: To fix this, kubectl delete apiservice <service-name>\n")
-		} else {
-			panic(err)
-		}
+		return nil, err
 	}
 
 	jobs := make(chan handleResourceTypeInput)
@@ -149,59 +724,246 @@ func RunCheckAllConditions(config *restclient.Config, args Arguments) (bool, err
 	// Without: 320ms
 	// With 10 or more workers: 190ms
 
-	createWorkers(&wg, jobs, results)
+	createWorkers(&wg, jobs, results, workers, limiter)
+
+	counter := &Counter{
+		errors:                discoveryErrors,
+		startTime:             time.Now(),
+		listedKinds:           make(map[schema.GroupKind]bool),
+		unknownConditionTypes: make(map[string]int),
+		inventory:             make(inventory),
+		owners:                make(ownerIndex),
+		namespaces:            make(map[string]namespaceMeta),
+		backstageIDs:          make(map[types.UID]backstageObject),
+		nodeTopology:          make(map[string]nodeTopology),
+		podNodes:              make(map[types.UID]string),
+		duplicateConditions:   make(map[schema.GroupResource]int),
+		crds:                  make(map[schema.GroupResource]*crdInfo),
+		podsOnNodes:           make(map[string]int),
+		nodeReady:             make(map[string]string),
+		finishedPods:          make(map[podCategoryKey]int),
+		replicas:              make(map[string]int64),
+		podLabels:             make(map[string][]labels.Set),
+	}
 
-	counter := Counter{startTime: time.Now()}
+	if args.Output == OutputNDJSON && args.structuredStdout() && args.needsNamespaceMetas() {
+		args.streamNamespaces, err = fetchNamespaceMetas(context.TODO(), clientset, args)
+		if err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: reading namespaces for ndjson failed, teams, maintenance windows and ephemeral namespaces do not match: %s\n",
+				err.Error())
+		}
+	}
 
+	done := make(chan struct{})
 	go func() {
 		for result := range results {
+			redactFindings(args.Config.Redactions, result.findings)
+			streamFindings(args, result.findings)
 			counter.add(result)
 		}
+		close(done)
 	}()
 
-	createJobs(serverResources, jobs, args, dynClient)
+	scanArgs := *args
+	scanArgs.budget = newObjectBudget(args.MaxObjects)
+	scanArgs.groupLimit = newGroupLimiter(args.MaxListsPerGroup)
+	scanArgs.limiter = limiter
+	if args.needsCRDs() {
+		scanArgs.crds, err = listCRDs(config)
+		if err != nil {
+			counter.errors = append(counter.errors, newScanError(crdGVR, err))
+		}
+	}
+	if args.MaxDuration > 0 {
+		scanArgs.deadline = counter.startTime.Add(args.MaxDuration)
+	}
+	createJobs(serverResources, jobs, scanArgs, dynClient)
 
 	close(jobs)
 	wg.Wait()
 	close(results)
-	slices.Sort(counter.lines)
-	for _, line := range counter.lines {
-		fmt.Println(line)
+	<-done
+	if counter.clusterID == "" {
+		counter.clusterID, counter.clusterIDError = getClusterID(clientset)
 	}
-	fmt.Printf("Checked %d conditions of %d resources of %d types. Duration: %s\n",
-		counter.checkedConditions, counter.checkedResources, counter.checkedResourceTypes, time.Since(counter.startTime).Round(time.Millisecond))
-	return counter.checkAgain, nil
+	if args.needsNamespaceMetas() && !counter.listedKinds[schema.GroupKind{Kind: "Namespace"}] {
+		addNamespaceMetas(args, counter, clientset)
+	}
+	if len(args.AllVersions) > 0 && args.checkEnabled(checkDeprecatedVersions) {
+		findings, errs := scanAllVersions(&scanArgs, counter, config, clientset)
+		redactFindings(args.Config.Redactions, findings)
+		streamFindings(args, findings)
+		counter.findings = append(counter.findings, findings...)
+		counter.errors = append(counter.errors, errs...)
+	}
+	for _, c := range args.enabledScanChecks() {
+		findings := c.scan(args, counter)
+		redactFindings(args.Config.Redactions, findings)
+		streamFindings(args, findings)
+		counter.findings = append(counter.findings, findings...)
+	}
+	addTopology(counter.findings, counter.nodeTopology, counter.podNodes)
+	markTerminatingOwners(counter.findings, counter.owners)
+	if args.AttachEvents {
+		attachEvents(context.TODO(), args, counter.findings, clientset)
+	}
+	sortSkipped(counter.skipped)
+	writeScanErrors(args, counter.errors)
+	holdBackPending(counter, args.GracePeriod, time.Now())
+	markMaintenance(args.Config, counter, time.Now())
+	markEphemeral(args.Config, counter)
+	applyTeam(args, counter)
+	sortFindings(counter.findings, args.SortBy)
+	sortFindings(counter.pending, args.SortBy)
+	counter.score, _ = healthScore(args.Config.Score, counter.findings)
+	return counter, nil
 }
 
+func (args *Arguments) loadHistory() error {
+	if args.HistoryFile == "" {
+		return nil
+	}
+	h, err := loadHistory(args.HistoryFile)
+	if err != nil {
+		return fmt.Errorf("failed to read history file: %w", err)
+	}
+	args.history = h
+	return nil
+}
+
+// updateHistory writes the result of the scan to the history file, and returns the lines
+// of the findings which are resolved since the last scan.
+func updateHistory(args *Arguments, counter *Counter) []string {
+	if args.history == nil {
+		return nil
+	}
+	now := time.Now()
+	resolved, err := args.history.update(counter.findings, counter.score, now, counter.partialReason(args) != "", counter.resolvable(args))
+	if err != nil {
+		fmt.Fprintf(args.diagnostics(), "WARNING: writing history file failed: %s\n", err.Error())
+		return nil
+	}
+	args.history.addSeen(counter.findings)
+	args.pruneHistory(now)
+	counter.availabilities = args.history.availabilities(now)
+	lines := make([]string, 0, len(resolved))
+	for i := range resolved {
+		lines = append(lines, resolved[i].String())
+	}
+	return lines
+}
+
+// afterScan sends the result of a scan to all configured destinations, except stdout.
+func afterScan(args *Arguments, counter *Counter) {
+	sendToSinks(args, counter)
+	if args.grafana != nil {
+		args.grafana.finish(counter)
+	}
+	if args.Textfile != "" {
+		if err := writeTextfile(args.Textfile, counter); err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: writing textfile failed: %s\n", err.Error())
+		}
+	}
+	if args.RemoteWriteURL != "" {
+		if err := pushRemoteWrite(args, counter); err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: %s\n", err.Error())
+		}
+	}
+	if args.PushGateway != "" {
+		if err := pushGateway(args, counter); err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: %s\n", err.Error())
+		}
+	}
+	if args.otlpEndpoint() != "" {
+		if err := exportOTLP(args, counter, time.Now()); err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: %s\n", err.Error())
+		}
+	}
+	if args.AuditLog != "" {
+		if err := writeAuditLog(args, counter, time.Now()); err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: writing audit log failed: %s\n", err.Error())
+		}
+	}
+}
+
+// discoverResources returns the preferred resources of all groups, and the group versions which
+// failed as scan errors. If resources are given (like "deploy" or "deployments.v1.apps"), only
+// these get returned. See selectResources.
+func discoverResources(clientset *kubernetes.Clientset, resources []string, log io.Writer) ([]*metav1.APIResourceList, []scanError, error) {
+	serverResources, err := clientset.Discovery().ServerPreferredResources()
+	var errs []scanError
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, nil, err
+		}
+		fmt.Fprintf(log, "WARNING: The Kubernetes server has an orphaned API service. Server reports: %s\n", err.Error())
+		fmt.Fprintf(log, "WARNING: To fix this, kubectl delete apiservice <service-name>\n")
+		errs = discoveryErrors(err)
+	}
+	if len(resources) > 0 {
+		serverResources, err = selectResources(clientset.Discovery(), serverResources, resources)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return serverResources, errs, nil
+}
+
+// createJobs sends one job per resource type. The jobs of the API groups get interleaved, so that
+// the workers do not work on the resource types of one API group at the same time.
 func createJobs(serverResources []*metav1.APIResourceList, jobs chan handleResourceTypeInput, args Arguments, dynClient *dynamic.DynamicClient) {
+	var groups []string
+	groupJobs := make(map[string][]handleResourceTypeInput)
 	for _, resourceList := range serverResources {
 		groupVersion, err := schema.ParseGroupVersion(resourceList.GroupVersion)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to parse group version: %v\n", err)
 			continue
 		}
+		if _, ok := groupJobs[groupVersion.Group]; !ok {
+			groups = append(groups, groupVersion.Group)
+		}
 		for i := range resourceList.APIResources {
-			jobs <- handleResourceTypeInput{
-				args:      &args,
-				dynClient: dynClient,
+			groupJobs[groupVersion.Group] = append(groupJobs[groupVersion.Group], handleResourceTypeInput{
+				args:       &args,
+				dynClient:  dynClient,
+				namespaced: resourceList.APIResources[i].Namespaced,
+				kind:       resourceList.APIResources[i].Kind,
+				verbs:      resourceList.APIResources[i].Verbs,
 				gvr: schema.GroupVersionResource{
 					Group:    groupVersion.Group,
 					Version:  groupVersion.Version,
 					Resource: resourceList.APIResources[i].Name,
 				},
+			})
+		}
+	}
+	for i := 0; len(groups) > 0; i++ {
+		var remaining []string
+		for _, group := range groups {
+			if i >= len(groupJobs[group]) {
+				continue
 			}
+			jobs <- groupJobs[group][i]
+			remaining = append(remaining, group)
 		}
+		groups = remaining
 	}
 }
 
-func createWorkers(wg *sync.WaitGroup, jobs chan handleResourceTypeInput, results chan handleResourceTypeOutput) {
-	for i := 0; i < 10; i++ {
+func createWorkers(wg *sync.WaitGroup, jobs chan handleResourceTypeInput, results chan handleResourceTypeOutput,
+	workers int, limiter *concurrencyLimiter,
+) {
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go func(workerID int32) {
 			defer wg.Done()
 			for input := range jobs {
 				input.workerID = workerID
-				results <- handleResourceType(input)
+				limiter.acquire()
+				output := handleResourceType(input)
+				limiter.release()
+				results <- output
 			}
 		}(int32(i))
 	}
@@ -214,30 +976,64 @@ func containsSlash(s string) bool {
 // printResources returns true if the conditions should get checked again N seconds later.
 func printResources(args *Arguments, list *unstructured.UnstructuredList, gvr schema.GroupVersionResource,
 	counter *handleResourceTypeOutput, workerID int32,
-) (lines []string, again bool) {
+) (findings []Finding, again bool) {
 	for _, obj := range list.Items {
 		counter.checkedResources++
-		var conditions []interface{}
-		var err error
-		if gvr.Resource == "hetznerbaremetalhosts" {
-			// For some reasons this resource stores the conditions differently
-			conditions, _, err = unstructured.NestedSlice(obj.Object, "spec", "status", "conditions")
-		} else {
-			conditions, _, err = unstructured.NestedSlice(obj.Object, "status", "conditions")
+		if args.needsOwnerIndex() {
+			counter.owners[obj.GetUID()] = newOwnerNode(&obj)
 		}
-		if err != nil {
-			panic(err)
+		if gvr.Group == "" && gvr.Resource == "namespaces" {
+			counter.namespaces[obj.GetName()] = newNamespaceMeta(&obj)
+			if obj.GetName() == "kube-system" {
+				counter.clusterID = obj.GetUID()
+			}
+		}
+		if gvr.Group == "" {
+			collectTopology(&obj, gvr.Resource, counter)
 		}
-		subLines, a := printConditions(args, conditions, counter, gvr, obj)
-		if a {
-			again = true
+		collectCRD(gvr, &obj, counter)
+		if args.Config != nil && args.Config.Backstage != nil {
+			collectBackstageID(args, &obj, counter)
 		}
-		lines = append(lines, subLines...)
+		first := len(findings)
+		for _, c := range args.enabledObjectChecks() {
+			subFindings := c.object(args, gvr, &obj, counter)
+			if matchesWhileRegex(args, subFindings) {
+				again = true
+			}
+			findings = append(findings, subFindings...)
+		}
+		if args.checkEnabled(checkConditions) {
+			subFindings, a := checkObjectConditions(args, gvr, obj, counter)
+			if a {
+				again = true
+			}
+			findings = append(findings, subFindings...)
+		}
+		if args.checkEnabled(checkImagePulls) {
+			findings = append(findings[:first], withoutImagePullFindings(gvr, &obj, findings[first:])...)
+		}
+		setCreationTimestamp(findings[first:], &obj)
 	}
 	if args.Verbose {
-		fmt.Printf("    checked %s %s %s workerID=%d\n", gvr.Resource, gvr.Group, gvr.Version, workerID)
+		fmt.Fprintf(args.diagnostics(), "    checked %s %s %s workerID=%d\n", gvr.Resource, gvr.Group, gvr.Version, workerID)
+	}
+	return findings, again
+}
+
+// checkObjectConditions checks the conditions of the object. See printConditions.
+func checkObjectConditions(args *Arguments, gvr schema.GroupVersionResource, obj unstructured.Unstructured,
+	counter *handleResourceTypeOutput,
+) (findings []Finding, again bool) {
+	conditions, err := objectConditions(args, gvr, &obj)
+	if err != nil {
+		fmt.Fprintf(args.diagnostics(), "WARNING: %s\n", err.Error())
+		return nil, false
+	}
+	if args.inventory {
+		counter.inventory.addConditions(gvr, obj.GetKind(), conditions)
 	}
-	return lines, again
+	return printConditions(args, conditions, counter, gvr, obj)
 }
 
 type conditionRow struct {
@@ -253,10 +1049,10 @@ var readyString = "Ready"
 // printConditions returns true if the conditions should be checked again N seconds later.
 func printConditions(args *Arguments, conditions []interface{}, counter *handleResourceTypeOutput,
 	gvr schema.GroupVersionResource, obj unstructured.Unstructured,
-) (lines []string, again bool) {
+) (findings []Finding, again bool) {
 	var rows []conditionRow
 	for _, condition := range conditions {
-		rows = handleCondition(condition, counter, gvr, rows)
+		rows = handleCondition(args, condition, counter, gvr, rows)
 	}
 	// remove general ready condition, if it is already contained in a particular condition
 	// https://pkg.go.dev/sigs.k8s.io/cluster-api/util/conditions#SetSummary
@@ -285,43 +1081,60 @@ func printConditions(args *Arguments, conditions []interface{}, counter *handleR
 		if skipReadyCondition && r.conditionType == readyString {
 			continue
 		}
-		duration := ""
-		if !r.conditionLastTransitionTime.IsZero() {
-			d := time.Since(r.conditionLastTransitionTime)
-			duration = fmt.Sprint(d.Round(time.Second))
+		f := Finding{
+			Namespace:          obj.GetNamespace(),
+			Group:              gvr.Group,
+			Version:            gvr.Version,
+			Resource:           gvr.Resource,
+			Kind:               obj.GetKind(),
+			Name:               obj.GetName(),
+			UID:                obj.GetUID(),
+			ConditionType:      r.conditionType,
+			ConditionStatus:    r.conditionStatus,
+			ConditionReason:    r.conditionReason,
+			ConditionMessage:   r.conditionMessage,
+			LastTransitionTime: r.conditionLastTransitionTime,
+			Severity:           conditionSeverity(args.conditionRules(), gvr.Resource, r.conditionType, r.conditionStatus),
+			MessageFingerprint: messageFingerprint(r.conditionMessage),
+			Code:               conditionCode(r.conditionStatus),
+			Check:              checkConditions,
 		}
-		outLine := fmt.Sprintf("  %s %s %s Condition %s=%s %s %q (%s)", obj.GetNamespace(), gvr.Resource, obj.GetName(), r.conditionType, r.conditionStatus,
-			r.conditionReason, r.conditionMessage, duration)
-		lines = append(lines, outLine)
+		findings = append(findings, f)
 		if args.WhileRegex != nil {
-			if args.WhileRegex.MatchString(outLine) {
+			if args.WhileRegex.MatchString(f.String()) {
 				again = true
 			}
 		}
 	}
-	return lines, again
+	return mergeDuplicateTypes(findings), again
 }
 
-func handleCondition(condition interface{}, counter *handleResourceTypeOutput, gvr schema.GroupVersionResource, rows []conditionRow) []conditionRow {
+func handleCondition(args *Arguments, condition interface{}, counter *handleResourceTypeOutput, gvr schema.GroupVersionResource, rows []conditionRow) []conditionRow {
 	conditionMap, ok := condition.(map[string]interface{})
 	if !ok {
-		fmt.Println("Invalid condition format")
+		fmt.Fprintln(args.diagnostics(), "Invalid condition format")
 		return rows
 	}
 	counter.checkedConditions++
 
 	conditionType, _ := conditionMap["type"].(string)
 	conditionStatus, _ := conditionMap["status"].(string)
-	if conditionToSkip(conditionType) {
+	if conditionToSkip(conditionType) || slices.Contains(args.conditionRules().skip[gvr.Resource], conditionType) {
 		return rows
 	}
-	switch conditionStatus {
-	case "True":
-		if conditionTypeHasPositiveMeaning(gvr.Resource, conditionType) {
+	// In strict mode no heuristics get used. Conditions of unknown types get reported with any status.
+	unknown := args.Strict && !conditionTypeKnown(args.conditionRules(), gvr.Resource, conditionType)
+	if unknown {
+		counter.unknownConditionTypes = append(counter.unknownConditionTypes, gvr.GroupResource().String()+" "+conditionType)
+	}
+	switch {
+	case unknown:
+	case conditionStatus == "True":
+		if conditionTypeHasPositiveMeaning(args.conditionRules(), gvr.Resource, conditionType) {
 			return rows
 		}
-	case "False":
-		if conditionTypeHasNegativeMeaning(gvr.Resource, conditionType) {
+	case conditionStatus == "False":
+		if conditionTypeHasNegativeMeaning(args.conditionRules(), gvr.Resource, conditionType) {
 			return rows
 		}
 	}
@@ -348,6 +1161,22 @@ func handleCondition(condition interface{}, counter *handleResourceTypeOutput, g
 	return rows
 }
 
+// conditionSeverity returns SeverityCritical if the condition clearly says that something is broken:
+// A condition with positive meaning is False, or a condition with negative meaning is True.
+func conditionSeverity(rules *conditionRuleSet, resource string, conditionType string, conditionStatus string) string {
+	switch conditionStatus {
+	case "False":
+		if conditionTypeHasPositiveMeaning(rules, resource, conditionType) {
+			return SeverityCritical
+		}
+	case "True":
+		if conditionTypeHasNegativeMeaning(rules, resource, conditionType) {
+			return SeverityCritical
+		}
+	}
+	return SeverityWarning
+}
+
 func conditionToSkip(ct string) bool {
 	// Skip conditions which can be True or False, and both values are fine.
 	toSkip := []string{
@@ -394,6 +1223,10 @@ var conditionTypesOfResourceWithPositiveMeaning = map[string][]string{
 	},
 }
 
+// conditionTypesOfResourceToSkip contains condition types which can be True or False, and both values are fine.
+// The config file can add more via conditionRules, see newConditionRuleSet.
+var conditionTypesOfResourceToSkip = map[string][]string{}
+
 var conditionTypesOfResourceWithNegativeMeaning = map[string][]string{
 	"nodes": {
 		"KernelDeadlock",
@@ -427,41 +1260,13 @@ var conditionLinesToIgnoreRegexs = []*regexp.Regexp{
 	regexp.MustCompile(`volumes Restore=False`),
 }
 
-func conditionTypeHasPositiveMeaning(resource string, ct string) bool {
-	types := conditionTypesOfResourceWithPositiveMeaning[resource]
+func conditionTypeHasPositiveMeaning(rules *conditionRuleSet, resource string, ct string) bool {
+	types := rules.positive[resource]
 	if slices.Contains(types, ct) {
 		return true
 	}
 
-	for _, suffix := range []string{
-		"Applied",
-		"Approved",
-		"Available",
-		"Built",
-		"Complete",
-		"Created",
-		"Downloaded",
-		"Established",
-		"Healthy",
-		"Initialized",
-		"Installed",
-		"LoadBalancerAttached",
-		"NamesAccepted",
-		"Passed",
-		"PodScheduled",
-		"Progressing",
-		"ProviderUpgraded",
-		"Provisioned",
-		"Reachable",
-		"Ready",
-		"Reconciled",
-		"RemediationAllowed",
-		"Resized",
-		"Succeeded",
-		"Synced",
-		"UpToDate",
-		"Valid",
-	} {
+	for _, suffix := range positiveSuffixes {
 		if strings.HasSuffix(ct, suffix) {
 			return true
 		}
@@ -476,6 +1281,37 @@ func conditionTypeHasPositiveMeaning(resource string, ct string) bool {
 	return false
 }
 
+// positiveSuffixes are suffixes of condition types with positive meaning.
+var positiveSuffixes = []string{
+	"Applied",
+	"Approved",
+	"Available",
+	"Built",
+	"Complete",
+	"Created",
+	"Downloaded",
+	"Established",
+	"Healthy",
+	"Initialized",
+	"Installed",
+	"LoadBalancerAttached",
+	"NamesAccepted",
+	"Passed",
+	"PodScheduled",
+	"Progressing",
+	"ProviderUpgraded",
+	"Provisioned",
+	"Reachable",
+	"Ready",
+	"Reconciled",
+	"RemediationAllowed",
+	"Resized",
+	"Succeeded",
+	"Synced",
+	"UpToDate",
+	"Valid",
+}
+
 func conditionDone(conditionType string, conditionStatus string, conditionReason string) bool {
 	// machinesets demo-1-md-0-q9qzp-6gsw9 Condition MachinesReady=False Deleted @ Machine/demo-1-md-0-q9qzp-6gsw9-vkxrp ""
 	// The reason contains "@ ...". We need to split that
@@ -492,15 +1328,13 @@ func conditionDone(conditionType string, conditionStatus string, conditionReason
 	return false
 }
 
-func conditionTypeHasNegativeMeaning(resource string, ct string) bool {
-	types := conditionTypesOfResourceWithNegativeMeaning[resource]
+func conditionTypeHasNegativeMeaning(rules *conditionRuleSet, resource string, ct string) bool {
+	types := rules.negative[resource]
 	if slices.Contains(types, ct) {
 		return true
 	}
 
-	for _, suffix := range []string{
-		"Unavailable", "Pressure", "Dangling", "Unhealthy",
-	} {
+	for _, suffix := range negativeSuffixes {
 		if strings.HasSuffix(ct, suffix) {
 			return true
 		}
@@ -512,11 +1346,46 @@ func conditionTypeHasNegativeMeaning(resource string, ct string) bool {
 	return false
 }
 
+// printUnknownConditionTypes prints the condition types which are unknown without heuristics (--strict).
+func printUnknownConditionTypes(w io.Writer, unknown map[string]int) {
+	if len(unknown) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(unknown))
+	for k := range unknown {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	fmt.Fprintln(w, "Unknown condition types (no rule, only heuristics):")
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s (%d conditions)\n", k, unknown[k])
+	}
+}
+
+// negativeSuffixes are suffixes of condition types with negative meaning.
+var negativeSuffixes = []string{
+	"Unavailable", "Pressure", "Dangling", "Unhealthy",
+}
+
+// conditionTypeKnown returns true if the meaning of the condition type is known without heuristics:
+// The type is listed for the resource, or it is equal to one of the suffixes.
+func conditionTypeKnown(rules *conditionRuleSet, resource string, ct string) bool {
+	return conditionToSkip(ct) ||
+		slices.Contains(rules.skip[resource], ct) ||
+		slices.Contains(rules.positive[resource], ct) ||
+		slices.Contains(rules.negative[resource], ct) ||
+		slices.Contains(positiveSuffixes, ct) ||
+		slices.Contains(negativeSuffixes, ct)
+}
+
 type handleResourceTypeInput struct {
-	args      *Arguments
-	dynClient *dynamic.DynamicClient
-	gvr       schema.GroupVersionResource
-	workerID  int32
+	args       *Arguments
+	dynClient  *dynamic.DynamicClient
+	gvr        schema.GroupVersionResource
+	namespaced bool
+	kind       string
+	verbs      metav1.Verbs
+	workerID   int32
 }
 
 type handleResourceTypeOutput struct {
@@ -524,35 +1393,184 @@ type handleResourceTypeOutput struct {
 	checkedResources     int32
 	checkedConditions    int32
 	checkAgain           bool
-	lines                []string
+	findings             []Finding
+	owners               ownerIndex
+	namespaces           map[string]namespaceMeta
+	backstageIDs         map[types.UID]backstageObject
+	nodeTopology         map[string]nodeTopology
+	podNodes             map[types.UID]string
+	skipped              []skippedResourceType
+	errors               []scanError
+	ownerRefs            []ownerRefCandidate
+	staleObjects         []staleCandidate
+	spans                []resourceTypeSpan
+	clusterID            types.UID
+
+	// podsOnNodes counts the pods per node, which are not finished.
+	podsOnNodes map[string]int
+
+	// terminatingPods and nodeReady are used by the check terminatingpods.
+	terminatingPods []terminatingPod
+	nodeReady       map[string]string
+
+	// finishedPods counts the failed, evicted and completed pods per namespace.
+	finishedPods map[podCategoryKey]int
+
+	// orphanCandidates and orphanOwners are used by the check orphanedsecrets. orphanOwners
+	// contains "GroupKind namespace/name" of Helm releases and Certificates.
+	orphanCandidates []orphanCandidate
+	orphanOwners     []string
+
+	// statefulSets, pvcs and usedPVCs ("namespace/name") are used by the check orphanedpvcs.
+	statefulSets []statefulSetInfo
+	pvcs         []pvcInfo
+	usedPVCs     []string
+
+	// configObjects and configRefs ("Kind namespace/name") are used by the check unreferenced.
+	configObjects []configObject
+	configRefs    []string
+
+	// hpas and replicas ("Kind.group namespace/name") are used by the check hpatargets.
+	hpas     []hpaInfo
+	replicas map[string]int64
+
+	// networkPolicies and podLabels (per namespace) are used by the check networkpolicies.
+	networkPolicies []networkPolicyInfo
+	podLabels       map[string][]labels.Set
+
+	// classes ("Kind.group name") and classRefs are used by the check classrefs.
+	classes   []string
+	classRefs []classReference
+
+	// imagePullFailures is used by the check imagepulls.
+	imagePullFailures []imagePullFailure
+
+	// duplicateConditions counts the custom resources with duplicate conditions per CRD.
+	duplicateConditions map[schema.GroupResource]int
+
+	// crds contains the CustomResourceDefinitions by the resource they define. See collectCRD.
+	crds map[schema.GroupResource]*crdInfo
+
+	// unknownConditionTypes contains "resource.group ConditionType" for each condition of an unknown type.
+	// Only set with --strict.
+	unknownConditionTypes []string
+
+	// inventory is set, if Arguments.inventory is true.
+	inventory inventory
+
+	// listedKind is set, if all objects of the resource type were listed.
+	listedKind *schema.GroupKind
 }
 
-func handleResourceType(input handleResourceTypeInput) handleResourceTypeOutput {
-	var output handleResourceTypeOutput
+// skipReason returns why the resource type does not get listed. Empty means it gets listed.
+func skipReason(args *Arguments, resource string, namespaced bool) string {
+	// Skip subresources like pod/logs, pod/status
+	if containsSlash(resource) {
+		return "subresource"
+	}
+	if slices.Contains(resourcesToSkip, resource) {
+		return "not listable"
+	}
+	if args.namespaceLimited() && !namespaced {
+		return "cluster-scoped"
+	}
+	return ""
+}
 
+func handleResourceType(input handleResourceTypeInput) (output handleResourceTypeOutput) {
 	args := input.args
 	name := input.gvr.Resource
 	dynClient := input.dynClient
 	gvr := input.gvr
-	// Skip subresources like pod/logs, pod/status
-	if containsSlash(name) {
-		return output
-	}
-	if slices.Contains(resourcesToSkip, name) {
+	if skipReason(args, name, input.namespaced) != "" {
 		return output
 	}
 
 	output.checkedResourceTypes++
+	output.owners = make(ownerIndex)
+	output.inventory = make(inventory)
+	output.namespaces = make(map[string]namespaceMeta)
+	output.backstageIDs = make(map[types.UID]backstageObject)
+	output.nodeTopology = make(map[string]nodeTopology)
+	output.podNodes = make(map[types.UID]string)
 
-	list, err := dynClient.Resource(gvr).List(context.TODO(), metav1.ListOptions{})
+	useInformer := args.informers != nil && slices.Contains(input.verbs, "watch") && args.informers.usable(gvr)
+	if !useInformer {
+		// Acquire before the per-type timeout starts, so that waiting does not count as slow.
+		release := args.groupLimit.acquire(gvr.Group)
+		defer release()
+	}
+	if !args.deadline.IsZero() && time.Now().After(args.deadline) {
+		output.skipped = append(output.skipped, skippedResourceType{gvr: gvr, reason: skipReasonMaxDuration})
+		return output
+	}
+	span := resourceTypeSpan{gvr: gvr, workerID: input.workerID, start: time.Now()}
+	defer func() {
+		span.end = time.Now()
+		span.objects = output.checkedResources
+		span.conditions = output.checkedConditions
+		span.findings = len(output.findings)
+		if len(output.errors) > 0 {
+			span.err = output.errors[0].Message
+		}
+		output.spans = append(output.spans, span)
+	}()
+	ctx := context.TODO()
+	if args.PerTypeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.PerTypeTimeout)
+		defer cancel()
+	}
+	var list *unstructured.UnstructuredList
+	var err error
+	if useInformer {
+		namespaces := args.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{args.Namespace}
+		}
+		list, err = args.informers.list(ctx, gvr, namespaces, args.LabelSelector)
+		if errors.Is(err, errInformerNotSynced) {
+			fmt.Fprintf(args.diagnostics(), "WARNING: %s. Listing it instead of watching it.\n", err.Error())
+			useInformer = false
+			release := args.groupLimit.acquire(gvr.Group)
+			defer release()
+		}
+	}
+	if !useInformer {
+		var skipped string
+		var scanErrors []scanError
+		list, skipped, scanErrors, err = listInScope(ctx, dynClient.Resource(gvr), args, gvr)
+		output.errors = append(output.errors, scanErrors...)
+		if skipped != "" {
+			output.skipped = append(output.skipped, skippedResourceType{gvr: gvr, reason: skipped})
+			if len(list.Items) == 0 {
+				output.checkedResourceTypes--
+				return output
+			}
+		}
+	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		output.checkedResourceTypes--
+		output.skipped = append(output.skipped, skippedResourceType{gvr: gvr, reason: skipReasonSlow})
+		scanErr := newScanError(gvr, err)
+		scanErr.Type = scanErrorTimeout
+		output.errors = append(output.errors, scanErr)
+		return output
+	}
 	if err != nil {
-		fmt.Printf("..Error listing %s: %v. group %q version %q resource %q\n", name, err,
-			gvr.Group, gvr.Version, gvr.Resource)
+		output.errors = append(output.errors, newScanError(gvr, err))
 		return output
 	}
 
-	lines, again := printResources(args, list, gvr, &output, input.workerID)
+	if len(output.skipped) == 0 && len(output.errors) == 0 {
+		// Checks which look up objects (owners, classes, ...) need all objects of the kind.
+		output.listedKind = &schema.GroupKind{Group: gvr.Group, Kind: input.kind}
+	}
+	if args.snapshot != nil {
+		args.snapshot.add(gvr, input.kind, input.namespaced, list)
+	}
+	findings, again := printResources(args, list, gvr, &output, input.workerID)
 	output.checkAgain = again
-	output.lines = lines
+	output.findings = findings
 	return output
 }
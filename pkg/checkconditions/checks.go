@@ -0,0 +1,356 @@
+package checkconditions
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// checkConditions looks at status.conditions.
+	checkConditions = "conditions"
+
+	// checkOwnerRefs looks for ownerReferences to objects which do not exist.
+	checkOwnerRefs = "ownerrefs"
+
+	// checkEmptyStatus looks for custom resources with an empty status.
+	checkEmptyStatus = "emptystatus"
+
+	// checkStaleReconcile looks for custom resources which were not reconciled after a change of the spec.
+	checkStaleReconcile = "stalereconcile"
+
+	// checkStaleLeases looks for Leases which were not renewed for a long time.
+	checkStaleLeases = "staleleases"
+
+	// checkTerminatingPods looks for pods which are terminating much longer than their grace period.
+	checkTerminatingPods = "terminatingpods"
+
+	// checkPodAccumulation looks for namespaces with many failed, evicted or completed pods.
+	checkPodAccumulation = "podaccumulation"
+
+	// checkObjectSize looks for objects which come close to the size limit of etcd.
+	checkObjectSize = "objectsize"
+
+	// checkOrphanedSecrets looks for Secrets and ConfigMaps of Helm releases and Certificates which do not exist.
+	checkOrphanedSecrets = "orphanedsecrets"
+
+	// checkOrphanedPVCs looks for PVCs of deleted or scaled down StatefulSets.
+	checkOrphanedPVCs = "orphanedpvcs"
+
+	// checkUnreferenced looks for ConfigMaps and Secrets which are not referenced.
+	checkUnreferenced = "unreferenced"
+
+	// checkHPATargets looks for HorizontalPodAutoscalers with a missing or scaled down target.
+	checkHPATargets = "hpatargets"
+
+	// checkNetworkPolicies looks for NetworkPolicies whose selectors match nothing.
+	checkNetworkPolicies = "networkpolicies"
+
+	// checkClassRefs looks for references to PriorityClasses, RuntimeClasses and StorageClasses which do not exist.
+	checkClassRefs = "classrefs"
+
+	// checkHistory looks at history arrays like status.history. See Config.HistoryChecks.
+	checkHistory = "history"
+
+	// checkImagePulls rolls up pods with failing image pulls by registry.
+	checkImagePulls = "imagepulls"
+
+	// checkDuplicateConditions looks for objects with the same condition type more than once.
+	checkDuplicateConditions = "duplicateconditions"
+
+	// checkDeprecatedVersions looks for resources whose deprecated API versions are still in use.
+	// It runs for the groups of --all-versions, see scanAllVersions.
+	checkDeprecatedVersions = "deprecatedversions"
+)
+
+// checkDefinition is a family of checks. Checks can be enabled and disabled via --enable-checks
+// and --disable-checks.
+type checkDefinition struct {
+	id               string
+	description      string
+	enabledByDefault bool
+
+	// severity and kinds are documentation for "checks list".
+	severity string
+	kinds    string
+
+	// object checks one resource object. It gets called for all objects of all resource types.
+	object func(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+		output *handleResourceTypeOutput) []Finding
+
+	// scan gets called after all resource objects were checked.
+	scan func(args *Arguments, counter *Counter) []Finding
+}
+
+// checkDefinitions contains all checks. The check "conditions" is implemented by printConditions.
+// Its object and scan functions add details to the findings of NotReady nodes.
+var checkDefinitions = []*checkDefinition{
+	{
+		id:               checkConditions,
+		description:      "Conditions in status.conditions which need attention",
+		enabledByDefault: true,
+		severity:         "critical or warning",
+		kinds:            "all",
+		object:           collectPodsOnNodes,
+		scan:             describeNotReadyNodes,
+	},
+	{
+		id:               checkOwnerRefs,
+		description:      "ownerReferences to objects which do not exist",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "all with ownerReferences",
+		object:           collectOwnerRefs,
+		scan:             danglingOwnerRefs,
+	},
+	{
+		id:               checkEmptyStatus,
+		description:      "Custom resources with an empty status, older than --empty-status-age. Usually no controller is running",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "custom resources",
+		object:           emptyStatus,
+	},
+	{
+		id:               checkStaleReconcile,
+		description:      "Custom resources whose observedGeneration is lower than their generation. Reported per CRD",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "custom resources",
+		object:           collectStaleReconcile,
+		scan:             staleReconcile,
+	},
+	{
+		id:               checkStaleLeases,
+		description:      "Leases (leader election, node heartbeats) not renewed for --stale-lease-age",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "Lease",
+		object:           staleLease,
+	},
+	{
+		id:               checkTerminatingPods,
+		description:      "Pods terminating longer than their grace period plus --terminating-margin, with node status",
+		enabledByDefault: true,
+		severity:         SeverityWarning,
+		kinds:            "Pod",
+		object:           collectTerminatingPods,
+		scan:             stuckTerminatingPods,
+	},
+	{
+		id:               checkPodAccumulation,
+		description:      "Namespaces with more Failed, Evicted or Completed pods than --pod-thresholds",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "Pod",
+		object:           collectFinishedPods,
+		scan:             podAccumulation,
+	},
+	{
+		id:               checkObjectSize,
+		description:      "Objects larger than --object-size-threshold, close to the limit of etcd (1.5MiB)",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "all",
+		object:           objectSize,
+	},
+	{
+		id:               checkOrphanedSecrets,
+		description:      "Secrets and ConfigMaps of Helm releases or Certificates which do not exist, large unowned Secrets",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "Secret, ConfigMap",
+		object:           collectOrphanedSecrets,
+		scan:             orphanedSecrets,
+	},
+	{
+		id:               checkOrphanedPVCs,
+		description:      "PVCs of deleted StatefulSets or of ordinals above the replicas, with reclaimable storage per namespace",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "PersistentVolumeClaim",
+		object:           collectStatefulSetPVCs,
+		scan:             orphanedPVCs,
+	},
+	{
+		id:               checkUnreferenced,
+		description:      "ConfigMaps and Secrets not referenced by workloads, service accounts or ingresses",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "ConfigMap, Secret",
+		object:           collectConfigReferences,
+		scan:             unreferencedConfigs,
+	},
+	{
+		id:               checkHPATargets,
+		description:      "HorizontalPodAutoscalers whose scaleTargetRef does not exist or has zero replicas",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "HorizontalPodAutoscaler",
+		object:           collectHPATargets,
+		scan:             hpaTargets,
+	},
+	{
+		id:               checkNetworkPolicies,
+		description:      "NetworkPolicies whose podSelector matches no pods, or whose namespaceSelector matches no namespaces",
+		enabledByDefault: false,
+		severity:         SeverityWarning,
+		kinds:            "NetworkPolicy",
+		object:           collectNetworkPolicies,
+		scan:             ineffectiveNetworkPolicies,
+	},
+	{
+		id:               checkClassRefs,
+		description:      "Pods referencing PriorityClasses or RuntimeClasses, and PVCs referencing StorageClasses, which do not exist",
+		enabledByDefault: true,
+		severity:         SeverityWarning,
+		kinds:            "Pod, PersistentVolumeClaim",
+		object:           collectClassReferences,
+		scan:             danglingClassReferences,
+	},
+	{
+		id:               checkHistory,
+		description:      "History arrays (like status.history) whose last entries failed, or without recent success. Configured via historyChecks",
+		enabledByDefault: true,
+		severity:         SeverityWarning,
+		kinds:            "configured in historyChecks",
+		object:           historyChecks,
+	},
+	{
+		id:               checkImagePulls,
+		description:      "Pods in ImagePullBackOff or ErrImagePull, rolled up by registry host and image. Replaces the condition findings caused only by the image pulls",
+		enabledByDefault: true,
+		severity:         SeverityWarning,
+		kinds:            "Pod",
+		object:           collectImagePullFailures,
+		scan:             imagePullRollup,
+	},
+	{
+		id:               checkDuplicateConditions,
+		description:      "Objects whose conditions contain the same type more than once. Additionally one finding per CRD, with the number of objects",
+		enabledByDefault: true,
+		severity:         SeverityWarning,
+		kinds:            "all",
+		object:           duplicateConditions,
+		scan:             duplicateConditionsPerCRD,
+	},
+	{
+		id:               checkDeprecatedVersions,
+		description:      "Resources whose deprecated API versions are still in use (CRD storedVersions, deprecation warnings). Needs --all-versions",
+		enabledByDefault: true,
+		severity:         SeverityWarning,
+		kinds:            "CRDs and resources of the groups of --all-versions",
+	},
+}
+
+// resolveChecks sets the enabled checks from --enable-checks and --disable-checks. If --enable-checks
+// is given, only these checks are enabled. Otherwise the checks which are enabled by default.
+func (args *Arguments) resolveChecks() error {
+	if len(args.EnableChecks) == 0 && len(args.DisableChecks) == 0 {
+		return nil
+	}
+	enabled := make(map[string]bool)
+	for _, c := range checkDefinitions {
+		enabled[c.id] = c.enabledByDefault && len(args.EnableChecks) == 0
+	}
+	for _, flag := range []struct {
+		name     string
+		patterns []string
+		value    bool
+	}{
+		{"--enable-checks", args.EnableChecks, true},
+		{"--disable-checks", args.DisableChecks, false},
+	} {
+		for _, pattern := range flag.patterns {
+			matched := false
+			for _, c := range checkDefinitions {
+				ok, err := path.Match(pattern, c.id)
+				if err != nil {
+					return fmt.Errorf("invalid value for %s: %q: %w", flag.name, pattern, err)
+				}
+				if ok {
+					enabled[c.id] = flag.value
+					matched = true
+				}
+			}
+			if !matched {
+				return fmt.Errorf("invalid value for %s: %q matches no check. Valid checks: %s", flag.name, pattern,
+					strings.Join(checkIDs(), ", "))
+			}
+		}
+	}
+	args.enabledChecks = enabled
+	return nil
+}
+
+// RunChecksList prints all checks with ID, description, default severity and the kinds they apply to.
+func RunChecksList(args Arguments) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0) //nolint:gomnd
+	fmt.Fprintln(w, "ID\tENABLED\tDEFAULT\tKINDS\tSEVERITY\tDESCRIPTION")
+	for _, c := range checkDefinitions {
+		fmt.Fprintf(w, "%s\t%t\t%t\t%s\t%s\t%s\n", c.id, args.checkEnabled(c.id), c.enabledByDefault, c.kinds,
+			c.severity, c.description)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+}
+
+func checkIDs() []string {
+	ids := make([]string, 0, len(checkDefinitions))
+	for _, c := range checkDefinitions {
+		ids = append(ids, c.id)
+	}
+	return ids
+}
+
+func (args *Arguments) checkEnabled(id string) bool {
+	if args.enabledChecks != nil {
+		return args.enabledChecks[id]
+	}
+	for _, c := range checkDefinitions {
+		if c.id == id {
+			return c.enabledByDefault
+		}
+	}
+	return false
+}
+
+func (args *Arguments) enabledObjectChecks() []*checkDefinition {
+	var result []*checkDefinition
+	for _, c := range checkDefinitions {
+		if c.object != nil && args.checkEnabled(c.id) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func (args *Arguments) enabledScanChecks() []*checkDefinition {
+	var result []*checkDefinition
+	for _, c := range checkDefinitions {
+		if c.scan != nil && args.checkEnabled(c.id) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// matchesWhileRegex returns true if one of the findings matches the regex of the while command.
+func matchesWhileRegex(args *Arguments, findings []Finding) bool {
+	if args.WhileRegex == nil {
+		return false
+	}
+	for i := range findings {
+		if args.WhileRegex.MatchString(findings[i].String()) {
+			return true
+		}
+	}
+	return false
+}
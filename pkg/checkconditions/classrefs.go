@@ -0,0 +1,108 @@
+package checkconditions
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// classReference is a reference of a pod or PVC to a cluster-scoped class.
+type classReference struct {
+	gvr       schema.GroupVersionResource
+	kind      string
+	namespace string
+	name      string
+	uid       types.UID
+	field     string
+	class     schema.GroupKind
+	className string
+}
+
+// classFields contains the fields which reference classes, per consuming resource.
+var classFields = map[schema.GroupResource][]struct {
+	path  []string
+	class schema.GroupKind
+}{
+	{Resource: "pods"}: {
+		{[]string{"spec", "priorityClassName"}, schema.GroupKind{Group: "scheduling.k8s.io", Kind: "PriorityClass"}},
+		{[]string{"spec", "runtimeClassName"}, schema.GroupKind{Group: "node.k8s.io", Kind: "RuntimeClass"}},
+	},
+	{Resource: "persistentvolumeclaims"}: {
+		{[]string{"spec", "storageClassName"}, schema.GroupKind{Group: "storage.k8s.io", Kind: "StorageClass"}},
+	},
+}
+
+// classKinds contains the kinds of the classes.
+var classKinds = map[schema.GroupKind]bool{
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}: true,
+	{Group: "node.k8s.io", Kind: "RuntimeClass"}:        true,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:     true,
+}
+
+// collectClassReferences records PriorityClasses, RuntimeClasses and StorageClasses, and the
+// references of pods and PVCs to them.
+func collectClassReferences(_ *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	gk := schema.GroupKind{Group: gvr.Group, Kind: obj.GetKind()}
+	if classKinds[gk] {
+		output.classes = append(output.classes, gk.String()+" "+obj.GetName())
+		return nil
+	}
+	for _, field := range classFields[gvr.GroupResource()] {
+		name, _, _ := unstructured.NestedString(obj.Object, field.path...)
+		if name == "" {
+			continue
+		}
+		output.classRefs = append(output.classRefs, classReference{
+			gvr:       gvr,
+			kind:      obj.GetKind(),
+			namespace: obj.GetNamespace(),
+			name:      obj.GetName(),
+			uid:       obj.GetUID(),
+			field:     field.path[len(field.path)-1],
+			class:     field.class,
+			className: name,
+		})
+	}
+	return nil
+}
+
+// danglingClassReferences reports pods and PVCs which reference a class which does not exist.
+// Classes are only checked, if their resource type was listed completely.
+func danglingClassReferences(args *Arguments, counter *Counter) []Finding {
+	if args.LabelSelector != "" {
+		return nil
+	}
+	classes := make(map[string]bool, len(counter.classes))
+	for _, c := range counter.classes {
+		classes[c] = true
+	}
+	var findings []Finding
+	for i := range counter.classRefs {
+		r := &counter.classRefs[i]
+		if !counter.listedKinds[r.class] || classes[r.class.String()+" "+r.className] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Namespace:          r.namespace,
+			Group:              r.gvr.Group,
+			Version:            r.gvr.Version,
+			Resource:           r.gvr.Resource,
+			Kind:               r.kind,
+			Name:               r.name,
+			UID:                r.uid,
+			ConditionType:      r.class.Kind,
+			ConditionStatus:    "Missing",
+			ConditionReason:    r.class.Kind + "NotFound",
+			ConditionMessage:   fmt.Sprintf("%s %q does not exist", r.field, r.className),
+			Severity:           SeverityWarning,
+			MessageFingerprint: fmt.Sprintf("%s <name> does not exist", r.field),
+			Code:               CodeClassRefDangling,
+			Check:              checkClassRefs,
+		})
+	}
+	return findings
+}
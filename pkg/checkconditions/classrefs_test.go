@@ -0,0 +1,66 @@
+package checkconditions
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDanglingClassReferences(t *testing.T) {
+	object := func(kind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":     kind,
+			"metadata": map[string]interface{}{"namespace": namespace, "name": name},
+			"spec":     spec,
+		}}
+	}
+	output := &handleResourceTypeOutput{}
+	for _, o := range []struct {
+		gvr schema.GroupVersionResource
+		obj *unstructured.Unstructured
+	}{
+		{schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}, object("PriorityClass", "", "high", nil)},
+		{schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}, object("StorageClass", "", "fast", nil)},
+		{schema.GroupVersionResource{Version: "v1", Resource: "pods"}, object("Pod", "a", "ok", map[string]interface{}{"priorityClassName": "high"})},
+		{schema.GroupVersionResource{Version: "v1", Resource: "pods"}, object("Pod", "a", "missing-priority", map[string]interface{}{"priorityClassName": "low"})},
+		{schema.GroupVersionResource{Version: "v1", Resource: "pods"}, object("Pod", "a", "runtime", map[string]interface{}{"runtimeClassName": "gvisor"})},
+		{schema.GroupVersionResource{Version: "v1", Resource: "pods"}, object("Pod", "a", "no-class", map[string]interface{}{})},
+		{schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, object("PersistentVolumeClaim", "a", "data", map[string]interface{}{"storageClassName": "slow"})},
+		{schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, object("PersistentVolumeClaim", "a", "fast", map[string]interface{}{"storageClassName": "fast"})},
+	} {
+		collectClassReferences(&Arguments{}, o.gvr, o.obj, output)
+	}
+	if len(output.classes) != 2 || len(output.classRefs) != 5 {
+		t.Fatalf("got %d classes and %d references, want 2 and 5", len(output.classes), len(output.classRefs))
+	}
+	counter := &Counter{
+		classes:   output.classes,
+		classRefs: output.classRefs,
+		// RuntimeClasses were not listed, so the reference to gvisor does not get checked.
+		listedKinds: map[schema.GroupKind]bool{
+			{Group: "scheduling.k8s.io", Kind: "PriorityClass"}: true,
+			{Group: "storage.k8s.io", Kind: "StorageClass"}:     true,
+		},
+	}
+	got := make(map[string]string)
+	for _, f := range danglingClassReferences(&Arguments{}, counter) {
+		got[f.Kind+" "+f.Name] = f.ConditionReason + ": " + f.ConditionMessage
+	}
+	want := map[string]string{
+		"Pod missing-priority":       `PriorityClassNotFound: priorityClassName "low" does not exist`,
+		"PersistentVolumeClaim data": `StorageClassNotFound: storageClassName "slow" does not exist`,
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for k, w := range want {
+		if got[k] != w {
+			t.Errorf("%s: got %q, want %q", k, got[k], w)
+		}
+	}
+
+	if findings := danglingClassReferences(&Arguments{LabelSelector: "app=web"}, counter); len(findings) != 0 {
+		t.Errorf("got findings with a label selector: %v", findings)
+	}
+}
@@ -0,0 +1,110 @@
+package checkconditions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// clusterCheckConfigGVR is the cluster-scoped custom resource, which serve --config-resource reads
+// instead of the config file. Its spec has the structure of the config file. The CRD is in
+// deploy/clustercheckconfigs.yaml.
+var clusterCheckConfigGVR = schema.GroupVersionResource{
+	Group: "checkconditions.guettli.github.io", Version: "v1alpha1", Resource: "clustercheckconfigs",
+}
+
+// configResourceData returns the spec of the ClusterCheckConfig as JSON, which parseConfig reads
+// like the YAML of the config file.
+func configResourceData(obj *unstructured.Unstructured) ([]byte, error) {
+	spec, ok := obj.Object["spec"]
+	if !ok || spec == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(spec)
+}
+
+// loadConfigResource reads the ClusterCheckConfig of --config-resource at the start of serve. It
+// replaces the config file, so both must not be combined.
+func (args *Arguments) loadConfigResource() error {
+	if args.ConfigFile != "" {
+		return fmt.Errorf("--config-resource and --config must not be combined")
+	}
+	if args.FromDir != "" {
+		return fmt.Errorf("--config-resource does not work with --from-dir")
+	}
+	config, err := newRestConfig(args)
+	if err != nil {
+		return err
+	}
+	dynClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	return args.readConfigResource(dynClient)
+}
+
+// readConfigResource reads the ClusterCheckConfig via dynClient, and validates it together with
+// the flags.
+func (args *Arguments) readConfigResource(dynClient dynamic.Interface) error {
+	obj, err := dynClient.Resource(clusterCheckConfigGVR).Get(context.Background(), args.ConfigResource, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to read ClusterCheckConfig: %w", err)
+	}
+	data, err := configResourceData(obj)
+	if err != nil {
+		return err
+	}
+	if err := args.parseConfig(data); err != nil {
+		return err
+	}
+	return args.Validate()
+}
+
+// watchConfigResource watches the ClusterCheckConfig of --config-resource with an informer. After
+// a change it triggers a scan, which reloads the config first. See reloadConfig. If the resource
+// gets deleted, the previous config stays active.
+func (s *server) watchConfigResource(dynClient dynamic.Interface, stop <-chan struct{}) {
+	name := s.args.ConfigResource
+	informer := dynamicinformer.NewFilteredDynamicInformer(dynClient, clusterCheckConfigGVR, "", 0, cache.Indexers{},
+		func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		})
+	update := func(obj interface{}) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok || u.GetName() != name {
+			return
+		}
+		data, err := configResourceData(u)
+		if err != nil {
+			fmt.Fprintf(s.args.diagnostics(), "WARNING: reading ClusterCheckConfig %q failed: %s\n", name, err.Error())
+			return
+		}
+		s.mutex.Lock()
+		s.configResource = data
+		s.mutex.Unlock()
+		select {
+		case s.configChanged <- struct{}{}:
+		default:
+		}
+	}
+	_, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    update,
+		UpdateFunc: func(_, obj interface{}) { update(obj) },
+		DeleteFunc: func(interface{}) {
+			fmt.Fprintf(s.args.diagnostics(), "WARNING: ClusterCheckConfig %q was deleted, the previous config stays active\n", name)
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(s.args.diagnostics(), "WARNING: watching ClusterCheckConfig %q failed: %s\n", name, err.Error())
+		return
+	}
+	informer.Informer().Run(stop)
+}
@@ -0,0 +1,83 @@
+package checkconditions
+
+// Codes are stable identifiers of the kind of a finding. Unlike the messages, they do not
+// change between releases, so automation can match on Finding.Code.
+const (
+	// CodeConditionFalse is a condition with status False.
+	CodeConditionFalse = "CONDITION_FALSE"
+
+	// CodeConditionTrue is a condition with status True, for example a condition with negative meaning.
+	CodeConditionTrue = "CONDITION_TRUE"
+
+	// CodeConditionUnknown is a condition with status Unknown or an other status.
+	CodeConditionUnknown = "CONDITION_UNKNOWN"
+
+	// CodeOwnerRefDangling is an ownerReference to an object which does not exist.
+	CodeOwnerRefDangling = "OWNERREF_DANGLING"
+
+	// CodeStatusEmpty is a custom resource with an empty status. Usually no controller is running.
+	CodeStatusEmpty = "STATUS_EMPTY"
+
+	// CodeGenerationLag is an object whose spec was changed, but the controller did not reconcile it.
+	CodeGenerationLag = "GENERATION_LAG"
+
+	// CodeLeaseStale is a lease which was not renewed.
+	CodeLeaseStale = "LEASE_STALE"
+
+	// CodePodTerminating is a pod which is stuck in terminating.
+	CodePodTerminating = "POD_TERMINATING"
+
+	// CodePodsAccumulated is a namespace with too many finished pods.
+	CodePodsAccumulated = "PODS_ACCUMULATED"
+
+	// CodeObjectSize is an object close to the size limit of etcd.
+	CodeObjectSize = "OBJECT_SIZE"
+
+	// CodeSecretOrphaned is a secret without owner.
+	CodeSecretOrphaned = "SECRET_ORPHANED"
+
+	// CodePVCOrphaned is a PVC of a StatefulSet which is not used.
+	CodePVCOrphaned = "PVC_ORPHANED"
+
+	// CodeUnreferenced is an object which is not referenced.
+	CodeUnreferenced = "UNREFERENCED"
+
+	// CodeHPATarget is a HorizontalPodAutoscaler with a missing or disabled scale target.
+	CodeHPATarget = "HPA_TARGET"
+
+	// CodeNetworkPolicyIneffective is a NetworkPolicy which selects nothing.
+	CodeNetworkPolicyIneffective = "NETWORKPOLICY_INEFFECTIVE"
+
+	// CodeClassRefDangling is a reference to a class (StorageClass, IngressClass, ...) which does not exist.
+	CodeClassRefDangling = "CLASSREF_DANGLING"
+
+	// CodeHistoryFailed is a history field whose last entries failed.
+	CodeHistoryFailed = "HISTORY_FAILED"
+
+	// CodeHistoryNoSuccess is a history field without a (recent) success.
+	CodeHistoryNoSuccess = "HISTORY_NO_SUCCESS"
+
+	// CodeImagePullFailing is a registry with failing image pulls.
+	CodeImagePullFailing = "IMAGE_PULL_FAILING"
+
+	// CodeDuplicateConditions is an object with the same condition type more than once.
+	CodeDuplicateConditions = "DUPLICATE_CONDITIONS"
+
+	// CodeDeprecatedStoredVersions is a CRD whose status.storedVersions contains deprecated versions.
+	CodeDeprecatedStoredVersions = "DEPRECATED_STORED_VERSIONS"
+
+	// CodeDeprecatedAPIVersion is a resource of a built-in group with objects, which is served via
+	// a deprecated version.
+	CodeDeprecatedAPIVersion = "DEPRECATED_API_VERSION"
+)
+
+// conditionCode returns the code of a finding of the check "conditions".
+func conditionCode(conditionStatus string) string {
+	switch conditionStatus {
+	case "False":
+		return CodeConditionFalse
+	case "True":
+		return CodeConditionTrue
+	}
+	return CodeConditionUnknown
+}
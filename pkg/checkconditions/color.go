@@ -0,0 +1,40 @@
+package checkconditions
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ANSI escape sequences of the colored text output.
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+)
+
+// useColor returns true, if the text output should be colored: stdout is a terminal, and neither
+// --no-color nor the environment variable NO_COLOR (https://no-color.org) is set.
+func (args *Arguments) useColor() bool {
+	if args.NoColor || os.Getenv("NO_COLOR") != "" || !args.textOutput() {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func colorize(color bool, code, s string) string {
+	if !color || s == "" {
+		return s
+	}
+	return code + s + colorReset
+}
+
+// statusColor returns the color of a condition status: red for True and False, which are
+// unexpected if there is a finding, yellow for Unknown.
+func statusColor(status string) string {
+	if status == "Unknown" {
+		return colorYellow
+	}
+	return colorRed
+}
@@ -0,0 +1,184 @@
+package checkconditions
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// ConditionPathConfig configures where the conditions of a kind are stored, if it is not status.conditions,
+// and which field names they use.
+type ConditionPathConfig struct {
+	Group string `json:"group"`
+	// Version is optional. Empty matches all versions.
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	// Path is a JSONPath to the conditions array, for example ".status.resourceStatus.conditions".
+	// With [*] the conditions of several arrays get checked: ".status.components[*].conditions".
+	// Empty means status.conditions.
+	Path string `json:"path"`
+	// Fields maps the field names of legacy conditions to the field names of metav1.Condition.
+	Fields *ConditionFieldsConfig `json:"fields"`
+}
+
+// ConditionFieldsConfig contains the field names used by the conditions of a kind.
+// Empty values mean the field name of metav1.Condition.
+type ConditionFieldsConfig struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	LastTransitionTime string `json:"lastTransitionTime"`
+}
+
+// template returns the path as JSONPath template. The braces are optional in the config file.
+func (c *ConditionPathConfig) template() string {
+	if strings.HasPrefix(c.Path, "{") {
+		return c.Path
+	}
+	return "{" + c.Path + "}"
+}
+
+// parse validates the entry. The path must be a valid JSONPath, and the field names must not be empty.
+func (c *ConditionPathConfig) parse() error {
+	if c.Kind == "" {
+		return fmt.Errorf("needs kind")
+	}
+	if c.Path == "" && c.Fields == nil {
+		return fmt.Errorf("needs path or fields")
+	}
+	if c.Fields != nil && *c.Fields == (ConditionFieldsConfig{}) {
+		return fmt.Errorf("fields needs at least one field name")
+	}
+	if c.Path == "" {
+		return nil
+	}
+	if err := jsonpath.New(c.Kind).Parse(c.template()); err != nil {
+		return fmt.Errorf("invalid path %q: %w", c.Path, err)
+	}
+	return nil
+}
+
+// key identifies the kind (and version) of the entry.
+func (c *ConditionPathConfig) key() string {
+	return strings.Join([]string{c.Group, c.Version, c.Kind}, "/")
+}
+
+// parseConditionPaths validates the entries of conditionPaths. Only the first entry of a kind
+// would be used, so duplicates are rejected.
+func parseConditionPaths(paths []ConditionPathConfig) error {
+	seen := make(map[string]int, len(paths))
+	for i := range paths {
+		if err := paths[i].parse(); err != nil {
+			return fmt.Errorf("conditionPaths[%d]: %w", i, err)
+		}
+		if j, ok := seen[paths[i].key()]; ok {
+			return fmt.Errorf("conditionPaths[%d]: same group, version and kind as conditionPaths[%d]", i, j)
+		}
+		seen[paths[i].key()] = i
+	}
+	return nil
+}
+
+// conditionPath returns the configured path for the kind, or nil.
+func (c *Config) conditionPath(gvr schema.GroupVersionResource, kind string) *ConditionPathConfig {
+	if c == nil {
+		return nil
+	}
+	for i := range c.ConditionPaths {
+		p := &c.ConditionPaths[i]
+		if p.Group == gvr.Group && p.Kind == kind && (p.Version == "" || p.Version == gvr.Version) {
+			return p
+		}
+	}
+	return nil
+}
+
+// objectConditions returns the conditions of the object. Without a configured path, they are read
+// from status.conditions. Conditions with configured field names get converted to the usual field names.
+func objectConditions(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) ([]interface{}, error) {
+	p := args.Config.conditionPath(gvr, obj.GetKind())
+	if p == nil {
+		return defaultConditions(gvr, obj)
+	}
+	var conditions []interface{}
+	if p.Path == "" {
+		var err error
+		conditions, err = defaultConditions(gvr, obj)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// A JSONPath is not safe for concurrent use, so it gets parsed for each object.
+		j := jsonpath.New(p.Kind).AllowMissingKeys(true)
+		if err := j.Parse(p.template()); err != nil {
+			return nil, err
+		}
+		results, err := j.FindResults(obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("conditions path %q of %s %s: %w", p.Path, obj.GetKind(), obj.GetName(), err)
+		}
+		for _, result := range results {
+			for _, v := range result {
+				list, ok := v.Interface().([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("conditions path %q of %s %s: %T is not a list", p.Path, obj.GetKind(), obj.GetName(), v.Interface())
+				}
+				conditions = append(conditions, list...)
+			}
+		}
+	}
+	if p.Fields != nil {
+		for i := range conditions {
+			conditions[i] = p.Fields.convert(conditions[i])
+		}
+	}
+	return conditions, nil
+}
+
+func defaultConditions(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) ([]interface{}, error) {
+	if gvr.Resource == "hetznerbaremetalhosts" {
+		// For some reasons this resource stores the conditions differently
+		conditions, _, err := unstructured.NestedSlice(obj.Object, "spec", "status", "conditions")
+		return conditions, err
+	}
+	conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	return conditions, err
+}
+
+// convert returns the condition with the usual field names. A boolean status (ok: true) gets
+// converted to "True" or "False".
+func (f *ConditionFieldsConfig) convert(condition interface{}) interface{} {
+	m, ok := condition.(map[string]interface{})
+	if !ok {
+		return condition
+	}
+	converted := make(map[string]interface{}, len(m))
+	for _, field := range []struct{ from, to string }{
+		{f.Type, "type"},
+		{f.Status, "status"},
+		{f.Reason, "reason"},
+		{f.Message, "message"},
+		{f.LastTransitionTime, "lastTransitionTime"},
+	} {
+		from := field.from
+		if from == "" {
+			from = field.to
+		}
+		v, ok := m[from]
+		if !ok {
+			continue
+		}
+		if b, isBool := v.(bool); isBool && field.to == "status" {
+			v = "False"
+			if b {
+				v = "True"
+			}
+		}
+		converted[field.to] = v
+	}
+	return converted
+}
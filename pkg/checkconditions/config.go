@@ -0,0 +1,430 @@
+package checkconditions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the content of the file given via --config.
+type Config struct {
+	// Cluster is the name of the cluster. It defaults to the cluster of the kubeconfig context.
+	// It is part of the keys of the issues and alerts, see Arguments.sinkKey.
+	Cluster string `json:"cluster"`
+
+	// Teams maps namespaces to teams. The first matching team wins.
+	Teams []TeamConfig `json:"teams"`
+
+	// Jira creates issues for findings which persist for a long time.
+	Jira *JiraConfig `json:"jira"`
+
+	// GitHub creates issues for critical findings.
+	GitHub *GitHubConfig `json:"github"`
+
+	// Opsgenie creates alerts for findings.
+	Opsgenie *OpsgenieConfig `json:"opsgenie"`
+
+	// Events creates Kubernetes Events for findings.
+	Events *EventsConfig `json:"events"`
+
+	// Backstage receives the health of Backstage entities after each scan.
+	Backstage *BackstageConfig `json:"backstage"`
+
+	// Grafana creates an annotation for each scan.
+	Grafana *GrafanaConfig `json:"grafana"`
+
+	// ConditionRules define the meaning of condition types. They extend the built-in rules.
+	ConditionRules []ConditionRule `json:"conditionRules"`
+
+	// ConditionPaths configure where the conditions of a kind are stored, if it is not status.conditions.
+	ConditionPaths []ConditionPathConfig `json:"conditionPaths"`
+
+	// HistoryChecks check history arrays, like the rollout history of a ClusterVersion.
+	HistoryChecks []HistoryCheckConfig `json:"historyChecks"`
+
+	// Score configures the weights of the health score (doctor command and metrics).
+	Score *ScoreConfig `json:"score"`
+
+	// MaintenanceWindows mute notifications during planned work.
+	MaintenanceWindows []MaintenanceWindowConfig `json:"maintenanceWindows"`
+
+	// Escalations raise the severity of long-standing findings (serve command).
+	Escalations []EscalationConfig `json:"escalations"`
+
+	// Ephemeral recognizes short-lived namespaces of CI jobs and preview environments.
+	Ephemeral *EphemeralConfig `json:"ephemeral"`
+
+	// ExitPolicies define when a scan fails. They get selected via --exit-policy.
+	ExitPolicies []ExitPolicyConfig `json:"exitPolicies"`
+
+	// Redactions remove secrets and personal data from condition messages.
+	Redactions []RedactionConfig `json:"redactions"`
+
+	// Serve configures the serve command. Command-line flags take precedence.
+	Serve ServeConfig `json:"serve"`
+}
+
+// ServeConfig configures the serve command.
+type ServeConfig struct {
+	// Listen is the address of the HTTP server. Defaults to ":8080".
+	Listen string `json:"listen"`
+	// GRPCListen is the address of the gRPC server. Empty means no gRPC server.
+	GRPCListen string `json:"grpcListen"`
+	// GRPCTLSCert and GRPCTLSKey are the files of the TLS certificate of the gRPC server.
+	GRPCTLSCert string `json:"grpcTLSCert"`
+	GRPCTLSKey  string `json:"grpcTLSKey"`
+	// GRPCClientCA is the file of the CA which signs the client certificates of gRPC clients.
+	GRPCClientCA string `json:"grpcClientCA"`
+	// GRPCTokenFile is the file with the bearer token of gRPC clients.
+	GRPCTokenFile string `json:"grpcTokenFile"`
+	// HTTPTLSCert, HTTPTLSKey, HTTPClientCA and HTTPTokenFile are the same for the HTTP server.
+	HTTPTLSCert   string `json:"httpTLSCert"`
+	HTTPTLSKey    string `json:"httpTLSKey"`
+	HTTPClientCA  string `json:"httpClientCA"`
+	HTTPTokenFile string `json:"httpTokenFile"`
+	// Interval is the time between two periodic scans. Defaults to 5m.
+	Interval metav1.Duration `json:"interval"`
+	// Schedule is a cron expression like "*/10 * * * *". It replaces the interval.
+	Schedule string `json:"schedule"`
+	// Jitter delays each periodic scan by a random duration up to this value.
+	Jitter metav1.Duration `json:"jitter"`
+	// Informers enables watching the resource objects instead of listing them for each scan.
+	Informers bool `json:"informers"`
+	// Textfile is the file for the textfile collector of node_exporter.
+	Textfile    string             `json:"textfile"`
+	RemoteWrite *RemoteWriteConfig `json:"remoteWrite"`
+}
+
+// RemoteWriteConfig configures the Prometheus remote-write push.
+type RemoteWriteConfig struct {
+	URL             string   `json:"url"`
+	BearerTokenFile string   `json:"bearerTokenFile"`
+	Headers         []string `json:"headers"`
+}
+
+const (
+	// MeaningPositive means True is fine, and False needs attention.
+	MeaningPositive = "positive"
+	// MeaningNegative means False is fine, and True needs attention.
+	MeaningNegative = "negative"
+	// MeaningIgnore means both True and False are fine.
+	MeaningIgnore = "ignore"
+)
+
+// ConditionRule defines the meaning of a condition type of a resource (for example "deployments").
+type ConditionRule struct {
+	Resource string `json:"resource"`
+	Type     string `json:"type"`
+	Meaning  string `json:"meaning"`
+}
+
+// TeamConfig selects the namespaces of a team via labels and annotations of the namespace.
+type TeamConfig struct {
+	Name                 string            `json:"name"`
+	NamespaceLabels      map[string]string `json:"namespaceLabels"`
+	NamespaceAnnotations map[string]string `json:"namespaceAnnotations"`
+
+	// The targets of the sinks for the findings in the namespaces of this team. Empty values use
+	// the sink config. The sink needs to be configured.
+
+	// JiraProject overrides the project of JiraConfig.
+	JiraProject string `json:"jiraProject"`
+	// GitHubRepo overrides the repo of GitHubConfig ("owner/name").
+	GitHubRepo string `json:"githubRepo"`
+	// OpsgenieTeam is set as responder of the Opsgenie alerts.
+	OpsgenieTeam string `json:"opsgenieTeam"`
+	// EventsMinSeverity overrides the minSeverity of EventsConfig.
+	EventsMinSeverity string `json:"eventsMinSeverity"`
+	// BackstageURL overrides the url of BackstageConfig. The health of the entities in the
+	// namespaces of this team gets posted there.
+	BackstageURL string `json:"backstageURL"`
+}
+
+// ReadConfigFile reads the config file given via --config. Without --config an empty Config is used.
+func (args *Arguments) ReadConfigFile() error {
+	args.Config = &Config{}
+	args.rules = nil
+	if args.ConfigFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(args.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	return args.parseConfig(data)
+}
+
+// configSource returns the source of the config for messages: the config file, or the
+// ClusterCheckConfig of serve --config-resource.
+func (args *Arguments) configSource() string {
+	if args.ConfigResource != "" {
+		return fmt.Sprintf("ClusterCheckConfig %q", args.ConfigResource)
+	}
+	return fmt.Sprintf("config file %q", args.ConfigFile)
+}
+
+// parseConfig parses and checks the config, which was read from the config file or the
+// ClusterCheckConfig.
+func (args *Arguments) parseConfig(data []byte) error {
+	args.Config = &Config{}
+	args.rules = nil
+	source := args.configSource()
+	args.configHash = hashConfig(data)
+	if err := yaml.UnmarshalStrict(data, args.Config); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	for i := range args.Config.Teams {
+		if err := args.Config.Teams[i].validate(args.Config); err != nil {
+			return fmt.Errorf("%s: teams[%d]: %w", source, i, err)
+		}
+	}
+	if jira := args.Config.Jira; jira != nil && (jira.URL == "" || jira.Project == "") {
+		return fmt.Errorf("%s: jira needs url and project", source)
+	}
+	if github := args.Config.GitHub; github != nil && strings.Count(github.Repo, "/") != 1 {
+		return fmt.Errorf("%s: github.repo needs to be \"owner/name\"", source)
+	}
+	if events := args.Config.Events; events != nil && events.MinSeverity != "" {
+		if _, ok := severityRank[events.MinSeverity]; !ok {
+			return fmt.Errorf("%s: events: unknown minSeverity %q", source, events.MinSeverity)
+		}
+	}
+	if backstage := args.Config.Backstage; backstage != nil {
+		if backstage.URL == "" {
+			return fmt.Errorf("%s: backstage needs url", source)
+		}
+		backstage.applyDefaults()
+	}
+	if grafana := args.Config.Grafana; grafana != nil && grafana.URL == "" {
+		return fmt.Errorf("%s: grafana needs url", source)
+	}
+	for i, rule := range args.Config.ConditionRules {
+		if rule.Resource == "" || rule.Type == "" {
+			return fmt.Errorf("%s: conditionRules[%d] needs resource and type", source, i)
+		}
+		if !slices.Contains([]string{MeaningPositive, MeaningNegative, MeaningIgnore}, rule.Meaning) {
+			return fmt.Errorf("%s: conditionRules[%d]: invalid meaning %q. Valid values: %s, %s, %s",
+				source, i, rule.Meaning, MeaningPositive, MeaningNegative, MeaningIgnore)
+		}
+	}
+	if score := args.Config.Score; score != nil {
+		for _, weights := range []map[string]float64{score.SeverityWeights, score.KindWeights, score.CheckWeights} {
+			for name, w := range weights {
+				if w < 0 {
+					return fmt.Errorf("%s: score: negative weight for %q", source, name)
+				}
+			}
+		}
+		for severity := range score.SeverityWeights {
+			if _, ok := severityRank[severity]; !ok {
+				return fmt.Errorf("%s: score: unknown severity %q", source, severity)
+			}
+		}
+	}
+	if err := parseConditionPaths(args.Config.ConditionPaths); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	for i := range args.Config.HistoryChecks {
+		if err := args.Config.HistoryChecks[i].parse(); err != nil {
+			return fmt.Errorf("%s: historyChecks[%d]: %w", source, i, err)
+		}
+	}
+	for i := range args.Config.MaintenanceWindows {
+		if err := args.Config.MaintenanceWindows[i].parse(); err != nil {
+			return fmt.Errorf("%s: maintenanceWindows[%d]: %w", source, i, err)
+		}
+	}
+	for i := range args.Config.Escalations {
+		if err := args.Config.Escalations[i].validate(); err != nil {
+			return fmt.Errorf("%s: escalations[%d]: %w", source, i, err)
+		}
+	}
+	for i := range args.Config.ExitPolicies {
+		if err := args.Config.ExitPolicies[i].validate(); err != nil {
+			return fmt.Errorf("%s: exitPolicies[%d]: %w", source, i, err)
+		}
+	}
+	for i := range args.Config.Redactions {
+		if err := args.Config.Redactions[i].parse(); err != nil {
+			return fmt.Errorf("%s: redactions[%d]: %w", source, i, err)
+		}
+	}
+	if ephemeral := args.Config.Ephemeral; ephemeral != nil {
+		if err := ephemeral.parse(); err != nil {
+			return fmt.Errorf("%s: ephemeral: %w", source, err)
+		}
+	}
+	if opsgenie := args.Config.Opsgenie; opsgenie != nil {
+		for severity, priority := range opsgenie.Priorities {
+			if !slices.Contains([]string{"P1", "P2", "P3", "P4", "P5"}, priority) {
+				return fmt.Errorf("%s: invalid opsgenie priority %q for %q", source, priority, severity)
+			}
+		}
+	}
+	args.rules = newConditionRuleSet(args.Config.ConditionRules)
+	return nil
+}
+
+// applyServeConfig uses the values of the serve block of the config file, if the corresponding
+// command-line flag was not set.
+func (args *Arguments) applyServeConfig() {
+	serve := &args.Config.Serve
+	if args.Listen == "" {
+		args.Listen = serve.Listen
+	}
+	if args.Listen == "" {
+		args.Listen = ":8080"
+	}
+	if args.GRPCListen == "" {
+		args.GRPCListen = serve.GRPCListen
+	}
+	if args.GRPCTLSCert == "" && args.GRPCTLSKey == "" {
+		args.GRPCTLSCert = serve.GRPCTLSCert
+		args.GRPCTLSKey = serve.GRPCTLSKey
+	}
+	if args.GRPCClientCA == "" {
+		args.GRPCClientCA = serve.GRPCClientCA
+	}
+	if args.GRPCTokenFile == "" {
+		args.GRPCTokenFile = serve.GRPCTokenFile
+	}
+	if args.HTTPTLSCert == "" && args.HTTPTLSKey == "" {
+		args.HTTPTLSCert = serve.HTTPTLSCert
+		args.HTTPTLSKey = serve.HTTPTLSKey
+	}
+	if args.HTTPClientCA == "" {
+		args.HTTPClientCA = serve.HTTPClientCA
+	}
+	if args.HTTPTokenFile == "" {
+		args.HTTPTokenFile = serve.HTTPTokenFile
+	}
+	if args.Interval == 0 {
+		args.Interval = serve.Interval.Duration
+	}
+	if args.Interval == 0 {
+		args.Interval = 5 * time.Minute //nolint:gomnd
+	}
+	if args.Schedule == "" {
+		args.Schedule = serve.Schedule
+	}
+	if args.Jitter == 0 {
+		args.Jitter = serve.Jitter.Duration
+	}
+	if serve.Informers {
+		args.Informers = true
+	}
+	if args.Textfile == "" {
+		args.Textfile = serve.Textfile
+	}
+	if args.RemoteWriteURL == "" && serve.RemoteWrite != nil {
+		args.RemoteWriteURL = serve.RemoteWrite.URL
+		args.RemoteWriteBearerTokenFile = serve.RemoteWrite.BearerTokenFile
+		args.RemoteWriteHeaders = serve.RemoteWrite.Headers
+	}
+}
+
+// conditionRuleSet contains the condition types of each resource with a known meaning.
+type conditionRuleSet struct {
+	positive map[string][]string
+	negative map[string][]string
+	skip     map[string][]string
+}
+
+// builtinConditionRules are the rules, which are used without a config file.
+var builtinConditionRules = &conditionRuleSet{
+	positive: conditionTypesOfResourceWithPositiveMeaning,
+	negative: conditionTypesOfResourceWithNegativeMeaning,
+	skip:     conditionTypesOfResourceToSkip,
+}
+
+// newConditionRuleSet returns the built-in rules plus the rules of the config file. The built-in
+// rules are not modified.
+func newConditionRuleSet(rules []ConditionRule) *conditionRuleSet {
+	set := &conditionRuleSet{
+		positive: copyConditionTypes(builtinConditionRules.positive),
+		negative: copyConditionTypes(builtinConditionRules.negative),
+		skip:     copyConditionTypes(builtinConditionRules.skip),
+	}
+	for _, rule := range rules {
+		switch rule.Meaning {
+		case MeaningPositive:
+			set.positive[rule.Resource] = append(set.positive[rule.Resource], rule.Type)
+		case MeaningNegative:
+			set.negative[rule.Resource] = append(set.negative[rule.Resource], rule.Type)
+		case MeaningIgnore:
+			set.skip[rule.Resource] = append(set.skip[rule.Resource], rule.Type)
+		}
+	}
+	return set
+}
+
+func copyConditionTypes(m map[string][]string) map[string][]string {
+	c := make(map[string][]string, len(m))
+	for resource, types := range m {
+		c[resource] = append([]string(nil), types...)
+	}
+	return c
+}
+
+// conditionRules returns the rules of the config file, or the built-in rules if no config file was read.
+func (args *Arguments) conditionRules() *conditionRuleSet {
+	if args.rules == nil {
+		return builtinConditionRules
+	}
+	return args.rules
+}
+
+func (t *TeamConfig) validate(config *Config) error {
+	switch {
+	case t.Name == "":
+		return fmt.Errorf("no name")
+	case t.JiraProject != "" && config.Jira == nil:
+		return fmt.Errorf("jiraProject needs the jira block")
+	case t.GitHubRepo != "" && config.GitHub == nil:
+		return fmt.Errorf("githubRepo needs the github block")
+	case t.GitHubRepo != "" && strings.Count(t.GitHubRepo, "/") != 1:
+		return fmt.Errorf("githubRepo needs to be \"owner/name\"")
+	case t.OpsgenieTeam != "" && config.Opsgenie == nil:
+		return fmt.Errorf("opsgenieTeam needs the opsgenie block")
+	case t.EventsMinSeverity != "" && config.Events == nil:
+		return fmt.Errorf("eventsMinSeverity needs the events block")
+	case t.BackstageURL != "" && config.Backstage == nil:
+		return fmt.Errorf("backstageURL needs the backstage block")
+	}
+	if _, ok := severityRank[t.EventsMinSeverity]; t.EventsMinSeverity != "" && !ok {
+		return fmt.Errorf("unknown eventsMinSeverity %q", t.EventsMinSeverity)
+	}
+	return nil
+}
+
+// team returns the team with the given name, or nil.
+func (c *Config) team(name string) *TeamConfig {
+	for i := range c.Teams {
+		if c.Teams[i].Name == name {
+			return &c.Teams[i]
+		}
+	}
+	return nil
+}
+
+func (t *TeamConfig) matches(ns namespaceMeta) bool {
+	if len(t.NamespaceLabels) == 0 && len(t.NamespaceAnnotations) == 0 {
+		return false
+	}
+	for k, v := range t.NamespaceLabels {
+		if ns.labels[k] != v {
+			return false
+		}
+	}
+	for k, v := range t.NamespaceAnnotations {
+		if ns.annotations[k] != v {
+			return false
+		}
+	}
+	return true
+}
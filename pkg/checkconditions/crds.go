@@ -0,0 +1,114 @@
+package checkconditions
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// crdGroupKind is the GroupKind of CustomResourceDefinitions.
+var crdGroupKind = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+
+// crdInfo contains the parts of a CustomResourceDefinition which are used by the checks. They get
+// collected while the CRDs are listed, so that the checks do not need to get the CRDs again.
+type crdInfo struct {
+	name     string
+	uid      types.UID
+	kind     string
+	versions []crdVersion
+
+	// storedVersions are the versions which were ever used to store objects in etcd.
+	storedVersions []string
+}
+
+// crdVersion is an entry of spec.versions of a CRD.
+type crdVersion struct {
+	name       string
+	served     bool
+	deprecated bool
+
+	// specReplicasPath is subresources.scale.specReplicasPath, like ".spec.replicas". It is empty,
+	// if the version has no scale subresource.
+	specReplicasPath string
+}
+
+// collectCRD adds the CRD to the output, if the object is a CustomResourceDefinition.
+func collectCRD(gvr schema.GroupVersionResource, obj *unstructured.Unstructured, output *handleResourceTypeOutput) {
+	if gvr.Group != crdGroupKind.Group || gvr.Resource != "customresourcedefinitions" {
+		return
+	}
+	group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "plural")
+	kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+	crd := &crdInfo{name: obj.GetName(), uid: obj.GetUID(), kind: kind}
+	crd.storedVersions, _, _ = unstructured.NestedStringSlice(obj.Object, "status", "storedVersions")
+	versions, _, _ := unstructured.NestedSlice(obj.Object, "spec", "versions")
+	for _, v := range versions {
+		served, _ := mapOf(v)["served"].(bool)
+		deprecated, _ := mapOf(v)["deprecated"].(bool)
+		specReplicasPath, _, _ := unstructured.NestedString(mapOf(v), "subresources", "scale", "specReplicasPath")
+		crd.versions = append(crd.versions, crdVersion{
+			name: stringField(v, "name"), served: served, deprecated: deprecated, specReplicasPath: specReplicasPath,
+		})
+	}
+	if output.crds == nil {
+		output.crds = make(map[schema.GroupResource]*crdInfo)
+	}
+	output.crds[schema.GroupResource{Group: group, Resource: plural}] = crd
+}
+
+// deprecatedStoredVersions returns the stored versions which are deprecated or not served anymore.
+func (crd *crdInfo) deprecatedStoredVersions() []string {
+	var result []string
+	for _, stored := range crd.storedVersions {
+		found := false
+		for _, v := range crd.versions {
+			if v.name != stored {
+				continue
+			}
+			found = true
+			if v.deprecated || !v.served {
+				result = append(result, stored)
+			}
+		}
+		if !found {
+			result = append(result, stored)
+		}
+	}
+	return result
+}
+
+// needsCRDs returns true if the object checks need to know the CRDs before the scan. See
+// customResource.
+func (args *Arguments) needsCRDs() bool {
+	return args.checkEnabled(checkEmptyStatus) || args.checkEnabled(checkStaleReconcile) ||
+		args.checkEnabled(checkDuplicateConditions) || args.checkEnabled(checkHPATargets)
+}
+
+// customResource returns true, if a CRD defines the resource. Groups like "apps" or
+// "networking.k8s.io" are built-in, but groups with a dot can be built-in, too (aggregated APIs
+// like "metrics.k8s.io"), so the CRDs are the only reliable source. The CRDs are listed at the
+// start of the scan, see needsCRDs.
+func (args *Arguments) customResource(gvr schema.GroupVersionResource) bool {
+	_, ok := args.crds[gvr.GroupResource()]
+	return ok
+}
+
+// replicasPath returns the fields of the replicas in the spec of the resource. Built-in resources
+// use spec.replicas. Custom resources define the field in the scale subresource of the CRD. ok is
+// false for custom resources without scale subresource.
+func (args *Arguments) replicasPath(gvr schema.GroupVersionResource) (fields []string, ok bool) {
+	crd, custom := args.crds[gvr.GroupResource()]
+	if !custom {
+		return []string{"spec", "replicas"}, true
+	}
+	for _, v := range crd.versions {
+		if v.name == gvr.Version && v.specReplicasPath != "" {
+			// The path has no array notation, like ".spec.replicas".
+			return strings.Split(strings.TrimPrefix(v.specReplicasPath, "."), "."), true
+		}
+	}
+	return nil, false
+}
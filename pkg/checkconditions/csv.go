@@ -0,0 +1,64 @@
+package checkconditions
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// csvHeader contains the columns of --output csv. The order is stable, so that spreadsheets
+// can import the files of several scans.
+var csvHeader = []string{
+	"cluster", "namespace", "resource", "name", "condition", "status", "reason", "message", "lastTransitionTime",
+	"firstSeen", "lastSeen", "occurrences",
+}
+
+// kubeconfigClusterName returns the name of the cluster of the context which the client config
+// uses. It is empty, if there is no kubeconfig (in-cluster).
+func kubeconfigClusterName(kubeconfig clientcmd.ClientConfig) string {
+	raw, err := kubeconfig.RawConfig()
+	if err != nil {
+		return ""
+	}
+	if ctx, ok := raw.Contexts[raw.CurrentContext]; ok {
+		return ctx.Cluster
+	}
+	return ""
+}
+
+// writeCSV writes one line per finding. The resource contains the group: "deployments.apps".
+func writeCSV(w io.Writer, r *report) error {
+	cluster := r.Scope.Cluster
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for i := range r.Findings {
+		f := &r.Findings[i]
+		resource := f.Resource
+		if f.Group != "" {
+			resource += "." + f.Group
+		}
+		lastTransitionTime := ""
+		if !f.LastTransitionTime.IsZero() {
+			lastTransitionTime = f.LastTransitionTime.UTC().Format(time.RFC3339)
+		}
+		firstSeen, lastSeen, occurrences := "", "", ""
+		if f.FirstSeen != nil {
+			firstSeen = f.FirstSeen.UTC().Format(time.RFC3339)
+			lastSeen = f.LastSeen.UTC().Format(time.RFC3339)
+			occurrences = strconv.Itoa(f.Occurrences)
+		}
+		if err := cw.Write([]string{
+			cluster, f.Namespace, resource, f.Name, f.ConditionType, f.ConditionStatus,
+			f.ConditionReason, f.ConditionMessage, lastTransitionTime, firstSeen, lastSeen, occurrences,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
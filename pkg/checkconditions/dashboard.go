@@ -0,0 +1,86 @@
+package checkconditions
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+)
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="30">
+<title>check-conditions</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: 0.2em 0.8em; border-bottom: 1px solid #ddd; vertical-align: top; }
+.critical { color: #b00; }
+</style>
+</head>
+<body>
+<h1>check-conditions</h1>
+{{if .Scanned}}
+<p>Last scan: {{.ScanTime.Format "2006-01-02 15:04:05 MST"}}.
+Checked {{.CheckedConditions}} conditions of {{.CheckedResources}} resources of {{.CheckedResourceTypes}} types.
+{{len .Findings}} conditions need attention.</p>
+<table>
+<tr><th>Namespace</th><th>Resource</th><th>Name</th><th>Condition</th><th>Reason</th><th>Message</th><th>Since</th><th>First seen</th></tr>
+{{range .Findings}}
+<tr class="{{.Severity}}"><td>{{.Namespace}}</td><td>{{.Resource}}</td><td>{{.Name}}</td>
+<td>{{.ConditionType}}={{.ConditionStatus}}</td><td>{{.ConditionReason}}</td><td>{{.ConditionMessage}}</td>
+<td>{{if not .LastTransitionTime.IsZero}}{{.LastTransitionTime.Format "2006-01-02 15:04:05"}}{{end}}</td>
+<td>{{if .FirstSeen}}{{.FirstSeen.Format "2006-01-02 15:04:05"}} ({{.Occurrences}} scans){{end}}</td></tr>
+{{end}}
+</table>
+{{else}}
+<p>The first scan is not finished yet.</p>
+{{end}}
+</body>
+</html>
+`))
+
+type dashboardData struct {
+	Scanned bool
+	scanResponse
+}
+
+// handleDashboard shows the findings of the last periodic scan as HTML page.
+func (s *server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	s.mutex.Lock()
+	last, lastTime := s.last, s.lastTime
+	s.mutex.Unlock()
+	var data dashboardData
+	if last != nil {
+		data.Scanned = true
+		data.scanResponse = newScanResponse(last, lastTime, "")
+		sort.SliceStable(data.Findings, func(i, j int) bool {
+			return data.Findings[i].String() < data.Findings[j].String()
+		})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, data); err != nil {
+		fmt.Fprintf(s.args.diagnostics(), "WARNING: rendering dashboard failed: %s\n", err.Error())
+	}
+}
+
+// handleMetrics serves the metrics of the last periodic scan in the Prometheus text format.
+func (s *server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	s.mutex.Lock()
+	last, lastTime := s.last, s.lastTime
+	s.mutex.Unlock()
+	if last == nil {
+		http.Error(w, "first scan is not finished yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := writeMetrics(w, last, last.findings, lastTime); err != nil {
+		fmt.Fprintf(s.args.diagnostics(), "WARNING: writing metrics failed: %s\n", err.Error())
+	}
+}
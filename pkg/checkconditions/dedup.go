@@ -0,0 +1,57 @@
+package checkconditions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxDedupSamples is the number of object names in a collapsed line of --dedup.
+const maxDedupSamples = 3
+
+// dedupLines returns the output lines of the findings like findingsLines, but findings of several
+// objects with the same resource type, condition, reason and message fingerprint are collapsed into
+// one line with the number of objects and some sample names.
+func dedupLines(args *Arguments, findings []Finding, owners ownerIndex, indent string) []string {
+	single := *args
+	single.Dedup = false
+	groups := make(map[string][]Finding)
+	for i := range findings {
+		f := &findings[i]
+		key := strings.Join([]string{f.Group, f.Resource, f.ConditionType, f.ConditionStatus, f.ConditionReason, f.MessageFingerprint}, "\x00")
+		groups[key] = append(groups[key], *f)
+	}
+	sortedGroups := make([][]Finding, 0, len(groups))
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].String() < group[j].String()
+		})
+		sortedGroups = append(sortedGroups, group)
+	}
+	sort.Slice(sortedGroups, func(i, j int) bool {
+		return sortedGroups[i][0].String() < sortedGroups[j][0].String()
+	})
+	var lines []string
+	for _, group := range sortedGroups {
+		if len(group) == 1 {
+			lines = append(lines, findingsLines(&single, group, owners, indent)...)
+			continue
+		}
+		names := make([]string, 0, maxDedupSamples)
+		for i := 0; i < len(group) && i < maxDedupSamples; i++ {
+			name := group[i].Name
+			if group[i].Namespace != "" {
+				name = group[i].Namespace + "/" + name
+			}
+			names = append(names, name)
+		}
+		if more := len(group) - len(names); more > 0 {
+			names = append(names, fmt.Sprintf("... (%d more)", more))
+		}
+		f := &group[0]
+		lines = append(lines, fmt.Sprintf("%s  %d× %s Condition %s=%s %s %q: %s", indent, len(group), f.Resource,
+			f.ConditionType, colorize(args.color, statusColor(f.ConditionStatus), f.ConditionStatus), f.ConditionReason,
+			f.ConditionMessage, colorize(args.color, colorCyan, strings.Join(names, ", "))))
+	}
+	return lines
+}
@@ -0,0 +1,68 @@
+package checkconditions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDedupLines(t *testing.T) {
+	pod := func(namespace, name, message string) Finding {
+		return Finding{
+			Namespace: namespace, Resource: "pods", Name: name, ConditionType: "Ready", ConditionStatus: "False",
+			ConditionReason: "ContainersNotReady", ConditionMessage: message, MessageFingerprint: messageFingerprint(message),
+		}
+	}
+	tests := []struct {
+		name     string
+		findings []Finding
+		lines    int
+		contains []string
+	}{
+		{
+			name:     "single finding is not collapsed",
+			findings: []Finding{pod("a", "p1", "containers with unready status: [app]")},
+			lines:    1,
+			contains: []string{"a pods p1 Condition Ready=False"},
+		},
+		{
+			name: "same fingerprint is collapsed",
+			findings: []Finding{
+				pod("b", "p2", "back-off 10s restarting"),
+				pod("a", "p1", "back-off 20s restarting"),
+			},
+			lines:    1,
+			contains: []string{"2× pods Condition Ready=False ContainersNotReady", "a/p1, b/p2"},
+		},
+		{
+			name: "different fingerprints are not collapsed",
+			findings: []Finding{
+				pod("a", "p1", "back-off restarting"),
+				pod("a", "p2", "image not found"),
+			},
+			lines:    2,
+			contains: []string{"a pods p1", "a pods p2"},
+		},
+		{
+			name: "different namespaces are collapsed",
+			findings: []Finding{
+				pod("a", "p1", "x"), pod("b", "p1", "x"), pod("c", "p1", "x"), pod("d", "p1", "x"), pod("e", "p1", "x"),
+			},
+			lines:    1,
+			contains: []string{"5× pods", "a/p1, b/p1, c/p1, ... (2 more)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := dedupLines(&Arguments{Dedup: true}, tt.findings, nil, "")
+			if len(lines) != tt.lines {
+				t.Fatalf("got %d lines, want %d: %q", len(lines), tt.lines, lines)
+			}
+			text := strings.Join(lines, "\n")
+			for _, want := range tt.contains {
+				if !strings.Contains(text, want) {
+					t.Errorf("%q does not contain %q", text, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package checkconditions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// duplicateConditions reports objects whose conditions contain the same type more than once.
+// This is a bug of the controller, which breaks many tools (kubectl wait, Argo CD health checks, ...).
+func duplicateConditions(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	conditions, err := objectConditions(args, gvr, obj)
+	if err != nil || len(conditions) < 2 {
+		return nil
+	}
+	counts := make(map[string]int, len(conditions))
+	for _, c := range conditions {
+		if t := stringField(c, "type"); t != "" {
+			counts[t]++
+		}
+	}
+	var duplicates []string
+	for t, n := range counts {
+		if n > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("%s (%d times)", t, n))
+		}
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	sort.Strings(duplicates)
+	if args.customResource(gvr) {
+		if output.duplicateConditions == nil {
+			output.duplicateConditions = make(map[schema.GroupResource]int)
+		}
+		output.duplicateConditions[gvr.GroupResource()]++
+	}
+	return []Finding{{
+		Namespace:          obj.GetNamespace(),
+		Group:              gvr.Group,
+		Version:            gvr.Version,
+		Resource:           gvr.Resource,
+		Kind:               obj.GetKind(),
+		Name:               obj.GetName(),
+		UID:                obj.GetUID(),
+		ConditionType:      "DuplicateConditions",
+		ConditionStatus:    "True",
+		ConditionReason:    "DuplicateConditionTypes",
+		ConditionMessage:   "status.conditions contains these types more than once: " + strings.Join(duplicates, ", "),
+		Severity:           SeverityWarning,
+		MessageFingerprint: "status.conditions contains these types more than once: <types>",
+		Code:               CodeDuplicateConditions,
+		Check:              checkDuplicateConditions,
+	}}
+}
+
+// mergeDuplicateTypes returns one finding per condition type of an object. If the conditions
+// contain a type more than once, the findings would have the same ID, and the notifier, the history
+// and the sinks would mix them up. The finding with the highest severity is kept (the first one of
+// them), and Finding.Details tells how often the type was found.
+func mergeDuplicateTypes(findings []Finding) []Finding {
+	if len(findings) < 2 {
+		return findings
+	}
+	counts := make(map[string]int, len(findings))
+	for i := range findings {
+		counts[findings[i].ConditionType]++
+	}
+	if len(counts) == len(findings) {
+		return findings
+	}
+	merged := make([]Finding, 0, len(counts))
+	index := make(map[string]int, len(counts))
+	for _, f := range findings {
+		i, ok := index[f.ConditionType]
+		if !ok {
+			index[f.ConditionType] = len(merged)
+			merged = append(merged, f)
+			continue
+		}
+		if severityRank[f.Severity] > severityRank[merged[i].Severity] {
+			merged[i] = f
+		}
+	}
+	for i := range merged {
+		if n := counts[merged[i].ConditionType]; n > 1 {
+			merged[i].Details = fmt.Sprintf("status.conditions contains %d conditions of type %s", n, merged[i].ConditionType)
+		}
+	}
+	return merged
+}
+
+// duplicateConditionsPerCRD reports one finding per CRD with duplicate conditions, so that the
+// controllers which exhibit the bug most are visible. The message contains the number of objects.
+func duplicateConditionsPerCRD(_ *Arguments, counter *Counter) []Finding {
+	grs := make([]schema.GroupResource, 0, len(counter.duplicateConditions))
+	for gr := range counter.duplicateConditions {
+		grs = append(grs, gr)
+	}
+	sort.Slice(grs, func(i, j int) bool {
+		ni, nj := counter.duplicateConditions[grs[i]], counter.duplicateConditions[grs[j]]
+		if ni != nj {
+			return ni > nj
+		}
+		return grs[i].String() < grs[j].String()
+	})
+	findings := make([]Finding, 0, len(grs))
+	for _, gr := range grs {
+		findings = append(findings, Finding{
+			Group:              "apiextensions.k8s.io",
+			Version:            "v1",
+			Resource:           "customresourcedefinitions",
+			Kind:               "CustomResourceDefinition",
+			Name:               gr.String(),
+			ConditionType:      "DuplicateConditions",
+			ConditionStatus:    "True",
+			ConditionReason:    "ControllerBug",
+			ConditionMessage:   fmt.Sprintf("%d objects have duplicate condition types", counter.duplicateConditions[gr]),
+			Severity:           SeverityWarning,
+			MessageFingerprint: "<n> objects have duplicate condition types",
+			Code:               CodeDuplicateConditions,
+			Check:              checkDuplicateConditions,
+		})
+	}
+	return findings
+}
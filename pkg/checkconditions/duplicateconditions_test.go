@@ -0,0 +1,69 @@
+package checkconditions
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDuplicateConditions(t *testing.T) {
+	widgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	args := &Arguments{Config: &Config{}, crds: map[schema.GroupResource]*crdInfo{widgets.GroupResource(): {}}}
+	object := func(name string, conditions ...map[string]interface{}) *unstructured.Unstructured {
+		list := make([]interface{}, 0, len(conditions))
+		for _, c := range conditions {
+			list = append(list, c)
+		}
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"conditions": list}}}
+		obj.SetKind("Widget")
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		return obj
+	}
+	condition := func(conditionType, status, reason string) map[string]interface{} {
+		return map[string]interface{}{"type": conditionType, "status": status, "reason": reason}
+	}
+	tests := []struct {
+		name    string
+		obj     *unstructured.Unstructured
+		message string
+	}{
+		{"no duplicates", object("a", condition("Ready", "True", ""), condition("Synced", "True", "")), ""},
+		{"one duplicate type", object("b", condition("Ready", "Unknown", "Pending"), condition("Ready", "False", "Failed"),
+			condition("Synced", "True", "")), "status.conditions contains these types more than once: Ready (2 times)"},
+		{"two duplicate types", object("c", condition("Ready", "True", ""), condition("Synced", "True", ""),
+			condition("Synced", "True", ""), condition("Ready", "True", ""), condition("Ready", "True", "")),
+			"status.conditions contains these types more than once: Ready (3 times), Synced (2 times)"},
+	}
+	output := &handleResourceTypeOutput{}
+	for _, tt := range tests {
+		findings := duplicateConditions(args, widgets, tt.obj, output)
+		message := ""
+		if len(findings) == 1 {
+			message = findings[0].ConditionMessage
+		}
+		if len(findings) > 1 || message != tt.message {
+			t.Errorf("%s: got %+v, want message %q", tt.name, findings, tt.message)
+		}
+	}
+	perCRD := duplicateConditionsPerCRD(args, &Counter{duplicateConditions: output.duplicateConditions})
+	if len(perCRD) != 1 || perCRD[0].Name != "widgets.example.com" || perCRD[0].ConditionMessage != "2 objects have duplicate condition types" {
+		t.Errorf("unexpected findings per CRD %+v", perCRD)
+	}
+
+	// The check conditions reports a duplicate type once, so that the IDs of the findings are unique.
+	obj := tests[1].obj
+	conditions, err := objectConditions(args, widgets, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, _ := printConditions(args, conditions, &handleResourceTypeOutput{}, widgets, *obj)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want one per condition type: %+v", len(findings), findings)
+	}
+	if f := findings[0]; f.ConditionReason != "Failed" || f.Severity != SeverityCritical ||
+		f.Details != "status.conditions contains 2 conditions of type Ready" {
+		t.Errorf("got %+v, want the critical condition with the number of duplicates", f)
+	}
+}
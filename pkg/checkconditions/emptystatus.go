@@ -0,0 +1,51 @@
+package checkconditions
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// emptyStatus reports custom resources which exist longer than Arguments.EmptyStatusAge, but have
+// an empty status. Usually no controller is running for the CRD.
+func emptyStatus(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	_ *handleResourceTypeOutput,
+) []Finding {
+	if !args.customResource(gvr) || obj.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	created := obj.GetCreationTimestamp().Time
+	if created.IsZero() || time.Since(created) < args.EmptyStatusAge {
+		return nil
+	}
+	status, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "status")
+	switch s := status.(type) {
+	case nil:
+	case map[string]interface{}:
+		if len(s) > 0 {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return []Finding{{
+		Namespace:          obj.GetNamespace(),
+		Group:              gvr.Group,
+		Version:            gvr.Version,
+		Resource:           gvr.Resource,
+		Kind:               obj.GetKind(),
+		Name:               obj.GetName(),
+		UID:                obj.GetUID(),
+		ConditionType:      "Status",
+		ConditionStatus:    "Empty",
+		ConditionReason:    "NoController",
+		ConditionMessage:   fmt.Sprintf("status is empty, although the object exists since %s. Is the controller of %s running?", created.Format(time.RFC3339), gvr.GroupResource()),
+		LastTransitionTime: created,
+		Severity:           SeverityWarning,
+		MessageFingerprint: fmt.Sprintf("status is empty, although the object exists since <time>. Is the controller of %s running?", gvr.GroupResource()),
+		Code:               CodeStatusEmpty,
+		Check:              checkEmptyStatus,
+	}}
+}
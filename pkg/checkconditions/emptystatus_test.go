@@ -0,0 +1,57 @@
+package checkconditions
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestEmptyStatus(t *testing.T) {
+	widgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	args := &Arguments{
+		EmptyStatusAge: time.Hour,
+		crds:           map[schema.GroupResource]*crdInfo{widgets.GroupResource(): {}},
+	}
+	object := func(age time.Duration, status interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Widget"}}
+		obj.SetNamespace("a")
+		obj.SetName("w1")
+		obj.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+		if status != nil {
+			obj.Object["status"] = status
+		}
+		return obj
+	}
+	deleted := object(2*time.Hour, nil)
+	deleted.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	tests := []struct {
+		name string
+		gvr  schema.GroupVersionResource
+		obj  *unstructured.Unstructured
+		want bool
+	}{
+		{"no status", widgets, object(2*time.Hour, nil), true},
+		{"empty status", widgets, object(2*time.Hour, map[string]interface{}{}), true},
+		{"status with fields", widgets, object(2*time.Hour, map[string]interface{}{"phase": "Ready"}), false},
+		{"young object", widgets, object(time.Minute, nil), false},
+		{"being deleted", widgets, deleted, false},
+		{"built-in resource", schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, object(2*time.Hour, nil), false},
+	}
+	for _, tt := range tests {
+		findings := emptyStatus(args, tt.gvr, tt.obj, nil)
+		if got := len(findings) == 1; got != tt.want {
+			t.Errorf("%s: got %v, want a finding: %t", tt.name, findings, tt.want)
+			continue
+		}
+		if tt.want {
+			f := findings[0]
+			if f.Code != CodeStatusEmpty || f.Check != checkEmptyStatus || f.ConditionReason != "NoController" ||
+				f.MessageFingerprint != "status is empty, although the object exists since <time>. Is the controller of widgets.example.com running?" {
+				t.Errorf("%s: unexpected finding %+v", tt.name, f)
+			}
+		}
+	}
+}
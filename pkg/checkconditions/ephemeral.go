@@ -0,0 +1,123 @@
+package checkconditions
+
+import (
+	"fmt"
+	"path"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// EphemeralSection reports the findings of ephemeral namespaces in a separate section.
+	EphemeralSection = "section"
+
+	// EphemeralExclude drops the findings of ephemeral namespaces.
+	EphemeralExclude = "exclude"
+)
+
+// defaultTTLAnnotations are set by kube-janitor on namespaces which get deleted automatically.
+var defaultTTLAnnotations = []string{"janitor/ttl", "janitor/expires"}
+
+// EphemeralConfig recognizes short-lived namespaces, for example of CI jobs or preview environments.
+// A namespace is ephemeral if its name matches, if its labels match, or if it has a TTL annotation.
+type EphemeralConfig struct {
+	// Names are globs of namespace names. Example: "pr-*".
+	Names []string `json:"names"`
+
+	// NamespaceSelector matches the labels of the namespaces. Example: "preview=true".
+	NamespaceSelector string `json:"namespaceSelector"`
+
+	// TTLAnnotations are annotations which mark a namespace for automatic deletion.
+	// Defaults to janitor/ttl and janitor/expires (kube-janitor).
+	TTLAnnotations []string `json:"ttlAnnotations"`
+
+	// Mode is "section" (default) or "exclude".
+	Mode string `json:"mode"`
+
+	selector labels.Selector
+}
+
+// parse validates the config and sets the defaults.
+func (e *EphemeralConfig) parse() error {
+	switch e.Mode {
+	case "":
+		e.Mode = EphemeralSection
+	case EphemeralSection, EphemeralExclude:
+	default:
+		return fmt.Errorf("invalid mode %q. Valid values: %s, %s", e.Mode, EphemeralSection, EphemeralExclude)
+	}
+	for _, pattern := range e.Names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+	if e.NamespaceSelector != "" {
+		selector, err := labels.Parse(e.NamespaceSelector)
+		if err != nil {
+			return fmt.Errorf("invalid namespaceSelector: %w", err)
+		}
+		e.selector = selector
+	}
+	if len(e.TTLAnnotations) == 0 {
+		e.TTLAnnotations = defaultTTLAnnotations
+	}
+	return nil
+}
+
+// matches returns true if the namespace is ephemeral.
+func (e *EphemeralConfig) matches(namespace string, namespaces map[string]namespaceMeta) bool {
+	if namespace == "" {
+		return false
+	}
+	for _, pattern := range e.Names {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	meta, ok := namespaces[namespace]
+	if !ok {
+		return false
+	}
+	if e.selector != nil && e.selector.Matches(labels.Set(meta.labels)) {
+		return true
+	}
+	for _, a := range e.TTLAnnotations {
+		if _, ok := meta.annotations[a]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// markEphemeral sets Finding.Ephemeral for the findings in ephemeral namespaces. In mode
+// "exclude" these findings get removed, so they do not reach the output, the sinks and the metrics.
+func markEphemeral(config *Config, counter *Counter) {
+	e := config.Ephemeral
+	if e == nil {
+		return
+	}
+	findings := counter.findings[:0]
+	for i := range counter.findings {
+		f := counter.findings[i]
+		if e.matches(f.Namespace, counter.namespaces) {
+			if e.Mode == EphemeralExclude {
+				continue
+			}
+			f.Ephemeral = true
+		}
+		findings = append(findings, f)
+	}
+	counter.findings = findings
+}
+
+// splitEphemeral returns the findings of regular namespaces and the findings of ephemeral namespaces.
+func splitEphemeral(findings []Finding) (regular, ephemeral []Finding) {
+	for i := range findings {
+		if findings[i].Ephemeral {
+			ephemeral = append(ephemeral, findings[i])
+		} else {
+			regular = append(regular, findings[i])
+		}
+	}
+	return regular, ephemeral
+}
@@ -0,0 +1,78 @@
+package checkconditions
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EscalationConfig raises the severity of findings which persist longer than After. It is used
+// by the serve command. Example: from warning to critical after 24h.
+type EscalationConfig struct {
+	From  string          `json:"from"`
+	To    string          `json:"to"`
+	After metav1.Duration `json:"after"`
+}
+
+// validate checks the severities and the duration.
+func (e *EscalationConfig) validate() error {
+	if _, ok := severityRank[e.From]; !ok {
+		return fmt.Errorf("unknown severity %q in from", e.From)
+	}
+	if _, ok := severityRank[e.To]; !ok {
+		return fmt.Errorf("unknown severity %q in to", e.To)
+	}
+	if severityRank[e.To] <= severityRank[e.From] {
+		return fmt.Errorf("%q is not higher than %q", e.To, e.From)
+	}
+	if e.After.Duration <= 0 {
+		return fmt.Errorf("after is missing")
+	}
+	return nil
+}
+
+// escalate updates the time each finding was seen first, and raises the severity of the findings
+// which persist longer than configured. The first seen time is taken from the history file, if
+// the finding was open before the serve command started.
+func (s *server) escalate(counter *Counter, now time.Time) {
+	firstSeen := make(map[string]time.Time, len(counter.findings))
+	for i := range counter.findings {
+		id := counter.findings[i].ID()
+		first, ok := s.firstSeen[id]
+		if !ok {
+			first = now
+			if s.args.history != nil {
+				if o, ok := s.args.history.open[id]; ok {
+					first = o.firstSeen
+				}
+			}
+		}
+		firstSeen[id] = first
+	}
+	s.firstSeen = firstSeen
+	if escalateFindings(s.args.Config.Escalations, counter.findings, firstSeen, now) > 0 {
+		counter.score, _ = healthScore(s.args.Config.Score, counter.findings)
+	}
+}
+
+// escalateFindings applies the escalations in the order of the config and returns the number of
+// escalated findings. Finding.EscalatedFrom contains the original severity.
+func escalateFindings(escalations []EscalationConfig, findings []Finding, firstSeen map[string]time.Time, now time.Time) int {
+	escalated := 0
+	for i := range findings {
+		f := &findings[i]
+		age := now.Sub(firstSeen[f.ID()])
+		for _, e := range escalations {
+			if f.Severity != e.From || age < e.After.Duration {
+				continue
+			}
+			if f.EscalatedFrom == "" {
+				f.EscalatedFrom = f.Severity
+				escalated++
+			}
+			f.Severity = e.To
+		}
+	}
+	return escalated
+}
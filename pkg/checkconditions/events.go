@@ -0,0 +1,135 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EventsConfig configures the creation of Kubernetes Events for findings.
+type EventsConfig struct {
+	// MinSeverity is the lowest severity which creates Events. Defaults to warning (all findings).
+	MinSeverity string `json:"minSeverity"`
+	// ReportingController defaults to "check-conditions".
+	ReportingController string `json:"reportingController"`
+}
+
+// eventNamePrefix is the prefix of the names of all Events created by check-conditions.
+const eventNamePrefix = "check-conditions."
+
+type eventsSink struct {
+	config *EventsConfig
+}
+
+func newEventsSink(config *EventsConfig) *eventsSink {
+	if config.MinSeverity == "" {
+		config.MinSeverity = SeverityWarning
+	}
+	if config.ReportingController == "" {
+		config.ReportingController = "check-conditions"
+	}
+	return &eventsSink{config: config}
+}
+
+func (s *eventsSink) name() string {
+	return "Events"
+}
+
+// send creates an Event for each finding. The name of the Event is derived from the hash of the
+// finding ID. If the Event exists (from a previous scan), its count and lastTimestamp get updated,
+// so that repeated scans do not create duplicate Events. In serve mode, only new and changed
+// findings update the Event, see Arguments.sinkDue.
+func (s *eventsSink) send(ctx context.Context, args *Arguments, counter *Counter) error {
+	config, err := newRestConfig(args)
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	return s.sendEvents(ctx, args, counter, clientset)
+}
+
+// sendEvents creates or updates the Events with the clientset. A finding whose Event could not be
+// written gets logged, and is sent again after the next scan.
+func (s *eventsSink) sendEvents(ctx context.Context, args *Arguments, counter *Counter, clientset kubernetes.Interface) error {
+	now := metav1.NewTime(time.Now())
+	failed := 0
+	for i := range counter.findings {
+		f := &counter.findings[i]
+		if f.Maintenance != "" || !severityAtLeast(f.Severity, s.minSeverity(args.Config, counter, f)) {
+			continue
+		}
+		if !args.sinkDue(s.name(), f) {
+			continue
+		}
+		namespace := f.Namespace
+		if namespace == "" {
+			// Events of cluster-scoped objects are in the namespace "default".
+			namespace = metav1.NamespaceDefault
+		}
+		events := clientset.CoreV1().Events(namespace)
+		name := eventNamePrefix + f.hash()
+		message := strings.TrimSpace(f.String())
+		event, err := events.Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			_, err = events.Create(ctx, s.newEvent(f, namespace, name, message, now), metav1.CreateOptions{})
+		case err == nil:
+			event.Count++
+			event.LastTimestamp = now
+			event.Message = message
+			_, err = events.Update(ctx, event, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: writing the Event for %s failed: %s\n", f.ID(), err.Error())
+			failed++
+			continue
+		}
+		args.sinkSent(s.name(), f)
+	}
+	return sendFailed(failed, "Events")
+}
+
+// minSeverity returns the eventsMinSeverity of the team of the finding, or the minSeverity of the config.
+func (s *eventsSink) minSeverity(config *Config, counter *Counter, f *Finding) string {
+	if team := teamConfigOf(config, counter.namespaces, f.Namespace); team != nil && team.EventsMinSeverity != "" {
+		return team.EventsMinSeverity
+	}
+	return s.config.MinSeverity
+}
+
+func (s *eventsSink) newEvent(f *Finding, namespace, name, message string, now metav1.Time) *corev1.Event {
+	reason := f.ConditionReason
+	if reason == "" {
+		reason = f.ConditionType + f.ConditionStatus
+	}
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: schema.GroupVersion{Group: f.Group, Version: f.Version}.String(),
+			Kind:       f.Kind,
+			Namespace:  f.Namespace,
+			Name:       f.Name,
+			UID:        f.UID,
+		},
+		Reason:              reason,
+		Message:             message,
+		Type:                corev1.EventTypeWarning,
+		Count:               1,
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Source:              corev1.EventSource{Component: s.config.ReportingController},
+		ReportingController: s.config.ReportingController,
+		ReportingInstance:   s.config.ReportingController,
+		Action:              "Check",
+	}
+}
@@ -0,0 +1,74 @@
+package checkconditions
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestEventsSink(t *testing.T) {
+	pod := Finding{
+		Namespace: "a", Version: "v1", Resource: "pods", Kind: "Pod", Name: "p1",
+		ConditionType: "Ready", ConditionStatus: "False", ConditionReason: "CrashLoop", Severity: SeverityWarning,
+	}
+	node := Finding{Version: "v1", Resource: "nodes", Kind: "Node", Name: "n1", ConditionType: "Ready", ConditionStatus: "False", Severity: SeverityCritical}
+	clientset := fake.NewSimpleClientset()
+	creates := 0
+	clientset.PrependReactor("create", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		creates++
+		if creates == 1 {
+			return true, nil, fmt.Errorf("etcd is unavailable")
+		}
+		return false, nil, nil
+	})
+	var diagnostics bytes.Buffer
+	args := &Arguments{Config: &Config{}, stderr: &diagnostics, sinkNotifiers: make(map[string]*notifier)}
+	s := newEventsSink(&EventsConfig{})
+	counter := &Counter{findings: []Finding{pod, node}}
+
+	// The Event of the pod fails, the Event of the node (namespace default) still gets created.
+	err := s.sendEvents(t.Context(), args, counter, clientset)
+	if err == nil || !strings.Contains(diagnostics.String(), pod.ID()) {
+		t.Fatalf("error %v, diagnostics %q: want the failed Event of the pod", err, diagnostics.String())
+	}
+	if _, err := clientset.CoreV1().Events(metav1.NamespaceDefault).Get(t.Context(), eventNamePrefix+node.hash(), metav1.GetOptions{}); err != nil {
+		t.Fatalf("no Event of the node: %v", err)
+	}
+
+	// The next scan retries the pod, and does not touch the unchanged node.
+	if err := s.sendEvents(t.Context(), args, counter, clientset); err != nil {
+		t.Fatal(err)
+	}
+	event, err := clientset.CoreV1().Events("a").Get(t.Context(), eventNamePrefix+pod.hash(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("no Event of the pod after the retry: %v", err)
+	}
+	if event.Reason != "CrashLoop" || event.Type != corev1.EventTypeWarning || event.InvolvedObject.Name != "p1" {
+		t.Errorf("unexpected Event %+v", event)
+	}
+
+	// A changed finding updates the existing Event instead of creating a duplicate.
+	changed := pod
+	changed.ConditionMessage = "back-off"
+	counter.findings = []Finding{changed, node}
+	if err := s.sendEvents(t.Context(), args, counter, clientset); err != nil {
+		t.Fatal(err)
+	}
+	events, err := clientset.CoreV1().Events("a").List(t.Context(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 1 || events.Items[0].Count != event.Count+1 || !strings.Contains(events.Items[0].Message, "back-off") {
+		t.Errorf("got Events %+v, want one updated Event", events.Items)
+	}
+	if creates != 3 {
+		t.Errorf("%d creates, want 3", creates)
+	}
+}
@@ -0,0 +1,146 @@
+package checkconditions
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExitCodeFindings is the default exit code, if an exit policy fails. See ExitPolicyConfig.
+const ExitCodeFindings = 2
+
+// ExitPolicyConfig defines when a scan fails (exit code != 0). It gets selected via --exit-policy,
+// so that CI jobs and cron jobs share the same semantics.
+type ExitPolicyConfig struct {
+	Name string `json:"name"`
+
+	// Severities of the findings which count. Defaults to critical.
+	Severities []string `json:"severities"`
+
+	// Threshold is the number of counted findings which fails the scan. Defaults to 1.
+	Threshold int `json:"threshold"`
+
+	// ScanErrors fails the scan, if resource types could not be listed or were skipped.
+	ScanErrors bool `json:"scanErrors"`
+
+	// Suppressed counts findings in maintenance windows, in ephemeral namespaces and findings
+	// within the grace period, too.
+	Suppressed bool `json:"suppressed"`
+
+	// ExitCode is the exit code of a failed scan. Defaults to 2.
+	ExitCode int `json:"exitCode"`
+}
+
+// validate checks the severities and sets the defaults.
+func (p *ExitPolicyConfig) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is missing")
+	}
+	if len(p.Severities) == 0 {
+		p.Severities = []string{SeverityCritical}
+	}
+	for _, s := range p.Severities {
+		if _, ok := severityRank[s]; !ok {
+			return fmt.Errorf("unknown severity %q", s)
+		}
+	}
+	if p.Threshold < 0 {
+		return fmt.Errorf("negative threshold %d", p.Threshold)
+	}
+	if p.Threshold == 0 {
+		p.Threshold = 1
+	}
+	if p.ExitCode < 0 || p.ExitCode > 255 {
+		return fmt.Errorf("exitCode %d is not between 0 and 255", p.ExitCode)
+	}
+	if p.ExitCode == 0 {
+		p.ExitCode = ExitCodeFindings
+	}
+	return nil
+}
+
+// builtinExitPolicies can be used without config file. Exit policies of the config file with the
+// same name take precedence.
+var builtinExitPolicies = []ExitPolicyConfig{
+	{
+		Name:       "fail-on-critical",
+		Severities: []string{SeverityCritical},
+		Threshold:  1,
+		ExitCode:   ExitCodeFindings,
+	},
+	{
+		Name:       "fail-on-warning",
+		Severities: []string{SeverityCritical, SeverityWarning},
+		Threshold:  1,
+		ScanErrors: true,
+		ExitCode:   ExitCodeFindings,
+	},
+}
+
+// builtinExitPolicyNames returns the names of builtinExitPolicies.
+func builtinExitPolicyNames() []string {
+	names := make([]string, 0, len(builtinExitPolicies))
+	for _, p := range builtinExitPolicies {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// exitPolicy returns the exit policy with the name, or nil.
+func (c *Config) exitPolicy(name string) *ExitPolicyConfig {
+	for i := range c.ExitPolicies {
+		if c.ExitPolicies[i].Name == name {
+			return &c.ExitPolicies[i]
+		}
+	}
+	for i := range builtinExitPolicies {
+		if builtinExitPolicies[i].Name == name {
+			p := builtinExitPolicies[i]
+			return &p
+		}
+	}
+	return nil
+}
+
+// evaluate returns the reasons why the scan fails. No reasons mean success.
+func (p *ExitPolicyConfig) evaluate(findings []Finding, counter *Counter) []string {
+	if p.Suppressed {
+		findings = append(append([]Finding(nil), findings...), counter.pending...)
+	}
+	count := 0
+	for i := range findings {
+		f := &findings[i]
+		if !p.Suppressed && (f.Maintenance != "" || f.Ephemeral) {
+			continue
+		}
+		for _, s := range p.Severities {
+			if f.Severity == s {
+				count++
+				break
+			}
+		}
+	}
+	var reasons []string
+	if count >= p.Threshold {
+		reasons = append(reasons, fmt.Sprintf("%d findings with severity %s (threshold %d)", count,
+			strings.Join(p.Severities, "/"), p.Threshold))
+	}
+	if p.ScanErrors && (len(counter.errors) > 0 || len(counter.skipped) > 0) {
+		reasons = append(reasons, fmt.Sprintf("%d scan errors, %d skipped resource types", len(counter.errors),
+			len(counter.skipped)))
+	}
+	return reasons
+}
+
+// exitPolicyCode returns the exit code of the exit policy of --exit-policy. 0 means success.
+func exitPolicyCode(args *Arguments, findings []Finding, counter *Counter) int {
+	if args.ExitPolicy == "" {
+		return 0
+	}
+	p := args.Config.exitPolicy(args.ExitPolicy)
+	reasons := p.evaluate(findings, counter)
+	if len(reasons) == 0 {
+		return 0
+	}
+	fmt.Fprintf(args.diagnostics(), "Exit policy %q failed: %s\n", p.Name, strings.Join(reasons, ", "))
+	return p.ExitCode
+}
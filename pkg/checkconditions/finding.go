@@ -0,0 +1,152 @@
+package checkconditions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// SeverityCritical is used for findings which clearly show that something is broken.
+	SeverityCritical = "critical"
+
+	// SeverityWarning is used for all other findings.
+	SeverityWarning = "warning"
+)
+
+// severityRank is used to compare severities.
+var severityRank = map[string]int{
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+// severityAtLeast returns true if the severity is equal or higher than the threshold.
+func severityAtLeast(severity, threshold string) bool {
+	return severityRank[severity] >= severityRank[threshold]
+}
+
+// Finding is a condition of a resource object which needs attention.
+type Finding struct {
+	Namespace          string    `json:"namespace,omitempty"`
+	Group              string    `json:"group"`
+	Version            string    `json:"version"`
+	Resource           string    `json:"resource"`
+	Kind               string    `json:"kind"`
+	Name               string    `json:"name"`
+	UID                types.UID `json:"uid"`
+	ConditionType      string    `json:"conditionType"`
+	ConditionStatus    string    `json:"conditionStatus"`
+	ConditionReason    string    `json:"conditionReason"`
+	ConditionMessage   string    `json:"conditionMessage"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	Severity           string    `json:"severity"`
+
+	// Details contains data of synthetic findings which changes from scan to scan, like the names
+	// of the affected objects. It is not part of the message, so that a change of the details does
+	// not notify the sinks again. See notifier.changed.
+	Details string `json:"details,omitempty"`
+
+	// Check is the ID of the check which created the finding. See checkDefinitions.
+	Check string `json:"check"`
+
+	// Code is a stable identifier of the kind of the finding, like CONDITION_FALSE. See codes.go.
+	Code string `json:"code"`
+
+	// MessageFingerprint is the condition message with UUIDs, timestamps, IPs and numbers replaced
+	// by placeholders. See messageFingerprint.
+	MessageFingerprint string `json:"messageFingerprint"`
+
+	// Maintenance is the name of the maintenance window, if the finding is in an active maintenance window.
+	Maintenance string `json:"maintenance,omitempty"`
+
+	// EscalatedFrom is the original severity, if the finding persisted so long, that its severity
+	// was raised. See EscalationConfig.
+	EscalatedFrom string `json:"escalatedFrom,omitempty"`
+
+	// Ephemeral is true if the namespace is short-lived (CI, preview environments). See EphemeralConfig.
+	Ephemeral bool `json:"ephemeral,omitempty"`
+
+	// TerminatingOwner is set if an owner of the object is terminating. See markTerminatingOwners.
+	TerminatingOwner *TerminatingOwner `json:"terminatingOwner,omitempty"`
+
+	// CreationTimestamp is the creation time of the object.
+	CreationTimestamp time.Time `json:"creationTimestamp"`
+
+	// FirstSeen and LastSeen are the times of the first and the last scan which found the finding.
+	// Occurrences is the number of scans which found it since FirstSeen. Only set with --history-file.
+	FirstSeen   *time.Time `json:"firstSeen,omitempty"`
+	LastSeen    *time.Time `json:"lastSeen,omitempty"`
+	Occurrences int        `json:"occurrences,omitempty"`
+
+	// NotReady is set for the Ready condition of nodes which are NotReady. See describeNotReadyNodes.
+	NotReady *NotReadyNode `json:"notReady,omitempty"`
+
+	// Zone and NodePool are the topology of the node of findings of pods and nodes.
+	Zone     string `json:"zone,omitempty"`
+	NodePool string `json:"nodePool,omitempty"`
+
+	// Events are the newest core/v1 Events of the object. Only set with --attach-events.
+	Events []RelatedEvent `json:"events,omitempty"`
+}
+
+// TerminatingOwner is an owner with deletionTimestamp.
+type TerminatingOwner struct {
+	Kind  string    `json:"kind"`
+	Name  string    `json:"name"`
+	Since time.Time `json:"since"`
+}
+
+// NotReadyNode tells since when a node is NotReady, and how many pods are stranded on it.
+type NotReadyNode struct {
+	Since        *time.Time `json:"since,omitempty"`
+	StrandedPods int        `json:"strandedPods"`
+}
+
+// ID identifies the finding across several runs. It does not contain the status, reason or message,
+// so that a changed condition keeps its ID.
+func (f *Finding) ID() string {
+	return strings.Join([]string{f.Group, f.Resource, f.Namespace, f.Name, f.ConditionType}, "/")
+}
+
+// String returns the line which gets printed for this finding.
+func (f *Finding) String() string {
+	return f.text(false)
+}
+
+// text returns the line of String. If color is true, the resource and the status get colored.
+func (f *Finding) text(color bool) string {
+	duration := ""
+	if !f.LastTransitionTime.IsZero() {
+		d := time.Since(f.LastTransitionTime)
+		duration = fmt.Sprint(d.Round(time.Second))
+	}
+	suffix := ""
+	if f.Maintenance != "" {
+		suffix = fmt.Sprintf(" [maintenance %s]", f.Maintenance)
+	}
+	if f.EscalatedFrom != "" {
+		suffix += fmt.Sprintf(" [escalated from %s]", f.EscalatedFrom)
+	}
+	if o := f.TerminatingOwner; o != nil {
+		suffix += fmt.Sprintf(" [owner %s %s is terminating since %s]", o.Kind, o.Name,
+			time.Since(o.Since).Round(time.Second))
+	}
+	if f.Details != "" {
+		suffix += fmt.Sprintf(" [%s]", f.Details)
+	}
+	if f.FirstSeen != nil {
+		suffix += fmt.Sprintf(" [first seen %s ago, %d scans]", time.Since(*f.FirstSeen).Round(time.Second), f.Occurrences)
+	}
+	if n := f.NotReady; n != nil {
+		notReady := "NotReady"
+		if n.Since != nil {
+			notReady = fmt.Sprintf("NotReady for %s", time.Since(*n.Since).Round(time.Second))
+		}
+		suffix += fmt.Sprintf(" [%s, %d pods stranded]", notReady, n.StrandedPods)
+	}
+	return fmt.Sprintf("  %s %s %s Condition %s=%s %s %q (%s)%s", f.Namespace, f.Resource,
+		colorize(color, colorCyan, f.Name), f.ConditionType, colorize(color, statusColor(f.ConditionStatus), f.ConditionStatus),
+		f.ConditionReason, f.ConditionMessage, duration, suffix)
+}
@@ -0,0 +1,27 @@
+package checkconditions
+
+import "regexp"
+
+// messageFingerprintPatterns replace the variable parts of condition messages. The order matters:
+// timestamps and IPs contain numbers.
+var messageFingerprintPatterns = []struct {
+	re          *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?( [A-Z]{3,4})?`), "<time>"},
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), "<time>"},
+	{regexp.MustCompile(`\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}(:\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`(?i)\[?\b((?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}|(?:[0-9a-f]{1,4}:){1,6}:(?:[0-9a-f]{1,4}(?::[0-9a-f]{1,4})*)?)\b\]?(:\d+)?`), "<ip>"},
+	{regexp.MustCompile(`\b(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+\b`), "<duration>"},
+	{regexp.MustCompile(`\b\d+(\.\d+)?\b`), "<n>"},
+}
+
+// messageFingerprint returns the message with UUIDs, timestamps, IPs and numbers replaced by
+// placeholders. Messages which only differ in these parts have the same fingerprint.
+func messageFingerprint(message string) string {
+	for _, p := range messageFingerprintPatterns {
+		message = p.re.ReplaceAllString(message, p.placeholder)
+	}
+	return message
+}
@@ -0,0 +1,209 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	restclient "k8s.io/client-go/rest"
+)
+
+// snapshotServer answers read requests like an api-server, with the objects of an extracted
+// snapshot. See RunSnapshot. So all commands work with --from-dir like with a cluster.
+type snapshotServer struct {
+	meta  snapshotMeta
+	lists map[schema.GroupVersionResource]*unstructured.UnstructuredList
+}
+
+// loadSnapshotDir reads the snapshot which was extracted to dir.
+func loadSnapshotDir(dir string) (*snapshotServer, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotMetaFile))
+	if err != nil {
+		return nil, fmt.Errorf("--from-dir: %w", err)
+	}
+	s := &snapshotServer{lists: make(map[schema.GroupVersionResource]*unstructured.UnstructuredList)}
+	if err := json.Unmarshal(data, &s.meta); err != nil {
+		return nil, fmt.Errorf("--from-dir: %s: %w", snapshotMetaFile, err)
+	}
+	for _, resourceList := range s.meta.Resources {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("--from-dir: %s: %w", snapshotMetaFile, err)
+		}
+		for _, resource := range resourceList.APIResources {
+			gvr := gv.WithResource(resource.Name)
+			path := filepath.Join(dir, filepath.FromSlash(snapshotObjectsPath(gvr)))
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("--from-dir: %w", err)
+			}
+			list := &unstructured.UnstructuredList{}
+			if err := list.UnmarshalJSON(data); err != nil {
+				return nil, fmt.Errorf("--from-dir: %s: %w", path, err)
+			}
+			s.lists[gvr] = list
+		}
+	}
+	return s, nil
+}
+
+// snapshotRestConfig starts a snapshotServer on localhost, and returns the config to access it.
+// The cluster name is taken from the snapshot.
+func snapshotRestConfig(args *Arguments) (*restclient.Config, error) {
+	s, err := loadSnapshotDir(args.FromDir)
+	if err != nil {
+		return nil, err
+	}
+	args.clusterName = s.meta.Cluster
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := http.Serve(listener, s); err != nil && !errors.Is(err, net.ErrClosed) {
+			fmt.Fprintf(args.diagnostics(), "WARNING: --from-dir server failed: %s\n", err.Error())
+		}
+	}()
+	return &restclient.Config{Host: "http://" + listener.Addr().String()}, nil
+}
+
+func (s *snapshotServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIStatus(w, apierrors.NewMethodNotSupported(schema.GroupResource{}, r.Method))
+		return
+	}
+	if r.URL.Query().Get("watch") == "true" {
+		writeAPIStatus(w, apierrors.NewMethodNotSupported(schema.GroupResource{}, "watch"))
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "version":
+		writeJSON(w, s.meta.ServerVersion)
+	case len(parts) == 1 && parts[0] == "api":
+		writeJSON(w, s.coreVersions())
+	case len(parts) == 1 && parts[0] == "apis":
+		writeJSON(w, s.groups())
+	case len(parts) >= 2 && parts[0] == "api":
+		s.serveGroupVersion(w, r, schema.GroupVersion{Version: parts[1]}, parts[2:])
+	case len(parts) >= 3 && parts[0] == "apis":
+		s.serveGroupVersion(w, r, schema.GroupVersion{Group: parts[1], Version: parts[2]}, parts[3:])
+	default:
+		writeAPIStatus(w, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+	}
+}
+
+func (s *snapshotServer) coreVersions() *metav1.APIVersions {
+	versions := &metav1.APIVersions{TypeMeta: metav1.TypeMeta{Kind: "APIVersions"}}
+	for _, list := range s.meta.Resources {
+		if !strings.Contains(list.GroupVersion, "/") {
+			versions.Versions = append(versions.Versions, list.GroupVersion)
+		}
+	}
+	return versions
+}
+
+func (s *snapshotServer) groups() *metav1.APIGroupList {
+	groupList := &metav1.APIGroupList{TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"}}
+	index := make(map[string]int)
+	for _, list := range s.meta.Resources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil || gv.Group == "" {
+			continue
+		}
+		version := metav1.GroupVersionForDiscovery{GroupVersion: gv.String(), Version: gv.Version}
+		i, ok := index[gv.Group]
+		if !ok {
+			// The snapshot only contains the preferred version of each group.
+			index[gv.Group] = len(groupList.Groups)
+			groupList.Groups = append(groupList.Groups, metav1.APIGroup{Name: gv.Group, PreferredVersion: version})
+			i = index[gv.Group]
+		}
+		groupList.Groups[i].Versions = append(groupList.Groups[i].Versions, version)
+	}
+	return groupList
+}
+
+// serveGroupVersion answers the discovery of the group version, and lists and gets of objects:
+// RESOURCE, RESOURCE/NAME, namespaces/NAMESPACE/RESOURCE and namespaces/NAMESPACE/RESOURCE/NAME.
+func (s *snapshotServer) serveGroupVersion(w http.ResponseWriter, r *http.Request, gv schema.GroupVersion, rest []string) {
+	if len(rest) == 0 {
+		for _, list := range s.meta.Resources {
+			if list.GroupVersion == gv.String() {
+				resources := *list
+				resources.TypeMeta = metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"}
+				writeJSON(w, &resources)
+				return
+			}
+		}
+		writeAPIStatus(w, apierrors.NewNotFound(schema.GroupResource{}, gv.String()))
+		return
+	}
+	namespace := ""
+	if len(rest) >= 3 && rest[0] == "namespaces" {
+		namespace, rest = rest[1], rest[2:]
+	}
+	if len(rest) > 2 {
+		// Subresources are not part of a snapshot.
+		writeAPIStatus(w, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+		return
+	}
+	gvr := gv.WithResource(rest[0])
+	list, ok := s.lists[gvr]
+	if !ok {
+		writeAPIStatus(w, apierrors.NewNotFound(gvr.GroupResource(), ""))
+		return
+	}
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeAPIStatus(w, apierrors.NewBadRequest(err.Error()))
+		return
+	}
+	result := &unstructured.UnstructuredList{Object: list.Object}
+	for i := range list.Items {
+		obj := &list.Items[i]
+		if namespace != "" && obj.GetNamespace() != namespace {
+			continue
+		}
+		if len(rest) == 2 {
+			if obj.GetName() == rest[1] {
+				writeJSON(w, obj.Object)
+				return
+			}
+			continue
+		}
+		if selector.Matches(labels.Set(obj.GetLabels())) {
+			result.Items = append(result.Items, *obj)
+		}
+	}
+	if len(rest) == 2 {
+		writeAPIStatus(w, apierrors.NewNotFound(gvr.GroupResource(), rest[1]))
+		return
+	}
+	data, err := result.MarshalJSON()
+	if err != nil {
+		writeAPIStatus(w, apierrors.NewInternalError(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// writeAPIStatus writes the error like the api-server, so that client-go returns it as StatusError.
+func writeAPIStatus(w http.ResponseWriter, err *apierrors.StatusError) {
+	status := err.ErrStatus
+	status.TypeMeta = metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(status.Code))
+	_ = json.NewEncoder(w).Encode(status)
+}
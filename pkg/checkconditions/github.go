@@ -0,0 +1,197 @@
+package checkconditions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// GitHubConfig configures the creation of GitHub issues for critical findings.
+type GitHubConfig struct {
+	// Repo is "owner/name".
+	Repo string `json:"repo"`
+	// APIURL defaults to https://api.github.com. Set it for GitHub Enterprise.
+	APIURL string `json:"apiURL"`
+	// TokenEnv is the name of the environment variable containing the token. Defaults to GITHUB_TOKEN.
+	TokenEnv string `json:"tokenEnv"`
+}
+
+// githubLabel is set on all issues created by check-conditions.
+const githubLabel = "check-conditions"
+
+// githubIDRegex finds the hash of the finding ID in the body of an issue.
+var githubIDRegex = regexp.MustCompile(`<!-- check-conditions-id: ([\w-]+) -->`)
+
+type githubSink struct {
+	config *GitHubConfig
+	header http.Header
+}
+
+func newGitHubSink(config *GitHubConfig) *githubSink {
+	if config.APIURL == "" {
+		config.APIURL = "https://api.github.com"
+	}
+	if config.TokenEnv == "" {
+		config.TokenEnv = "GITHUB_TOKEN"
+	}
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+os.Getenv(config.TokenEnv))
+	return &githubSink{config: config, header: header}
+}
+
+func (s *githubSink) name() string {
+	return "GitHub"
+}
+
+type githubIssue struct {
+	Number int    `json:"number"`
+	Body   string `json:"body"`
+
+	// PullRequest is set, if the item is a pull request. The issues API returns pull requests, too.
+	PullRequest json.RawMessage `json:"pull_request,omitempty"`
+}
+
+// githubIssueRef identifies an issue in one of the repos of the sink.
+type githubIssueRef struct {
+	repo   string
+	number int
+}
+
+// send creates an issue for each new critical finding, and closes the issues of findings which are resolved.
+// The key of the finding (see sinkKey) is stored as comment in the body of the issue, so that no duplicate issues get created.
+// The issues of a team get created in the githubRepo of the team.
+// A finding whose issue could not be created gets logged, and is sent again after the next scan.
+func (s *githubSink) send(ctx context.Context, args *Arguments, counter *Counter) error {
+	open, err := s.openIssues(ctx, s.repos(args.Config))
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for i := range counter.findings {
+		f := &counter.findings[i]
+		key := args.sinkKey(f)
+		if f.Severity != SeverityCritical {
+			continue
+		}
+		if f.Maintenance != "" {
+			continue
+		}
+		if !args.sinkDue(s.name(), f) {
+			continue
+		}
+		if _, ok := open[key]; !ok {
+			if err := s.createIssue(ctx, args.diagnostics(), s.repoOf(args.Config, counter, f), f, key); err != nil {
+				fmt.Fprintf(args.diagnostics(), "WARNING: creating a GitHub issue for %s failed: %s\n", f.ID(), err.Error())
+				failed++
+				continue
+			}
+		}
+		args.sinkSent(s.name(), f)
+	}
+	if !closeResolved(args, counter, s.name()) {
+		return sendFailed(failed, "GitHub issues")
+	}
+	current := currentKeys(args, counter, "")
+	for key, issue := range open {
+		if current[key] || !args.ownKey("", key) {
+			continue
+		}
+		if err := s.closeIssue(ctx, args.diagnostics(), issue); err != nil {
+			return err
+		}
+	}
+	return sendFailed(failed, "GitHub issues")
+}
+
+// repos returns the repo of the config and the repos of the teams.
+func (s *githubSink) repos(config *Config) []string {
+	repos := []string{s.config.Repo}
+	for i := range config.Teams {
+		if repo := config.Teams[i].GitHubRepo; repo != "" && !slices.Contains(repos, repo) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+// repoOf returns the repo for the finding: the repo of its team, or the repo of the config.
+func (s *githubSink) repoOf(config *Config, counter *Counter, f *Finding) string {
+	if team := teamConfigOf(config, counter.namespaces, f.Namespace); team != nil && team.GitHubRepo != "" {
+		return team.GitHubRepo
+	}
+	return s.config.Repo
+}
+
+func (s *githubSink) repoURL(repo string) string {
+	return strings.TrimSuffix(s.config.APIURL, "/") + "/repos/" + repo
+}
+
+// openIssues returns the open issues created by check-conditions in the repos, indexed by the key of the finding.
+func (s *githubSink) openIssues(ctx context.Context, repos []string) (map[string]githubIssueRef, error) {
+	issues := make(map[string]githubIssueRef)
+	for _, repo := range repos {
+		for page := 1; ; page++ {
+			var result []githubIssue
+			u := fmt.Sprintf("%s/issues?labels=%s&state=open&per_page=100&page=%d", s.repoURL(repo), githubLabel, page)
+			if err := doJSON(ctx, http.MethodGet, u, s.header, nil, &result); err != nil {
+				return nil, err
+			}
+			for _, issue := range result {
+				if issue.PullRequest != nil {
+					continue
+				}
+				if m := githubIDRegex.FindStringSubmatch(issue.Body); m != nil {
+					issues[m[1]] = githubIssueRef{repo: repo, number: issue.Number}
+				}
+			}
+			if len(result) < 100 {
+				break
+			}
+		}
+	}
+	return issues, nil
+}
+
+func (s *githubSink) createIssue(ctx context.Context, log io.Writer, repo string, f *Finding, key string) error {
+	labels := []string{githubLabel}
+	if f.Kind != "" {
+		labels = append(labels, "kind:"+f.Kind)
+	}
+	if f.Namespace != "" {
+		labels = append(labels, "namespace:"+f.Namespace)
+	}
+	body := map[string]interface{}{
+		"title": strings.TrimSpace(fmt.Sprintf("%s %s %s: %s=%s %s", f.Namespace, f.Resource, f.Name,
+			f.ConditionType, f.ConditionStatus, f.ConditionReason)),
+		"body": fmt.Sprintf("check-conditions found this condition:\n\n```\n%s\n```\n\nFinding ID: `%s`\n\n<!-- check-conditions-id: %s -->\n",
+			strings.TrimSpace(f.String()), f.ID(), key),
+		"labels": labels,
+	}
+	var created githubIssue
+	if err := doJSON(ctx, http.MethodPost, s.repoURL(repo)+"/issues", s.header, body, &created); err != nil {
+		return err
+	}
+	fmt.Fprintf(log, "Created GitHub issue %s#%d for %s\n", repo, created.Number, f.ID())
+	return nil
+}
+
+func (s *githubSink) closeIssue(ctx context.Context, log io.Writer, issue githubIssueRef) error {
+	u := fmt.Sprintf("%s/issues/%d", s.repoURL(issue.repo), issue.number)
+	comment := map[string]string{"body": "check-conditions: the condition is resolved."}
+	if err := doJSON(ctx, http.MethodPost, u+"/comments", s.header, comment, nil); err != nil {
+		return err
+	}
+	state := map[string]string{"state": "closed", "state_reason": "completed"}
+	if err := doJSON(ctx, http.MethodPatch, u, s.header, state, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(log, "Closed GitHub issue %s#%d\n", issue.repo, issue.number)
+	return nil
+}
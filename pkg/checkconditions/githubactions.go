@@ -0,0 +1,50 @@
+package checkconditions
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// writeGitHub writes the findings as workflow commands of GitHub Actions, which are shown as
+// annotations in the summary of the run. Critical findings are errors, the others warnings.
+func writeGitHub(w io.Writer, r *report) error {
+	for i := range r.Findings {
+		f := &r.Findings[i]
+		level := "warning"
+		if f.Severity == SeverityCritical {
+			level = "error"
+		}
+		title := fmt.Sprintf("%s %s %s=%s", f.Resource, f.Name, f.ConditionType, f.ConditionStatus)
+		if f.Namespace != "" {
+			title = fmt.Sprintf("%s %s/%s %s=%s", f.Resource, f.Namespace, f.Name, f.ConditionType, f.ConditionStatus)
+		}
+		message := f.ConditionReason + " " + f.ConditionMessage
+		if f.FirstSeen != nil {
+			message += fmt.Sprintf(" (first seen %s, %d scans)", f.FirstSeen.UTC().Format(time.RFC3339), f.Occurrences)
+		}
+		if _, err := fmt.Fprintf(w, "::%s title=%s::%s\n", level, githubEscapeProperty(title), githubEscapeData(message)); err != nil {
+			return err
+		}
+	}
+	for _, e := range r.Errors {
+		if _, err := fmt.Fprintf(w, "::warning title=%s::%s\n", githubEscapeProperty("scan error "+e.Type),
+			githubEscapeData(e.Message)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "::notice title=check-conditions::%d findings, %d checked resources, health score %d\n",
+		len(r.Findings), r.CheckedResources, r.Score)
+	return err
+}
+
+// githubEscapeData escapes the message of a workflow command.
+func githubEscapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubEscapeProperty escapes a property (like title) of a workflow command.
+func githubEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
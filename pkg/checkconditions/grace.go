@@ -0,0 +1,20 @@
+package checkconditions
+
+import "time"
+
+// holdBackPending removes the findings whose condition changed less than gracePeriod ago, since
+// these often resolve by themselves. They get reported by a later scan, if they persist.
+func holdBackPending(counter *Counter, gracePeriod time.Duration, now time.Time) {
+	if gracePeriod <= 0 {
+		return
+	}
+	findings := counter.findings[:0]
+	for _, f := range counter.findings {
+		if !f.LastTransitionTime.IsZero() && now.Sub(f.LastTransitionTime) < gracePeriod {
+			counter.pending = append(counter.pending, f)
+			continue
+		}
+		findings = append(findings, f)
+	}
+	counter.findings = findings
+}
@@ -0,0 +1,91 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// GrafanaConfig configures annotations in Grafana. An annotation gets created when a scan starts,
+// and it gets updated with the number of findings when the scan is finished.
+type GrafanaConfig struct {
+	URL string `json:"url"`
+	// TokenEnv is the name of the environment variable containing the service account token. Defaults to GRAFANA_TOKEN.
+	TokenEnv string `json:"tokenEnv"`
+	// DashboardUID is optional. Without it, the annotation is an organization wide annotation.
+	DashboardUID string `json:"dashboardUID"`
+	// Tags of the annotation. The tag "check-conditions" is always set.
+	Tags []string `json:"tags"`
+}
+
+type grafanaAnnotator struct {
+	config *GrafanaConfig
+	header http.Header
+	id     int64
+	// log receives the warnings.
+	log io.Writer
+}
+
+func newGrafanaAnnotator(config *GrafanaConfig, log io.Writer) *grafanaAnnotator {
+	if config.TokenEnv == "" {
+		config.TokenEnv = "GRAFANA_TOKEN"
+	}
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+os.Getenv(config.TokenEnv))
+	return &grafanaAnnotator{config: config, header: header, log: log}
+}
+
+func (g *grafanaAnnotator) url() string {
+	return strings.TrimSuffix(g.config.URL, "/") + "/api/annotations"
+}
+
+// start creates the annotation.
+func (g *grafanaAnnotator) start(startTime time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	body := map[string]interface{}{
+		"time": startTime.UnixMilli(),
+		"tags": append([]string{"check-conditions"}, g.config.Tags...),
+		"text": "check-conditions: scan started",
+	}
+	if g.config.DashboardUID != "" {
+		body["dashboardUID"] = g.config.DashboardUID
+	}
+	var result struct {
+		ID int64 `json:"id"`
+	}
+	g.id = 0
+	if err := doJSON(ctx, http.MethodPost, g.url(), g.header, body, &result); err != nil {
+		fmt.Fprintf(g.log, "WARNING: creating Grafana annotation failed: %s\n", err.Error())
+		return
+	}
+	g.id = result.ID
+}
+
+// finish updates the annotation with the end time and the number of findings.
+func (g *grafanaAnnotator) finish(counter *Counter) {
+	if g.id == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	critical := 0
+	for i := range counter.findings {
+		if counter.findings[i].Severity == SeverityCritical {
+			critical++
+		}
+	}
+	body := map[string]interface{}{
+		"time":    counter.startTime.UnixMilli(),
+		"timeEnd": time.Now().UnixMilli(),
+		"text": fmt.Sprintf("check-conditions: %d findings (%d critical). Checked %d conditions of %d resources of %d types.",
+			len(counter.findings), critical, counter.checkedConditions, counter.checkedResources, counter.checkedResourceTypes),
+	}
+	if err := doJSON(ctx, http.MethodPatch, fmt.Sprintf("%s/%d", g.url(), g.id), g.header, body, nil); err != nil {
+		fmt.Fprintf(g.log, "WARNING: updating Grafana annotation failed: %s\n", err.Error())
+	}
+}
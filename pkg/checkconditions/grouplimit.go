@@ -0,0 +1,36 @@
+package checkconditions
+
+import "sync"
+
+// groupLimiter limits the number of concurrent LIST calls per API group. This way a slow
+// aggregated api-server does not get all workers at once.
+type groupLimiter struct {
+	limit      int
+	mutex      sync.Mutex
+	semaphores map[string]chan struct{}
+}
+
+// newGroupLimiter returns nil if limit is zero (no limit).
+func newGroupLimiter(limit int) *groupLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &groupLimiter{limit: limit, semaphores: make(map[string]chan struct{})}
+}
+
+// acquire blocks until a LIST call for the API group is allowed. The returned function must be called
+// after the call.
+func (l *groupLimiter) acquire(group string) func() {
+	if l == nil {
+		return func() {}
+	}
+	l.mutex.Lock()
+	semaphore, ok := l.semaphores[group]
+	if !ok {
+		semaphore = make(chan struct{}, l.limit)
+		l.semaphores[group] = semaphore
+	}
+	l.mutex.Unlock()
+	semaphore <- struct{}{}
+	return func() { <-semaphore }
+}
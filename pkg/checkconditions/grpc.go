@@ -0,0 +1,195 @@
+package checkconditions
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	checkconditionsv1 "github.com/guettli/check-conditions/api/checkconditions/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// grpcServer implements the CheckConditionsService defined in api/checkconditions/v1/checkconditions.proto.
+type grpcServer struct {
+	checkconditionsv1.UnimplementedCheckConditionsServiceServer
+	server *server
+}
+
+func (s *server) serveGRPC() {
+	listen, opts, err := s.args.grpcServerOptions()
+	if err != nil {
+		fmt.Fprintf(s.args.diagnostics(), "gRPC server: %s\n", err.Error())
+		os.Exit(1)
+	}
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		fmt.Fprintln(s.args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	grpcSrv := s.newGRPCServer(opts...)
+	fmt.Fprintf(s.args.diagnostics(), "gRPC listening on %s\n", listen)
+	if err := grpcSrv.Serve(lis); err != nil {
+		fmt.Fprintln(s.args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+}
+
+func (s *server) newGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	grpcSrv := grpc.NewServer(opts...)
+	checkconditionsv1.RegisterCheckConditionsServiceServer(grpcSrv, &grpcServer{server: s})
+	return grpcSrv
+}
+
+// grpcServerOptions returns the address and the options of the gRPC server. See serverAuth.
+func (args *Arguments) grpcServerOptions() (string, []grpc.ServerOption, error) {
+	listen, tlsConfig, token, err := args.grpcAuth().resolve()
+	if err != nil {
+		return "", nil, err
+	}
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if token != "" {
+		opts = append(opts, grpcTokenAuth(token)...)
+	}
+	return listen, opts, nil
+}
+
+// grpcTokenAuth returns interceptors, which reject calls without the metadata "authorization: Bearer TOKEN".
+func grpcTokenAuth(token string) []grpc.ServerOption {
+	want := []byte("Bearer " + token)
+	check := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, got := range md.Get("authorization") {
+			if subtle.ConstantTimeCompare([]byte(got), want) == 1 {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+	}
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler,
+		) (interface{}, error) {
+			if err := check(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo,
+			handler grpc.StreamHandler,
+		) error {
+			if err := check(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	}
+}
+
+func (g *grpcServer) Scan(ctx context.Context, req *checkconditionsv1.ScanRequest) (*checkconditionsv1.ScanResponse, error) {
+	if _, err := labels.Parse(req.LabelSelector); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid label_selector: %s", err.Error())
+	}
+	trigger := scanTrigger{name: "gRPC Scan"}
+	if p, ok := peer.FromContext(ctx); ok {
+		trigger.remoteAddr = p.Addr.String()
+	}
+	counter, err := g.server.scanNow(req.Namespace, req.LabelSelector, trigger)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &checkconditionsv1.ScanResponse{
+		Summary: newScanSummary(counter, time.Now(), len(counter.findings)),
+	}
+	for i := range counter.findings {
+		resp.Findings = append(resp.Findings, newFindingMessage(&counter.findings[i]))
+	}
+	return resp, nil
+}
+
+func (g *grpcServer) StreamFindings(req *checkconditionsv1.StreamFindingsRequest,
+	stream checkconditionsv1.CheckConditionsService_StreamFindingsServer,
+) error {
+	ch := g.server.subscribe()
+	defer g.server.unsubscribe(ch)
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case counter := <-ch:
+			sent := 0
+			for i := range counter.findings {
+				f := &counter.findings[i]
+				if req.Namespace != "" && f.Namespace != req.Namespace {
+					continue
+				}
+				if err := stream.Send(&checkconditionsv1.StreamFindingsResponse{
+					Item: &checkconditionsv1.StreamFindingsResponse_Finding{Finding: newFindingMessage(f)},
+				}); err != nil {
+					return err
+				}
+				sent++
+			}
+			if err := stream.Send(&checkconditionsv1.StreamFindingsResponse{
+				Item: &checkconditionsv1.StreamFindingsResponse_Summary{
+					Summary: newScanSummary(counter, counter.startTime, sent),
+				},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func newScanSummary(counter *Counter, scanTime time.Time, findings int) *checkconditionsv1.ScanSummary {
+	return &checkconditionsv1.ScanSummary{
+		ScanTime:             timestamppb.New(scanTime),
+		CheckedResourceTypes: counter.checkedResourceTypes,
+		CheckedResources:     counter.checkedResources,
+		CheckedConditions:    counter.checkedConditions,
+		Findings:             int32(findings),
+	}
+}
+
+func newFindingMessage(f *Finding) *checkconditionsv1.Finding {
+	msg := &checkconditionsv1.Finding{
+		Id:               f.ID(),
+		Namespace:        f.Namespace,
+		Group:            f.Group,
+		Version:          f.Version,
+		Resource:         f.Resource,
+		Kind:             f.Kind,
+		Name:             f.Name,
+		Uid:              string(f.UID),
+		ConditionType:    f.ConditionType,
+		ConditionStatus:  f.ConditionStatus,
+		ConditionReason:  f.ConditionReason,
+		ConditionMessage: f.ConditionMessage,
+		Severity:         f.Severity,
+		Code:             f.Code,
+		Check:            f.Check,
+	}
+	if !f.LastTransitionTime.IsZero() {
+		msg.LastTransitionTime = timestamppb.New(f.LastTransitionTime)
+	}
+	if f.FirstSeen != nil {
+		msg.FirstSeen = timestamppb.New(*f.FirstSeen)
+		msg.Occurrences = int32(f.Occurrences)
+	}
+	if f.LastSeen != nil {
+		msg.LastSeen = timestamppb.New(*f.LastSeen)
+	}
+	return msg
+}
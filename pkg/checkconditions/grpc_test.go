@@ -0,0 +1,139 @@
+package checkconditions
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	checkconditionsv1 "github.com/guettli/check-conditions/api/checkconditions/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// writeTestSnapshot writes an extracted snapshot with a pod whose condition Ready is False, for --from-dir.
+func writeTestSnapshot(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	meta := snapshotMeta{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "pods", Namespaced: true, Kind: "Pod", Verbs: metav1.Verbs{"get", "list"}}},
+	}}}
+	pods := `{"apiVersion": "v1", "kind": "PodList", "metadata": {}, "items": [{
+		"apiVersion": "v1", "kind": "Pod", "metadata": {"namespace": "a", "name": "p1", "uid": "u1"},
+		"status": {"conditions": [{"type": "Ready", "status": "False", "reason": "ContainersNotReady"}]}}]}`
+	data, err := json.Marshal(&meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, filepath.FromSlash(snapshotObjectsPath(schema.GroupVersionResource{Version: "v1", Resource: "pods"})))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string][]byte{filepath.Join(dir, snapshotMetaFile): data, path: []byte(pods)} {
+		if err := os.WriteFile(name, content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGRPCServer(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	args := Arguments{Config: &Config{}, FromDir: writeTestSnapshot(t), GRPCListen: "localhost:0", GRPCTokenFile: tokenFile}
+	config, err := newRestConfig(&args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{args: args, config: config, subscribers: make(map[chan *Counter]struct{})}
+	_, opts, err := s.args.grpcServerOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	grpcSrv := s.newGRPCServer(opts...)
+	go func() { _ = grpcSrv.Serve(lis) }()
+	t.Cleanup(grpcSrv.Stop)
+	conn, err := grpc.DialContext(t.Context(), "bufnet", //nolint:staticcheck
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := checkconditionsv1.NewCheckConditionsServiceClient(conn)
+	authorized := metadata.AppendToOutgoingContext(t.Context(), "authorization", "Bearer secret")
+
+	t.Run("Scan", func(t *testing.T) {
+		resp, err := client.Scan(authorized, &checkconditionsv1.ScanRequest{Namespace: "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Findings) != 1 || resp.Findings[0].Name != "p1" || resp.Findings[0].ConditionType != "Ready" ||
+			resp.Summary.Findings != 1 {
+			t.Errorf("unexpected response %v", resp)
+		}
+		if f := resp.Findings[0]; f.Code != CodeConditionFalse || f.Check != checkConditions {
+			t.Errorf("finding without code and check: %v", f)
+		}
+	})
+	t.Run("invalid label_selector", func(t *testing.T) {
+		_, err := client.Scan(authorized, &checkconditionsv1.ScanRequest{LabelSelector: "a in (b"})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("error %v, want InvalidArgument", err)
+		}
+	})
+	t.Run("without token", func(t *testing.T) {
+		for _, ctx := range []context.Context{t.Context(), metadata.AppendToOutgoingContext(t.Context(), "authorization", "Bearer wrong")} {
+			if _, err := client.Scan(ctx, &checkconditionsv1.ScanRequest{}); status.Code(err) != codes.Unauthenticated {
+				t.Errorf("Scan: error %v, want Unauthenticated", err)
+			}
+			stream, err := client.StreamFindings(ctx, &checkconditionsv1.StreamFindingsRequest{})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if status.Code(err) != codes.Unauthenticated {
+				t.Errorf("StreamFindings: error %v, want Unauthenticated", err)
+			}
+		}
+	})
+	t.Run("StreamFindings", func(t *testing.T) {
+		s.mutex.Lock()
+		s.last = &Counter{findings: []Finding{
+			{Namespace: "a", Resource: "pods", Name: "p1", ConditionType: "Ready"},
+			{Namespace: "b", Resource: "pods", Name: "p2", ConditionType: "Ready"},
+		}}
+		s.mutex.Unlock()
+		ctx, cancel := context.WithCancel(authorized)
+		defer cancel()
+		stream, err := client.StreamFindings(ctx, &checkconditionsv1.StreamFindingsRequest{Namespace: "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f := msg.GetFinding(); f == nil || f.Name != "p2" {
+			t.Fatalf("got %v, want the finding p2", msg)
+		}
+		msg, err = stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary := msg.GetSummary(); summary == nil || summary.Findings != 1 {
+			t.Fatalf("got %v, want a summary with one finding", msg)
+		}
+	})
+}
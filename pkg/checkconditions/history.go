@@ -0,0 +1,228 @@
+package checkconditions
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// The history file contains one JSON object (historyEvent) per line. It only gets appended to.
+// On start the events get replayed, to know which findings are open.
+const (
+	historyEventScan     = "scan"
+	historyEventFound    = "found"
+	historyEventResolved = "resolved"
+)
+
+// historyFileMode is the mode of the history file. The findings contain the messages of the
+// conditions, which should not be readable by other users.
+const historyFileMode = 0o600
+
+type historyEvent struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+
+	// Finding is set for "found" and "resolved".
+	Finding *Finding `json:"finding,omitempty"`
+
+	// FirstSeen is set for "resolved".
+	FirstSeen *time.Time `json:"firstSeen,omitempty"`
+
+	// Findings is the number of findings. Set for "scan".
+	Findings int `json:"findings,omitempty"`
+
+	// Score is the health score. Set for "scan".
+	Score *int `json:"score,omitempty"`
+
+	// Critical contains the namespaces ("namespace/NAME") and kinds ("kind/KIND") with
+	// critical findings. Set for "scan".
+	Critical []string `json:"critical,omitempty"`
+
+	// Partial is true, if the scan did not check all objects of the cluster (see
+	// Counter.partialReason). Partial scans do not count for the availability. Set for "scan".
+	Partial bool `json:"partial,omitempty"`
+}
+
+// openFinding is a finding which was found, but which is not resolved yet.
+type openFinding struct {
+	finding   Finding
+	firstSeen time.Time
+
+	// lastSeen is the time of the last scan, occurrences the number of scans since firstSeen.
+	lastSeen    time.Time
+	occurrences int
+}
+
+// resolvedFinding is a finding which was found in a previous scan, but not in the current scan.
+type resolvedFinding struct {
+	finding    Finding
+	firstSeen  time.Time
+	resolvedAt time.Time
+}
+
+// activeDuration returns how long the finding was active. If the condition had the same status
+// before the finding was seen first, the lastTransitionTime of the condition is used.
+func (r *resolvedFinding) activeDuration() time.Duration {
+	start := r.firstSeen
+	if !r.finding.LastTransitionTime.IsZero() && r.finding.LastTransitionTime.Before(start) {
+		start = r.finding.LastTransitionTime
+	}
+	return r.resolvedAt.Sub(start)
+}
+
+func (r *resolvedFinding) String() string {
+	f := &r.finding
+	return fmt.Sprintf("  %s %s %s Condition %s RESOLVED (was %s=%s %s, active for %s)", f.Namespace, f.Resource, f.Name,
+		f.ConditionType, f.ConditionType, f.ConditionStatus, f.ConditionReason, r.activeDuration().Round(time.Second))
+}
+
+type history struct {
+	path  string
+	open  map[string]*openFinding
+	scans []scanRecord
+
+	// oldest is the time of the first event in the file, compacted the time of the last
+	// compaction. See pruneHistory.
+	oldest    time.Time
+	compacted time.Time
+}
+
+// loadHistory reads the history file. A missing file is not an error.
+func loadHistory(path string) (*history, error) {
+	h := &history{path: path, open: make(map[string]*openFinding)}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) //nolint:gomnd
+	line := 0
+	for scanner.Scan() {
+		line++
+		var event historyEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		h.replay(&event)
+	}
+	return h, scanner.Err()
+}
+
+func (h *history) replay(event *historyEvent) {
+	if h.oldest.IsZero() {
+		h.oldest = event.Time
+	}
+	switch event.Event {
+	case historyEventFound:
+		h.open[event.Finding.ID()] = &openFinding{finding: *event.Finding, firstSeen: event.Time}
+	case historyEventResolved:
+		delete(h.open, event.Finding.ID())
+	case historyEventScan:
+		critical := make(map[string]bool, len(event.Critical))
+		for _, k := range event.Critical {
+			critical[k] = true
+		}
+		h.scans = append(h.scans, scanRecord{time: event.Time, critical: critical, partial: event.Partial})
+		// The resolved findings were removed before the scan event, so the open findings were found
+		// by this scan, or they were not resolvable (partial scan).
+		for _, o := range h.open {
+			o.lastSeen = event.Time
+			o.occurrences++
+		}
+		h.pruneScans(event.Time)
+	}
+}
+
+// update compares the findings of the current scan with the open findings, and appends
+// the new and the resolved findings and the scan to the history file. Open findings which are
+// missing in the current scan are only resolved, if resolvable returns true. See Counter.resolvable.
+// A partial scan gets recorded as such, so that it does not count for the availability.
+func (h *history) update(findings []Finding, score int, now time.Time, partial bool, resolvable func(f *Finding) bool) ([]resolvedFinding, error) {
+	var events []historyEvent
+	current := make(map[string]bool, len(findings))
+	for i := range findings {
+		f := findings[i]
+		id := f.ID()
+		current[id] = true
+		if _, ok := h.open[id]; ok {
+			continue
+		}
+		events = append(events, historyEvent{Event: historyEventFound, Time: now, Finding: &f})
+	}
+	var resolved []resolvedFinding
+	for id, o := range h.open {
+		if current[id] || !resolvable(&o.finding) {
+			continue
+		}
+		firstSeen := o.firstSeen
+		finding := o.finding
+		events = append(events, historyEvent{Event: historyEventResolved, Time: now, Finding: &finding, FirstSeen: &firstSeen})
+		resolved = append(resolved, resolvedFinding{finding: finding, firstSeen: firstSeen, resolvedAt: now})
+	}
+	events = append(events, historyEvent{
+		Event: historyEventScan, Time: now, Findings: len(findings), Score: &score,
+		Critical: criticalKeys(findings), Partial: partial,
+	})
+	if err := h.append(events); err != nil {
+		return nil, err
+	}
+	for i := range events {
+		h.replay(&events[i])
+	}
+	return resolved, nil
+}
+
+// addSeen sets Finding.FirstSeen, Finding.LastSeen and Finding.Occurrences of the open findings.
+func (h *history) addSeen(findings []Finding) {
+	for i := range findings {
+		f := &findings[i]
+		o, ok := h.open[f.ID()]
+		if !ok || o.occurrences == 0 {
+			continue
+		}
+		firstSeen, lastSeen := o.firstSeen, o.lastSeen
+		f.FirstSeen = &firstSeen
+		f.LastSeen = &lastSeen
+		f.Occurrences = o.occurrences
+	}
+}
+
+// projectSeen sets Finding.FirstSeen, Finding.LastSeen and Finding.Occurrences of a finding of
+// the running scan, like addSeen does after the history got updated. It is used for the findings
+// which -o ndjson streams during the scan.
+func (h *history) projectSeen(f *Finding, now time.Time) {
+	firstSeen, lastSeen, occurrences := now, now, 1
+	if o, ok := h.open[f.ID()]; ok {
+		firstSeen, occurrences = o.firstSeen, o.occurrences+1
+	}
+	f.FirstSeen = &firstSeen
+	f.LastSeen = &lastSeen
+	f.Occurrences = occurrences
+}
+
+func (h *history) append(events []historyEvent) error {
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, historyFileMode)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
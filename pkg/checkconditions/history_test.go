@@ -0,0 +1,44 @@
+package checkconditions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryFileMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if _, err := h.update(nil, 100, now.Add(-48*time.Hour), false, func(*Finding) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	checkMode := func(what string) {
+		t.Helper()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != historyFileMode {
+			t.Errorf("%s: mode %o, want %o", what, mode, historyFileMode)
+		}
+	}
+	checkMode("append")
+
+	// A history file of an older version is readable by other users. Compacting tightens it.
+	if err := os.Chmod(path, 0o644); err != nil { //nolint:gomnd
+		t.Fatal(err)
+	}
+	removed, err := h.compact(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("removed %d events, want 1", removed)
+	}
+	checkMode("compact")
+}
@@ -0,0 +1,201 @@
+package checkconditions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// HistoryCheckConfig configures a check of a history array, like status.history of a ClusterVersion.
+type HistoryCheckConfig struct {
+	Group string `json:"group"`
+	// Version is optional. Empty matches all versions.
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	// Path is a JSONPath to the history array, for example ".status.history".
+	Path string `json:"path"`
+	// StateField is the field of an entry which contains the state. Defaults to "state".
+	StateField string `json:"stateField"`
+	// SuccessStates are the states of successful entries. Defaults to ["Completed"].
+	SuccessStates []string `json:"successStates"`
+	// TimeField is the field of an entry which contains the time it finished. Defaults to "completionTime".
+	// Entries without time are still in progress. They are ignored.
+	TimeField string `json:"timeField"`
+	// NewestLast means the newest entry is the last one. By default the newest entry is the first one.
+	NewestLast bool `json:"newestLast"`
+	// LastFailed reports the object, if the newest N entries all failed. 0 disables this.
+	LastFailed int `json:"lastFailed"`
+	// MaxAgeSinceSuccess reports the object, if there was no successful entry within this duration. 0 disables this.
+	MaxAgeSinceSuccess metav1.Duration `json:"maxAgeSinceSuccess"`
+}
+
+// template returns the path as JSONPath template. The braces are optional in the config file.
+func (c *HistoryCheckConfig) template() string {
+	if strings.HasPrefix(c.Path, "{") {
+		return c.Path
+	}
+	return "{" + c.Path + "}"
+}
+
+func (c *HistoryCheckConfig) parse() error {
+	if c.Kind == "" || c.Path == "" {
+		return fmt.Errorf("needs kind and path")
+	}
+	if c.LastFailed <= 0 && c.MaxAgeSinceSuccess.Duration <= 0 {
+		return fmt.Errorf("needs lastFailed or maxAgeSinceSuccess")
+	}
+	if err := jsonpath.New(c.Kind).Parse(c.template()); err != nil {
+		return fmt.Errorf("invalid path %q: %w", c.Path, err)
+	}
+	if c.StateField == "" {
+		c.StateField = "state"
+	}
+	if len(c.SuccessStates) == 0 {
+		c.SuccessStates = []string{"Completed"}
+	}
+	if c.TimeField == "" {
+		c.TimeField = "completionTime"
+	}
+	return nil
+}
+
+// historyEntry is one finished entry of a history array.
+type historyEntry struct {
+	success bool
+	time    time.Time
+}
+
+// entries returns the finished entries of the history of the object, newest first.
+func (c *HistoryCheckConfig) entries(obj *unstructured.Unstructured) ([]historyEntry, error) {
+	// A JSONPath is not safe for concurrent use, so it gets parsed for each object.
+	j := jsonpath.New(c.Kind).AllowMissingKeys(true)
+	if err := j.Parse(c.template()); err != nil {
+		return nil, err
+	}
+	results, err := j.FindResults(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	var entries []historyEntry
+	for _, result := range results {
+		for _, v := range result {
+			list, ok := v.Interface().([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%T is not a list", v.Interface())
+			}
+			for _, item := range list {
+				m, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				t, err := time.Parse(time.RFC3339, stringField(m, c.TimeField))
+				if err != nil {
+					// In progress.
+					continue
+				}
+				entries = append(entries, historyEntry{
+					success: slices.Contains(c.SuccessStates, stringField(m, c.StateField)),
+					time:    t,
+				})
+			}
+		}
+	}
+	if c.NewestLast {
+		slices.Reverse(entries)
+	}
+	return entries, nil
+}
+
+// historyChecks checks the history arrays configured in Config.HistoryChecks.
+func historyChecks(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	_ *handleResourceTypeOutput,
+) []Finding {
+	if args.Config == nil {
+		return nil
+	}
+	var findings []Finding
+	for i := range args.Config.HistoryChecks {
+		c := &args.Config.HistoryChecks[i]
+		if c.Group != gvr.Group || c.Kind != obj.GetKind() || (c.Version != "" && c.Version != gvr.Version) {
+			continue
+		}
+		entries, err := c.entries(obj)
+		if err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: history path %q of %s %s: %s\n", c.Path, obj.GetKind(), obj.GetName(), err.Error())
+			continue
+		}
+		findings = append(findings, c.check(gvr, obj, entries, time.Now())...)
+	}
+	return findings
+}
+
+func (c *HistoryCheckConfig) check(gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	entries []historyEntry, now time.Time,
+) []Finding {
+	// The condition types differ, so that both findings of one object get different IDs.
+	newFinding := func(conditionType, code, reason, message, fingerprint string, lastTransition time.Time) Finding {
+		return Finding{
+			Namespace:          obj.GetNamespace(),
+			Group:              gvr.Group,
+			Version:            gvr.Version,
+			Resource:           gvr.Resource,
+			Kind:               obj.GetKind(),
+			Name:               obj.GetName(),
+			UID:                obj.GetUID(),
+			ConditionType:      conditionType,
+			ConditionStatus:    "Failed",
+			ConditionReason:    reason,
+			ConditionMessage:   message,
+			LastTransitionTime: lastTransition,
+			Severity:           SeverityWarning,
+			MessageFingerprint: fingerprint,
+			Code:               code,
+			Check:              checkHistory,
+		}
+	}
+	var findings []Finding
+	if c.LastFailed > 0 && len(entries) >= c.LastFailed {
+		failed := true
+		for _, e := range entries[:c.LastFailed] {
+			if e.success {
+				failed = false
+				break
+			}
+		}
+		if failed {
+			findings = append(findings, newFinding("HistoryLastFailed", CodeHistoryFailed, "LastEntriesFailed",
+				fmt.Sprintf("the last %d entries of %s failed", c.LastFailed, c.Path),
+				fmt.Sprintf("the last <n> entries of %s failed", c.Path),
+				entries[c.LastFailed-1].time))
+		}
+	}
+	maxAge := c.MaxAgeSinceSuccess.Duration
+	if maxAge > 0 && len(entries) > 0 && now.Sub(obj.GetCreationTimestamp().Time) > maxAge {
+		var lastSuccess time.Time
+		for _, e := range entries {
+			if e.success {
+				lastSuccess = e.time
+				break
+			}
+		}
+		switch {
+		case lastSuccess.IsZero():
+			findings = append(findings, newFinding("HistorySuccess", CodeHistoryNoSuccess, "NoSuccess",
+				fmt.Sprintf("no successful entry in %s", c.Path),
+				fmt.Sprintf("no successful entry in %s", c.Path),
+				obj.GetCreationTimestamp().Time))
+		case now.Sub(lastSuccess) > maxAge:
+			findings = append(findings, newFinding("HistorySuccess", CodeHistoryNoSuccess, "NoRecentSuccess",
+				fmt.Sprintf("the last successful entry of %s is %s old", c.Path, now.Sub(lastSuccess).Round(time.Minute)),
+				fmt.Sprintf("the last successful entry of %s is <duration> old", c.Path),
+				lastSuccess))
+		}
+	}
+	return findings
+}
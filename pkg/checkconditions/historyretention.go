@@ -0,0 +1,133 @@
+package checkconditions
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyPruneInterval is the minimum time between two automatic compactions of the history file.
+const historyPruneInterval = 24 * time.Hour
+
+// DayDuration is a time.Duration flag which additionally accepts days: "90d".
+type DayDuration time.Duration
+
+func (d *DayDuration) String() string {
+	return time.Duration(*d).String()
+}
+
+func (d *DayDuration) Set(s string) error {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		*d = DayDuration(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = DayDuration(v)
+	return nil
+}
+
+func (d *DayDuration) Type() string {
+	return "duration"
+}
+
+// pruneHistory compacts the history file, if it contains events older than the retention.
+// This happens at most once per historyPruneInterval, since the whole file gets rewritten.
+func (args *Arguments) pruneHistory(now time.Time) {
+	h := args.history
+	if h == nil || args.HistoryRetention <= 0 || h.oldest.IsZero() || now.Sub(h.oldest) < args.HistoryRetention {
+		return
+	}
+	if !h.compacted.IsZero() && now.Sub(h.compacted) < historyPruneInterval {
+		return
+	}
+	h.compacted = now
+	removed, err := h.compact(now.Add(-args.HistoryRetention))
+	if err != nil {
+		fmt.Fprintf(args.diagnostics(), "WARNING: compacting history file failed: %s\n", err.Error())
+		return
+	}
+	if args.Verbose {
+		fmt.Fprintf(args.diagnostics(), "Removed %d events older than %s from the history file\n", removed, args.HistoryRetention)
+	}
+}
+
+// compact rewrites the history file without the events before cutoff. The events which found
+// the open findings are kept, so that the first seen time of open findings does not change.
+// It returns the number of removed events.
+func (h *history) compact(cutoff time.Time) (int, error) {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	var oldest time.Time
+	removed := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) //nolint:gomnd
+	for scanner.Scan() {
+		var event historyEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return 0, err
+		}
+		if event.Time.Before(cutoff) && !h.findsOpenFinding(&event) {
+			removed++
+			continue
+		}
+		if oldest.IsZero() || event.Time.Before(oldest) {
+			oldest = event.Time
+		}
+		buf.Write(scanner.Bytes())
+		buf.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if removed == 0 {
+		h.oldest = oldest
+		return 0, nil
+	}
+	if err := writeFileAtomicMode(h.path, buf.Bytes(), historyFileMode); err != nil {
+		return 0, err
+	}
+	h.oldest = oldest
+	return removed, nil
+}
+
+// findsOpenFinding returns true if the event is the "found" event of a finding which is still open.
+func (h *history) findsOpenFinding(event *historyEvent) bool {
+	if event.Event != historyEventFound || event.Finding == nil {
+		return false
+	}
+	o, ok := h.open[event.Finding.ID()]
+	return ok && o.firstSeen.Equal(event.Time)
+}
+
+// RunHistoryCompact removes the events older than --history-retention from the history file.
+func RunHistoryCompact(args Arguments) {
+	if args.HistoryFile == "" || args.HistoryRetention <= 0 {
+		fmt.Fprintln(args.diagnostics(), "history compact needs --history-file and --history-retention")
+		os.Exit(1)
+	}
+	if err := args.loadHistory(); err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	removed, err := args.history.compact(time.Now().Add(-args.HistoryRetention))
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Removed %d events older than %s from %s\n", removed, args.HistoryRetention, args.HistoryFile)
+}
@@ -0,0 +1,119 @@
+package checkconditions
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// hpaInfo is a HorizontalPodAutoscaler and its scaleTargetRef.
+type hpaInfo struct {
+	gvr        schema.GroupVersionResource
+	namespace  string
+	name       string
+	uid        types.UID
+	targetKind schema.GroupKind
+	targetName string
+}
+
+// scalableKey returns "Kind.group namespace/name".
+func scalableKey(gk schema.GroupKind, namespace, name string) string {
+	return gk.String() + " " + namespace + "/" + name
+}
+
+// collectHPATargets records HorizontalPodAutoscalers, and the replicas of all scalable objects. See
+// Arguments.replicasPath.
+func collectHPATargets(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	if gvr.Group == "autoscaling" && gvr.Resource == "horizontalpodautoscalers" {
+		apiVersion, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "apiVersion")
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "name")
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil || kind == "" || name == "" {
+			return nil
+		}
+		output.hpas = append(output.hpas, hpaInfo{
+			gvr:        gvr,
+			namespace:  obj.GetNamespace(),
+			name:       obj.GetName(),
+			uid:        obj.GetUID(),
+			targetKind: schema.GroupKind{Group: gv.Group, Kind: kind},
+			targetName: name,
+		})
+		return nil
+	}
+	path, ok := args.replicasPath(gvr)
+	if !ok {
+		return nil
+	}
+	replicas, found, err := unstructured.NestedInt64(obj.Object, path...)
+	switch {
+	case err != nil:
+		return nil
+	case !found:
+		if args.customResource(gvr) {
+			// The scale subresource exists, but the field is not set.
+			replicas = unknownReplicas
+		} else {
+			return nil
+		}
+	}
+	if output.replicas == nil {
+		output.replicas = make(map[string]int64)
+	}
+	gk := schema.GroupKind{Group: gvr.Group, Kind: obj.GetKind()}
+	output.replicas[scalableKey(gk, obj.GetNamespace(), obj.GetName())] = replicas
+	return nil
+}
+
+// unknownReplicas is used for scalable custom resources whose replicas field is not set.
+const unknownReplicas = -1
+
+// hpaTargets reports HorizontalPodAutoscalers whose scaleTargetRef does not exist, or has zero
+// replicas. With zero replicas the autoscaling is disabled. Targets are only checked, if their
+// resource type was listed completely.
+func hpaTargets(args *Arguments, counter *Counter) []Finding {
+	if args.LabelSelector != "" {
+		return nil
+	}
+	var findings []Finding
+	for i := range counter.hpas {
+		h := &counter.hpas[i]
+		if !counter.listedKinds[h.targetKind] {
+			continue
+		}
+		target := h.targetKind.Kind + " " + h.targetName
+		replicas, ok := counter.replicas[scalableKey(h.targetKind, h.namespace, h.targetName)]
+		var reason, message string
+		switch {
+		case !ok:
+			reason, message = "TargetNotFound", fmt.Sprintf("scaleTargetRef %s does not exist", target)
+		case replicas == 0:
+			reason, message = "TargetScaledToZero", fmt.Sprintf("scaleTargetRef %s has zero replicas, autoscaling is disabled", target)
+		default:
+			continue
+		}
+		findings = append(findings, Finding{
+			Namespace:          h.namespace,
+			Group:              h.gvr.Group,
+			Version:            h.gvr.Version,
+			Resource:           h.gvr.Resource,
+			Kind:               "HorizontalPodAutoscaler",
+			Name:               h.name,
+			UID:                h.uid,
+			ConditionType:      "ScaleTarget",
+			ConditionStatus:    "False",
+			ConditionReason:    reason,
+			ConditionMessage:   message,
+			Severity:           SeverityWarning,
+			MessageFingerprint: reason,
+			Code:               CodeHPATarget,
+			Check:              checkHPATargets,
+		})
+	}
+	return findings
+}
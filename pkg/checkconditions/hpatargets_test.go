@@ -0,0 +1,79 @@
+package checkconditions
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestHPATargets(t *testing.T) {
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	hpas := schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+	deployment := func(name string, replicas int64) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind": "Deployment",
+			"spec": map[string]interface{}{"replicas": replicas},
+		}}
+		obj.SetNamespace("shop")
+		obj.SetName(name)
+		return obj
+	}
+	hpa := func(name, apiVersion, kind, target string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind": "HorizontalPodAutoscaler",
+			"spec": map[string]interface{}{"scaleTargetRef": map[string]interface{}{
+				"apiVersion": apiVersion, "kind": kind, "name": target,
+			}},
+		}}
+		obj.SetNamespace("shop")
+		obj.SetName(name)
+		return obj
+	}
+
+	args := &Arguments{}
+	output := &handleResourceTypeOutput{}
+	for _, o := range []struct {
+		gvr schema.GroupVersionResource
+		obj *unstructured.Unstructured
+	}{
+		{deployments, deployment("web", 3)},
+		{deployments, deployment("worker", 0)},
+		{hpas, hpa("web", "apps/v1", "Deployment", "web")},
+		{hpas, hpa("worker", "apps/v1", "Deployment", "worker")},
+		{hpas, hpa("missing", "apps/v1", "Deployment", "missing")},
+		{hpas, hpa("rollout", "argoproj.io/v1alpha1", "Rollout", "web")},
+		{hpas, hpa("invalid", "apps/v1", "Deployment", "")},
+	} {
+		collectHPATargets(args, o.gvr, o.obj, output)
+	}
+	if n := len(output.hpas); n != 4 {
+		t.Errorf("recorded %d HPAs, want 4", n)
+	}
+
+	counter := &Counter{
+		hpas:        output.hpas,
+		replicas:    output.replicas,
+		listedKinds: map[schema.GroupKind]bool{{Group: "apps", Kind: "Deployment"}: true},
+	}
+	got := make(map[string]string)
+	for _, f := range hpaTargets(args, counter) {
+		if f.Kind != "HorizontalPodAutoscaler" || f.Code != CodeHPATarget || f.Check != checkHPATargets {
+			t.Errorf("unexpected finding %+v", f)
+		}
+		got[f.Name] = f.ConditionReason + ": " + f.ConditionMessage
+	}
+	want := map[string]string{
+		"worker":  "TargetScaledToZero: scaleTargetRef Deployment worker has zero replicas, autoscaling is disabled",
+		"missing": "TargetNotFound: scaleTargetRef Deployment missing does not exist",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// With a label selector the targets were not listed completely.
+	if findings := hpaTargets(&Arguments{LabelSelector: "app=web"}, counter); len(findings) != 0 {
+		t.Errorf("findings with --selector: %+v", findings)
+	}
+}
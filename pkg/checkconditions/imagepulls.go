@@ -0,0 +1,191 @@
+package checkconditions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// imagePullReasons are the waiting reasons of containers whose image can not be pulled.
+var imagePullReasons = map[string]bool{
+	"ImagePullBackOff": true,
+	"ErrImagePull":     true,
+}
+
+// imagePullFailure is a container whose image can not be pulled.
+type imagePullFailure struct {
+	namespace string
+	pod       string
+	uid       types.UID
+	container string
+	image     string
+}
+
+// imagePullFailures returns the containers of the pod which wait for their image.
+func imagePullFailures(obj *unstructured.Unstructured) []imagePullFailure {
+	var result []imagePullFailure
+	for _, field := range []string{"initContainerStatuses", "containerStatuses", "ephemeralContainerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(obj.Object, "status", field)
+		for _, s := range statuses {
+			if !imagePullReasons[stringField(s, "state", "waiting", "reason")] {
+				continue
+			}
+			result = append(result, imagePullFailure{
+				namespace: obj.GetNamespace(),
+				pod:       obj.GetName(),
+				uid:       obj.GetUID(),
+				container: stringField(s, "name"),
+				image:     stringField(s, "image"),
+			})
+		}
+	}
+	return result
+}
+
+// collectImagePullFailures records containers which wait for their image.
+func collectImagePullFailures(_ *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	if gvr.Group != "" || gvr.Resource != "pods" {
+		return nil
+	}
+	output.imagePullFailures = append(output.imagePullFailures, imagePullFailures(obj)...)
+	return nil
+}
+
+// unreadyContainersRegex matches the message of the pod conditions Ready, ContainersReady and
+// Initialized: "containers with unready status: [app sidecar]".
+var unreadyContainersRegex = regexp.MustCompile(`^containers with (?:unready|incomplete) status: \[([^\]]*)\]$`)
+
+// withoutImagePullFindings removes the findings of the conditions of a pod, which are only caused
+// by failing image pulls: all containers of the message wait for their image. These pods are
+// reported by the registry finding of imagePullRollup. Other findings of the pod are kept.
+// This is done per object, before the findings get streamed.
+func withoutImagePullFindings(gvr schema.GroupVersionResource, obj *unstructured.Unstructured, findings []Finding) []Finding {
+	if gvr.Group != "" || gvr.Resource != "pods" || len(findings) == 0 {
+		return findings
+	}
+	failing := make(map[string]bool)
+	for _, p := range imagePullFailures(obj) {
+		failing[p.container] = true
+	}
+	if len(failing) == 0 {
+		return findings
+	}
+	result := findings[:0]
+	for _, f := range findings {
+		if f.Check == checkConditions && onlyImagePulls(f.ConditionMessage, failing) {
+			continue
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// onlyImagePulls returns true if all containers of the condition message are failing image pulls.
+func onlyImagePulls(message string, failing map[string]bool) bool {
+	match := unreadyContainersRegex.FindStringSubmatch(message)
+	if match == nil {
+		return false
+	}
+	containers := strings.Fields(match[1])
+	if len(containers) == 0 {
+		return false
+	}
+	for _, c := range containers {
+		if !failing[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// imageRegistry returns the registry host of the image reference. Images without
+// registry (like "nginx" or "library/nginx") are pulled from docker.io.
+func imageRegistry(image string) string {
+	i := strings.Index(image, "/")
+	if i < 0 {
+		return "docker.io"
+	}
+	host := image[:i]
+	if strings.ContainsAny(host, ".:") || host == "localhost" {
+		return host
+	}
+	return "docker.io"
+}
+
+// imagePullRollup reports one finding per registry with failing image pulls, so that an outage
+// of a registry is one incident instead of many pod findings. The findings of the conditions of
+// the affected pods were removed by withoutImagePullFindings.
+func imagePullRollup(_ *Arguments, counter *Counter) []Finding {
+	type registryFailures struct {
+		pods       map[types.UID]bool
+		namespaces map[string]bool
+		images     map[string]map[types.UID]bool
+	}
+	registries := make(map[string]*registryFailures)
+	for _, p := range counter.imagePullFailures {
+		host := imageRegistry(p.image)
+		r, ok := registries[host]
+		if !ok {
+			r = &registryFailures{
+				pods:       make(map[types.UID]bool),
+				namespaces: make(map[string]bool),
+				images:     make(map[string]map[types.UID]bool),
+			}
+			registries[host] = r
+		}
+		r.pods[p.uid] = true
+		r.namespaces[p.namespace] = true
+		if r.images[p.image] == nil {
+			r.images[p.image] = make(map[types.UID]bool)
+		}
+		r.images[p.image][p.uid] = true
+	}
+	if len(registries) == 0 {
+		return nil
+	}
+	hosts := make([]string, 0, len(registries))
+	for host := range registries {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	result := make([]Finding, 0, len(hosts))
+	for _, host := range hosts {
+		r := registries[host]
+		images := make([]string, 0, len(r.images))
+		for image := range r.images {
+			images = append(images, image)
+		}
+		sort.Slice(images, func(i, j int) bool {
+			if len(r.images[images[i]]) != len(r.images[images[j]]) {
+				return len(r.images[images[i]]) > len(r.images[images[j]])
+			}
+			return images[i] < images[j]
+		})
+		parts := make([]string, 0, len(images))
+		for _, image := range images {
+			parts = append(parts, fmt.Sprintf("%s (%d pods)", image, len(r.images[image])))
+		}
+		result = append(result, Finding{
+			Resource:        "registries",
+			Kind:            "Registry",
+			Name:            host,
+			ConditionType:   "ImagePull",
+			ConditionStatus: "False",
+			ConditionReason: "ImagePullFailing",
+			ConditionMessage: fmt.Sprintf("registry %s failing for %d pods across %d namespaces: %s",
+				host, len(r.pods), len(r.namespaces), strings.Join(parts, ", ")),
+			Severity:           SeverityWarning,
+			MessageFingerprint: fmt.Sprintf("registry %s failing for <n> pods across <n> namespaces", host),
+			Code:               CodeImagePullFailing,
+			Check:              checkImagePulls,
+		})
+	}
+	return result
+}
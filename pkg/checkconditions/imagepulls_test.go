@@ -0,0 +1,118 @@
+package checkconditions
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// testImagePullPod returns a pod of the namespace, whose containers have the images. Images with
+// the prefix "!" can not be pulled.
+func testImagePullPod(namespace, name string, images ...string) *unstructured.Unstructured {
+	statuses := make([]interface{}, 0, len(images))
+	for i, image := range images {
+		state := map[string]interface{}{"running": map[string]interface{}{}}
+		if image[0] == '!' {
+			image = image[1:]
+			state = map[string]interface{}{"waiting": map[string]interface{}{"reason": "ImagePullBackOff"}}
+		}
+		statuses = append(statuses, map[string]interface{}{
+			"name": fmt.Sprintf("c%d", i), "image": image, "state": state,
+		})
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":   "Pod",
+		"status": map[string]interface{}{"containerStatuses": statuses},
+	}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID(namespace + "/" + name))
+	return obj
+}
+
+func TestImageRegistry(t *testing.T) {
+	for image, want := range map[string]string{
+		"nginx":                        "docker.io",
+		"library/nginx:1.25":           "docker.io",
+		"ghcr.io/example/app:v1":       "ghcr.io",
+		"localhost/app":                "localhost",
+		"registry.local:5000/team/app": "registry.local:5000",
+	} {
+		if got := imageRegistry(image); got != want {
+			t.Errorf("imageRegistry(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestImagePullRollup(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	output := &handleResourceTypeOutput{}
+	for _, obj := range []*unstructured.Unstructured{
+		testImagePullPod("a", "web-1", "!ghcr.io/example/web:v2", "!nginx"),
+		testImagePullPod("a", "web-2", "!ghcr.io/example/web:v2"),
+		testImagePullPod("b", "api", "!ghcr.io/example/api:v1", "ghcr.io/example/sidecar:v1"),
+		testImagePullPod("b", "ok", "ghcr.io/example/api:v1"),
+	} {
+		collectImagePullFailures(&Arguments{}, pods, obj, output)
+	}
+	collectImagePullFailures(&Arguments{}, schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "pods"},
+		testImagePullPod("a", "other", "!quay.io/app"), output)
+
+	got := make(map[string]string)
+	for _, f := range imagePullRollup(&Arguments{}, &Counter{imagePullFailures: output.imagePullFailures}) {
+		if f.Kind != "Registry" || f.Code != CodeImagePullFailing || f.Check != checkImagePulls {
+			t.Errorf("unexpected finding %+v", f)
+		}
+		got[f.Name] = f.ConditionReason + ": " + f.ConditionMessage
+	}
+	want := map[string]string{
+		"ghcr.io": "ImagePullFailing: registry ghcr.io failing for 3 pods across 2 namespaces: " +
+			"ghcr.io/example/web:v2 (2 pods), ghcr.io/example/api:v1 (1 pods)",
+		"docker.io": "ImagePullFailing: registry docker.io failing for 1 pods across 1 namespaces: nginx (1 pods)",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestWithoutImagePullFindings(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	condition := func(conditionType, message string) Finding {
+		return Finding{Kind: "Pod", Name: "web-1", ConditionType: conditionType, ConditionMessage: message, Check: checkConditions}
+	}
+	findings := func() []Finding {
+		return []Finding{
+			condition("Ready", "containers with unready status: [c0 c1]"),
+			condition("ContainersReady", "containers with unready status: [c0]"),
+			condition("PodScheduled", "0/3 nodes are available"),
+			{Kind: "Pod", Name: "web-1", ConditionType: "Ready", ConditionMessage: "containers with unready status: [c0]", Check: checkDuplicateConditions},
+		}
+	}
+	conditionTypes := func(findings []Finding) []string {
+		result := make([]string, 0, len(findings))
+		for _, f := range findings {
+			result = append(result, f.ConditionType)
+		}
+		return result
+	}
+	for _, tc := range []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want []string
+	}{
+		// c1 is running, so Ready is not only caused by the image pull.
+		{"one container fails", testImagePullPod("a", "web-1", "!nginx", "redis"), []string{"Ready", "PodScheduled", "Ready"}},
+		{"all containers fail", testImagePullPod("a", "web-1", "!nginx", "!redis"), []string{"PodScheduled", "Ready"}},
+		{"no failing pull", testImagePullPod("a", "web-1", "nginx", "redis"), []string{"Ready", "ContainersReady", "PodScheduled", "Ready"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := conditionTypes(withoutImagePullFindings(pods, tc.obj, findings()))
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package checkconditions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// informerSyncTimeout is the maximum time to wait for the initial list of an informer. If the
+// cache does not sync in time (for example because watching is forbidden), the resource type gets
+// listed instead.
+const informerSyncTimeout = time.Minute
+
+// errInformerNotSynced is returned by informerCache.list if the resource type should be listed.
+var errInformerNotSynced = errors.New("informer cache did not sync")
+
+// informerCache is used in serve mode if informers are enabled. Instead of listing all
+// resource objects for each scan, the resource objects get watched and the scans read from the cache.
+// This needs more memory, but it reduces the load of the api-server.
+type informerCache struct {
+	dynClient dynamic.Interface
+
+	mutex     sync.Mutex
+	informers map[schema.GroupVersionResource]*watchedResource
+
+	// changed receives a value if a resource object was added, updated or deleted.
+	changed chan struct{}
+}
+
+// watchedResource is the informer of one resource type.
+type watchedResource struct {
+	informer informers.GenericInformer
+	stop     chan struct{}
+	synced   bool
+
+	// failed is true if the cache did not sync. The resource type gets listed for each scan.
+	failed bool
+}
+
+func newInformerCache(dynClient dynamic.Interface) *informerCache {
+	return &informerCache{
+		dynClient: dynClient,
+		informers: make(map[schema.GroupVersionResource]*watchedResource),
+		changed:   make(chan struct{}, 1),
+	}
+}
+
+func (c *informerCache) notifyChanged() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// usable returns false if the informer of the resource type did not sync before.
+func (c *informerCache) usable(gvr schema.GroupVersionResource) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	w := c.informers[gvr]
+	return w == nil || !w.failed
+}
+
+// start returns the informer of the resource type. It gets started on the first call.
+func (c *informerCache) start(gvr schema.GroupVersionResource) (*watchedResource, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if w, ok := c.informers[gvr]; ok {
+		return w, nil
+	}
+	w := &watchedResource{
+		informer: dynamicinformer.NewFilteredDynamicInformer(c.dynClient, gvr, "", 0,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil),
+		stop: make(chan struct{}),
+	}
+	_, err := w.informer.Informer().AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if !isInInitialList {
+				c.notifyChanged()
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) { c.notifyChanged() },
+		DeleteFunc: func(obj interface{}) { c.notifyChanged() },
+	})
+	if err != nil {
+		return nil, err
+	}
+	go w.informer.Informer().Run(w.stop)
+	c.informers[gvr] = w
+	return w, nil
+}
+
+// waitForSync waits until the cache of the informer is synced. If the cache does not sync within
+// informerSyncTimeout, the informer gets stopped and errInformerNotSynced gets returned.
+func (c *informerCache) waitForSync(ctx context.Context, gvr schema.GroupVersionResource, w *watchedResource) error {
+	c.mutex.Lock()
+	synced := w.synced
+	c.mutex.Unlock()
+	if synced {
+		return nil
+	}
+	syncCtx, cancel := context.WithTimeout(ctx, informerSyncTimeout)
+	defer cancel()
+	if cache.WaitForCacheSync(syncCtx.Done(), w.informer.Informer().HasSynced) {
+		c.mutex.Lock()
+		w.synced = true
+		c.mutex.Unlock()
+		return nil
+	}
+	if ctx.Err() != nil {
+		// The scan (or --per-type-timeout) is over. Try again in the next scan.
+		return fmt.Errorf("cache of %s did not sync: %w", gvr.String(), ctx.Err())
+	}
+	c.mutex.Lock()
+	if !w.failed {
+		w.failed = true
+		close(w.stop)
+	}
+	c.mutex.Unlock()
+	return fmt.Errorf("%w: %s", errInformerNotSynced, gvr.String())
+}
+
+// list returns the resource objects of the namespaces from the cache. An empty namespace means
+// all namespaces. The informer of the resource type gets started on the first call.
+func (c *informerCache) list(ctx context.Context, gvr schema.GroupVersionResource, namespaces []string,
+	labelSelector string,
+) (*unstructured.UnstructuredList, error) {
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	w, err := c.start(gvr)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.waitForSync(ctx, gvr, w); err != nil {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{}
+	for _, namespace := range namespaces {
+		var objects []runtime.Object
+		if namespace == "" {
+			objects, err = w.informer.Lister().List(selector)
+		} else {
+			objects, err = w.informer.Lister().ByNamespace(namespace).List(selector)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			list.Items = append(list.Items, *u)
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,169 @@
+package checkconditions
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// inventoryKey is a distinct pair of kind and condition type.
+type inventoryKey struct {
+	Group         string
+	Resource      string
+	Kind          string
+	ConditionType string
+}
+
+// inventoryCounts counts the statuses of the conditions of one inventoryKey.
+type inventoryCounts struct {
+	True, False, Unknown int
+}
+
+type inventory map[inventoryKey]*inventoryCounts
+
+// addConditions counts the conditions of one object.
+func (inv inventory) addConditions(gvr schema.GroupVersionResource, kind string, conditions []interface{}) {
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditionType, _ := conditionMap["type"].(string)
+		conditionStatus, _ := conditionMap["status"].(string)
+		key := inventoryKey{Group: gvr.Group, Resource: gvr.Resource, Kind: kind, ConditionType: conditionType}
+		counts, ok := inv[key]
+		if !ok {
+			counts = &inventoryCounts{}
+			inv[key] = counts
+		}
+		switch conditionStatus {
+		case "True":
+			counts.True++
+		case "False":
+			counts.False++
+		default:
+			counts.Unknown++
+		}
+	}
+}
+
+func (inv inventory) merge(other inventory) {
+	for key, counts := range other {
+		c, ok := inv[key]
+		if !ok {
+			c = &inventoryCounts{}
+			inv[key] = c
+		}
+		c.True += counts.True
+		c.False += counts.False
+		c.Unknown += counts.Unknown
+	}
+}
+
+func (inv inventory) sortedKeys() []inventoryKey {
+	keys := make([]inventoryKey, 0, len(inv))
+	for key := range inv {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ConditionType < b.ConditionType
+	})
+	return keys
+}
+
+// RunInventory scans the cluster and prints each distinct pair of kind and condition type, with the
+// number of conditions which are True, False or Unknown. The conditions do not get judged.
+func RunInventory(args Arguments) {
+	if args.Config == nil {
+		args.Config = &Config{}
+	}
+	config, err := newRestConfig(&args)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	args.inventory = true
+	// Other checks are not needed.
+	args.enabledChecks = map[string]bool{checkConditions: true}
+	counter, err := scan(config, &args)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	printSkipped(args.diagnostics(), counter.skipped)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0) //nolint:gomnd
+	fmt.Fprintln(w, "GROUP\tKIND\tCONDITION\tTRUE\tFALSE\tUNKNOWN")
+	for _, key := range counter.inventory.sortedKeys() {
+		c := counter.inventory[key]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\n", key.Group, key.Kind, key.ConditionType, c.True, c.False, c.Unknown)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	if args.WriteConfig != "" {
+		n, err := writeSuggestedConfig(args.WriteConfig, counter.inventory, args.conditionRules())
+		if err != nil {
+			fmt.Fprintln(args.diagnostics(), err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %d suggested rules for unknown condition types to %s\n", n, args.WriteConfig)
+	}
+}
+
+// guessMeaning guesses the meaning of an unknown condition type: by its suffix, or by the status
+// most conditions have. Usually most conditions are fine.
+func guessMeaning(rules *conditionRuleSet, resource, conditionType string, counts *inventoryCounts) (meaning, reason string) {
+	switch {
+	case conditionTypeHasPositiveMeaning(rules, resource, conditionType):
+		return MeaningPositive, "guessed by suffix"
+	case conditionTypeHasNegativeMeaning(rules, resource, conditionType):
+		return MeaningNegative, "guessed by suffix"
+	case counts.True >= counts.False:
+		return MeaningPositive, "guessed, because most conditions are True"
+	default:
+		return MeaningNegative, "guessed, because most conditions are False"
+	}
+}
+
+// writeSuggestedConfig writes conditionRules for all unknown condition types of the inventory.
+// It returns the number of rules.
+func writeSuggestedConfig(path string, inv inventory, rules *conditionRuleSet) (int, error) {
+	var b strings.Builder
+	b.WriteString("# Suggested rules for unknown condition types, written by \"check-conditions conditions inventory\".\n")
+	b.WriteString("# Check each rule, then copy them to your config file (--config).\n")
+	b.WriteString("# meaning: positive (True is fine), negative (False is fine) or ignore (both are fine).\n")
+	suggested := struct {
+		ConditionRules []ConditionRule `json:"conditionRules"`
+	}{ConditionRules: []ConditionRule{}}
+	for _, key := range inv.sortedKeys() {
+		if conditionTypeKnown(rules, key.Resource, key.ConditionType) {
+			continue
+		}
+		counts := inv[key]
+		meaning, reason := guessMeaning(rules, key.Resource, key.ConditionType, counts)
+		fmt.Fprintf(&b, "# TODO %s %s: %s. %s (group %q) seen: True=%d False=%d Unknown=%d\n", key.Resource,
+			key.ConditionType, reason, key.Kind, key.Group, counts.True, counts.False, counts.Unknown)
+		suggested.ConditionRules = append(suggested.ConditionRules,
+			ConditionRule{Resource: key.Resource, Type: key.ConditionType, Meaning: meaning})
+	}
+	data, err := yaml.Marshal(suggested)
+	if err != nil {
+		return 0, err
+	}
+	b.Write(data)
+	n := len(suggested.ConditionRules)
+	return n, writeFileAtomic(path, []byte(b.String()))
+}
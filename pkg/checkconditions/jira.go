@@ -0,0 +1,199 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// JiraConfig configures the creation of Jira issues for findings which persist for a long time.
+type JiraConfig struct {
+	URL     string `json:"url"`
+	Project string `json:"project"`
+	// IssueType defaults to "Bug".
+	IssueType string `json:"issueType"`
+	User      string `json:"user"`
+	// TokenEnv is the name of the environment variable containing the API token. Defaults to JIRA_API_TOKEN.
+	TokenEnv string `json:"tokenEnv"`
+	// After is the duration a condition needs to be in its state before an issue gets created.
+	After metav1.Duration `json:"after"`
+	// CloseTransition is the name of the transition used to close resolved issues. Defaults to "Done".
+	CloseTransition string `json:"closeTransition"`
+}
+
+// jiraLabel is set on all issues created by check-conditions.
+const jiraLabel = "check-conditions"
+
+type jiraSink struct {
+	config *JiraConfig
+	header http.Header
+}
+
+func newJiraSink(config *JiraConfig) *jiraSink {
+	if config.IssueType == "" {
+		config.IssueType = "Bug"
+	}
+	if config.TokenEnv == "" {
+		config.TokenEnv = "JIRA_API_TOKEN"
+	}
+	if config.CloseTransition == "" {
+		config.CloseTransition = "Done"
+	}
+	req := http.Request{Header: make(http.Header)}
+	req.SetBasicAuth(config.User, os.Getenv(config.TokenEnv))
+	return &jiraSink{config: config, header: req.Header}
+}
+
+func (s *jiraSink) name() string {
+	return "Jira"
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Labels []string `json:"labels"`
+	} `json:"fields"`
+}
+
+// send creates an issue for each finding which persists longer than JiraConfig.After,
+// and closes the issues of findings which are resolved. The key of the finding (see sinkKey) is
+// stored as label, so that no duplicate issues get created. A finding whose issue could not be
+// created gets logged, and is sent again after the next scan.
+func (s *jiraSink) send(ctx context.Context, args *Arguments, counter *Counter) error {
+	open, err := s.openIssues(ctx)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for i := range counter.findings {
+		f := &counter.findings[i]
+		label := jiraLabel + "-" + args.sinkKey(f)
+		if f.Maintenance != "" {
+			continue
+		}
+		if since := openSince(f); s.config.After.Duration > 0 && (since.IsZero() || time.Since(since) < s.config.After.Duration) {
+			continue
+		}
+		if !args.sinkDue(s.name(), f) {
+			continue
+		}
+		if _, ok := open[label]; !ok {
+			if err := s.createIssue(ctx, args, counter, f, label); err != nil {
+				fmt.Fprintf(args.diagnostics(), "WARNING: creating a Jira issue for %s failed: %s\n", f.ID(), err.Error())
+				failed++
+				continue
+			}
+		}
+		args.sinkSent(s.name(), f)
+	}
+	if !closeResolved(args, counter, s.name()) {
+		return sendFailed(failed, "Jira issues")
+	}
+	current := currentKeys(args, counter, jiraLabel+"-")
+	for label, key := range open {
+		if current[label] || !args.ownKey(jiraLabel+"-", label) {
+			continue
+		}
+		if err := s.closeIssue(ctx, args.diagnostics(), key); err != nil {
+			return err
+		}
+	}
+	return sendFailed(failed, "Jira issues")
+}
+
+// openSince returns the time since when the finding is open: the first scan which found it
+// (--history-file), or the lastTransitionTime of the condition without history.
+func openSince(f *Finding) time.Time {
+	if f.FirstSeen != nil {
+		return *f.FirstSeen
+	}
+	return f.LastTransitionTime
+}
+
+// openIssues returns the keys of the open issues created by check-conditions, indexed by the label of the finding.
+func (s *jiraSink) openIssues(ctx context.Context) (map[string]string, error) {
+	jql := fmt.Sprintf("labels = %q AND statusCategory != Done", jiraLabel)
+	issues := make(map[string]string)
+	for startAt := 0; ; {
+		var result struct {
+			Issues []jiraIssue `json:"issues"`
+			Total  int         `json:"total"`
+		}
+		u := fmt.Sprintf("%s/rest/api/2/search?jql=%s&fields=labels&startAt=%d", strings.TrimSuffix(s.config.URL, "/"),
+			url.QueryEscape(jql), startAt)
+		if err := doJSON(ctx, http.MethodGet, u, s.header, nil, &result); err != nil {
+			return nil, err
+		}
+		for _, issue := range result.Issues {
+			for _, label := range issue.Fields.Labels {
+				if strings.HasPrefix(label, jiraLabel+"-") {
+					issues[label] = issue.Key
+				}
+			}
+		}
+		startAt += len(result.Issues)
+		if len(result.Issues) == 0 || startAt >= result.Total {
+			return issues, nil
+		}
+	}
+}
+
+func (s *jiraSink) createIssue(ctx context.Context, args *Arguments, counter *Counter, f *Finding, label string) error {
+	project := s.config.Project
+	if team := teamConfigOf(args.Config, counter.namespaces, f.Namespace); team != nil && team.JiraProject != "" {
+		project = team.JiraProject
+	}
+	body := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":   map[string]string{"key": project},
+			"issuetype": map[string]string{"name": s.config.IssueType},
+			"summary": strings.TrimSpace(fmt.Sprintf("%s %s %s: %s=%s %s", f.Namespace, f.Resource, f.Name,
+				f.ConditionType, f.ConditionStatus, f.ConditionReason)),
+			"description": fmt.Sprintf("check-conditions found this condition:\n\n{noformat}\n%s\n{noformat}\n\nFinding ID: %s",
+				strings.TrimSpace(f.String()), f.ID()),
+			"labels": []string{jiraLabel, label},
+		},
+	}
+	var created jiraIssue
+	if err := doJSON(ctx, http.MethodPost, strings.TrimSuffix(s.config.URL, "/")+"/rest/api/2/issue", s.header, body, &created); err != nil {
+		return err
+	}
+	fmt.Fprintf(args.diagnostics(), "Created Jira issue %s for %s\n", created.Key, f.ID())
+	return nil
+}
+
+func (s *jiraSink) closeIssue(ctx context.Context, log io.Writer, key string) error {
+	base := strings.TrimSuffix(s.config.URL, "/") + "/rest/api/2/issue/" + key
+	comment := map[string]string{"body": "check-conditions: the condition is resolved."}
+	if err := doJSON(ctx, http.MethodPost, base+"/comment", s.header, comment, nil); err != nil {
+		return err
+	}
+	var transitions struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	if err := doJSON(ctx, http.MethodGet, base+"/transitions", s.header, nil, &transitions); err != nil {
+		return err
+	}
+	for _, t := range transitions.Transitions {
+		if !strings.EqualFold(t.Name, s.config.CloseTransition) {
+			continue
+		}
+		body := map[string]interface{}{"transition": map[string]string{"id": t.ID}}
+		if err := doJSON(ctx, http.MethodPost, base+"/transitions", s.header, body, nil); err != nil {
+			return err
+		}
+		fmt.Fprintf(log, "Closed Jira issue %s\n", key)
+		return nil
+	}
+	return fmt.Errorf("issue %s has no transition %q", key, s.config.CloseTransition)
+}
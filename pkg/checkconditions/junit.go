@@ -0,0 +1,153 @@
+package checkconditions
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSuiteName returns the name of the test suite of a resource type: "deployments.apps/v1" or "pods/v1".
+func junitSuiteName(group, version, resource string) string {
+	if group == "" {
+		return resource + "/" + version
+	}
+	return resource + "." + group + "/" + version
+}
+
+// writeJUnit writes the report as JUnit XML. Each resource type is a test suite, each finding a
+// failed test case. Skipped resource types and scan errors are test cases, too, so that CI
+// systems show them. Each resource type which was listed completely has a passing test case
+// "list", so that the checked types are visible even without findings.
+func writeJUnit(w io.Writer, r *report) error {
+	suites := make(map[string]*junitTestSuite)
+	suite := func(group, version, resource string) *junitTestSuite {
+		name := junitSuiteName(group, version, resource)
+		s, ok := suites[name]
+		if !ok {
+			s = &junitTestSuite{Name: name, Timestamp: r.ScanTime.Format(time.RFC3339)}
+			suites[name] = s
+		}
+		return s
+	}
+	for i := range r.Findings {
+		f := &r.Findings[i]
+		s := suite(f.Group, f.Version, f.Resource)
+		name := f.Name
+		if f.Namespace != "" {
+			name = f.Namespace + "/" + f.Name
+		}
+		s.Tests++
+		s.Failures++
+		s.Cases = append(s.Cases, junitTestCase{
+			Name:      name + " " + f.ConditionType,
+			ClassName: strings.TrimSuffix(f.Resource+"."+f.Group, "."),
+			Failure: &junitMessage{
+				Message: strings.TrimSpace(fmt.Sprintf("%s=%s %s %s", f.ConditionType, f.ConditionStatus,
+					f.ConditionReason, f.ConditionMessage)),
+				Type: f.Severity,
+				Text: strings.TrimSpace(f.String()),
+			},
+		})
+	}
+	for _, skipped := range r.Skipped {
+		s := suite(skipped.Group, skipped.Version, skipped.Resource)
+		s.Tests++
+		s.Skipped++
+		s.Cases = append(s.Cases, junitTestCase{
+			Name:      "list",
+			ClassName: strings.TrimSuffix(skipped.Resource+"."+skipped.Group, "."),
+			Skipped:   &junitMessage{Message: skipped.Reason},
+		})
+	}
+	for _, e := range r.Errors {
+		s := suite(e.Group, e.Version, e.Resource)
+		s.Tests++
+		s.Errors++
+		s.Cases = append(s.Cases, junitTestCase{
+			Name:      e.Type,
+			ClassName: strings.TrimSuffix(e.Resource+"."+e.Group, "."),
+			Error:     &junitMessage{Message: e.Message, Type: e.Type},
+		})
+	}
+	if r.counter != nil {
+		for _, span := range r.counter.spans {
+			if span.err != "" {
+				continue
+			}
+			s := suite(span.gvr.Group, span.gvr.Version, span.gvr.Resource)
+			if slices.ContainsFunc(s.Cases, func(c junitTestCase) bool { return c.Name == "list" }) {
+				continue
+			}
+			s.Tests++
+			s.Cases = append(s.Cases, junitTestCase{
+				Name:      "list",
+				ClassName: strings.TrimSuffix(span.gvr.Resource+"."+span.gvr.Group, "."),
+			})
+		}
+	}
+	duration, _ := time.ParseDuration(r.Duration)
+	root := junitTestSuites{
+		Name: "check-conditions",
+		Time: duration.Seconds(),
+	}
+	for _, s := range suites {
+		sort.SliceStable(s.Cases, func(i, j int) bool { return s.Cases[i].Name < s.Cases[j].Name })
+		root.Suites = append(root.Suites, *s)
+		root.Tests += s.Tests
+		root.Failures += s.Failures
+		root.Errors += s.Errors
+		root.Skipped += s.Skipped
+	}
+	sort.Slice(root.Suites, func(i, j int) bool { return root.Suites[i].Name < root.Suites[j].Name })
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
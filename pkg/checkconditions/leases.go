@@ -0,0 +1,51 @@
+package checkconditions
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// staleLease reports Leases (leader election of controllers, heartbeats of nodes) whose renewTime
+// is older than Arguments.StaleLeaseAge. Usually the holder died silently. Released leases
+// (without holderIdentity) are fine.
+func staleLease(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	_ *handleResourceTypeOutput,
+) []Finding {
+	if gvr.Group != "coordination.k8s.io" || gvr.Resource != "leases" {
+		return nil
+	}
+	holder, _, _ := unstructured.NestedString(obj.Object, "spec", "holderIdentity")
+	s, _, _ := unstructured.NestedString(obj.Object, "spec", "renewTime")
+	if holder == "" || s == "" {
+		return nil
+	}
+	renewTime, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || time.Since(renewTime) < args.StaleLeaseAge {
+		return nil
+	}
+	what := "controller"
+	if obj.GetNamespace() == "kube-node-lease" {
+		what = "node"
+	}
+	return []Finding{{
+		Namespace:          obj.GetNamespace(),
+		Group:              gvr.Group,
+		Version:            gvr.Version,
+		Resource:           gvr.Resource,
+		Kind:               obj.GetKind(),
+		Name:               obj.GetName(),
+		UID:                obj.GetUID(),
+		ConditionType:      "Renewed",
+		ConditionStatus:    "False",
+		ConditionReason:    "LeaseStale",
+		ConditionMessage:   fmt.Sprintf("lease held by %q was not renewed since %s. Is the %s still alive?", holder, renewTime.Format(time.RFC3339), what),
+		LastTransitionTime: renewTime,
+		Severity:           SeverityWarning,
+		MessageFingerprint: fmt.Sprintf("lease held by <holder> was not renewed since <time>. Is the %s still alive?", what),
+		Code:               CodeLeaseStale,
+		Check:              checkStaleLeases,
+	}}
+}
@@ -0,0 +1,56 @@
+package checkconditions
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestStaleLease(t *testing.T) {
+	leases := schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}
+	args := &Arguments{StaleLeaseAge: 10 * time.Minute}
+	lease := func(namespace, holder string, renewed time.Duration) *unstructured.Unstructured {
+		spec := map[string]interface{}{}
+		if holder != "" {
+			spec["holderIdentity"] = holder
+		}
+		if renewed != 0 {
+			spec["renewTime"] = time.Now().Add(-renewed).Format(time.RFC3339Nano)
+		}
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Lease", "spec": spec}}
+		obj.SetNamespace(namespace)
+		obj.SetName("l1")
+		return obj
+	}
+	tests := []struct {
+		name        string
+		gvr         schema.GroupVersionResource
+		obj         *unstructured.Unstructured
+		wantMessage string
+	}{
+		{"stale controller lease", leases, lease("kube-system", "controller-1", time.Hour),
+			"lease held by <holder> was not renewed since <time>. Is the controller still alive?"},
+		{"stale node lease", leases, lease("kube-node-lease", "node-1", time.Hour),
+			"lease held by <holder> was not renewed since <time>. Is the node still alive?"},
+		{"renewed lease", leases, lease("kube-system", "controller-1", time.Minute), ""},
+		{"released lease", leases, lease("kube-system", "", time.Hour), ""},
+		{"never renewed", leases, lease("kube-system", "controller-1", 0), ""},
+		{"other resource", schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, lease("kube-system", "controller-1", time.Hour), ""},
+	}
+	for _, tt := range tests {
+		findings := staleLease(args, tt.gvr, tt.obj, nil)
+		switch {
+		case tt.wantMessage == "" && len(findings) != 0:
+			t.Errorf("%s: unexpected findings %v", tt.name, findings)
+		case tt.wantMessage != "" && len(findings) != 1:
+			t.Errorf("%s: got %d findings, want 1", tt.name, len(findings))
+		case tt.wantMessage != "":
+			f := findings[0]
+			if f.MessageFingerprint != tt.wantMessage || f.ConditionReason != "LeaseStale" || f.Code != CodeLeaseStale || f.Check != checkStaleLeases {
+				t.Errorf("%s: unexpected finding %+v", tt.name, f)
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package checkconditions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// MaintenanceWindowConfig is a recurring time window for planned work. Findings in a maintenance
+// window get reported and recorded, but they do not create notifications (Jira, GitHub, Opsgenie).
+type MaintenanceWindowConfig struct {
+	Name string `json:"name"`
+
+	// Schedule is the start of the window in cron syntax (minute hour day-of-month month day-of-week),
+	// in the local time zone. Example: "0 2 * * 6" (Saturday 02:00).
+	Schedule string `json:"schedule"`
+
+	// Duration is the length of the window.
+	Duration metav1.Duration `json:"duration"`
+
+	// Namespaces limits the window to these namespaces. Empty means all namespaces, including
+	// cluster-scoped resources.
+	Namespaces []string `json:"namespaces"`
+
+	// NamespaceSelector limits the window to namespaces with matching labels. Example: "env=staging".
+	NamespaceSelector string `json:"namespaceSelector"`
+
+	schedule *cronSchedule
+	selector labels.Selector
+}
+
+// parse validates the window and parses the schedule and the selector.
+func (w *MaintenanceWindowConfig) parse() error {
+	if w.Name == "" {
+		return fmt.Errorf("name is missing")
+	}
+	if w.Duration.Duration <= 0 {
+		return fmt.Errorf("maintenance window %q: duration is missing", w.Name)
+	}
+	schedule, err := parseCronSchedule(w.Schedule)
+	if err != nil {
+		return fmt.Errorf("maintenance window %q: %w", w.Name, err)
+	}
+	w.schedule = schedule
+	if w.NamespaceSelector != "" {
+		selector, err := labels.Parse(w.NamespaceSelector)
+		if err != nil {
+			return fmt.Errorf("maintenance window %q: invalid namespaceSelector: %w", w.Name, err)
+		}
+		w.selector = selector
+	}
+	return nil
+}
+
+// active returns true if a window started less than Duration before now.
+func (w *MaintenanceWindowConfig) active(now time.Time) bool {
+	now = now.Truncate(time.Minute)
+	for t := now; now.Sub(t) < w.Duration.Duration; t = t.Add(-time.Minute) {
+		if w.schedule.matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers returns true if the window applies to the namespace.
+func (w *MaintenanceWindowConfig) covers(namespace string, namespaces map[string]namespaceMeta) bool {
+	if len(w.Namespaces) > 0 && !slices.Contains(w.Namespaces, namespace) {
+		return false
+	}
+	if w.selector != nil {
+		if namespace == "" {
+			return false
+		}
+		return w.selector.Matches(labels.Set(namespaces[namespace].labels))
+	}
+	return true
+}
+
+// markMaintenance sets Finding.Maintenance for all findings in an active maintenance window.
+func markMaintenance(config *Config, counter *Counter, now time.Time) {
+	var active []*MaintenanceWindowConfig
+	for i := range config.MaintenanceWindows {
+		if config.MaintenanceWindows[i].active(now) {
+			active = append(active, &config.MaintenanceWindows[i])
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+	for i := range counter.findings {
+		f := &counter.findings[i]
+		for _, w := range active {
+			if w.covers(f.Namespace, counter.namespaces) {
+				f.Maintenance = w.Name
+				break
+			}
+		}
+	}
+}
+
+// cronSchedule contains the allowed values of the five fields of a cron expression.
+type cronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek map[int]bool
+
+	// dayOfMonthAny and dayOfWeekAny are true if the field is "*". Like in cron, a time matches if
+	// one of both fields matches, if both are restricted.
+	dayOfMonthAny, dayOfWeekAny bool
+}
+
+func parseCronSchedule(s string) (*cronSchedule, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 5 { //nolint:gomnd
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields (minute hour day-of-month month day-of-week)", s)
+	}
+	var c cronSchedule
+	var err error
+	for _, f := range []struct {
+		values   *map[int]bool
+		field    string
+		min, max int
+	}{
+		{&c.minute, fields[0], 0, 59},
+		{&c.hour, fields[1], 0, 23},
+		{&c.dayOfMonth, fields[2], 1, 31},
+		{&c.month, fields[3], 1, 12},
+		{&c.dayOfWeek, fields[4], 0, 7},
+	} {
+		if *f.values, err = parseCronField(f.field, f.min, f.max); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", s, err)
+		}
+	}
+	if c.dayOfWeek[7] {
+		c.dayOfWeek[0] = true // 7 is Sunday, too.
+	}
+	c.dayOfMonthAny = fields[2] == "*"
+	c.dayOfWeekAny = fields[4] == "*"
+	return &c, nil
+}
+
+// parseCronField parses a comma separated list of "*", "N", "N-M", each with an optional "/STEP".
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+		from, to := min, max
+		if rangePart != "*" {
+			fromStr, toStr, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if from, err = strconv.Atoi(fromStr); err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(toStr); err != nil {
+					return nil, fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+		}
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+func (c *cronSchedule) matches(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+	dom := c.dayOfMonth[t.Day()]
+	dow := c.dayOfWeek[int(t.Weekday())]
+	if c.dayOfMonthAny || c.dayOfWeekAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// next returns the first minute after t which matches the schedule. The second return value
+// is false, if no minute matches within a year (for example "0 0 31 2 *").
+func (c *cronSchedule) next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for end := t.AddDate(1, 0, 0); t.Before(end); t = t.Add(time.Minute) {
+		if c.matches(t) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
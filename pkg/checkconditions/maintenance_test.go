@@ -0,0 +1,172 @@
+package checkconditions
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		wantErr  bool
+		matches  []string
+		misses   []string
+	}{
+		{
+			schedule: "* * * * *",
+			matches:  []string{"2026-10-16T09:12:00Z", "2026-01-01T00:00:00Z"},
+		},
+		{
+			schedule: "0 2 * * *",
+			matches:  []string{"2026-10-16T02:00:00Z"},
+			misses:   []string{"2026-10-16T02:01:00Z", "2026-10-16T03:00:00Z"},
+		},
+		{
+			schedule: "*/15 8-17 * * 1-5",
+			matches:  []string{"2026-10-16T08:45:00Z", "2026-10-12T17:00:00Z"},
+			misses:   []string{"2026-10-16T08:10:00Z", "2026-10-16T18:00:00Z", "2026-10-17T09:00:00Z"},
+		},
+		{
+			// 7 is Sunday, like 0.
+			schedule: "0 0 * * 7",
+			matches:  []string{"2026-10-18T00:00:00Z"},
+			misses:   []string{"2026-10-17T00:00:00Z"},
+		},
+		{
+			// Both day fields restricted: one of them needs to match, like in cron.
+			schedule: "0 0 1 * 1",
+			matches:  []string{"2026-10-01T00:00:00Z", "2026-10-19T00:00:00Z"},
+			misses:   []string{"2026-10-02T00:00:00Z"},
+		},
+		{
+			schedule: "0,30 * * * *",
+			matches:  []string{"2026-10-16T09:30:00Z"},
+			misses:   []string{"2026-10-16T09:15:00Z"},
+		},
+		{schedule: "* * * *", wantErr: true},
+		{schedule: "60 * * * *", wantErr: true},
+		{schedule: "* 24 * * *", wantErr: true},
+		{schedule: "* * 0 * *", wantErr: true},
+		{schedule: "* * * 13 *", wantErr: true},
+		{schedule: "5-1 * * * *", wantErr: true},
+		{schedule: "*/0 * * * *", wantErr: true},
+		{schedule: "a * * * *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			c, err := parseCronSchedule(tt.schedule)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, s := range tt.matches {
+				if !c.matches(mustParseTime(t, s)) {
+					t.Errorf("expected %s to match", s)
+				}
+			}
+			for _, s := range tt.misses {
+				if c.matches(mustParseTime(t, s)) {
+					t.Errorf("expected %s not to match", s)
+				}
+			}
+		})
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	tests := []struct {
+		schedule string
+		from     string
+		want     string
+		wantOK   bool
+	}{
+		{"0 2 * * *", "2026-10-16T09:12:30Z", "2026-10-17T02:00:00Z", true},
+		{"*/15 * * * *", "2026-10-16T09:12:00Z", "2026-10-16T09:15:00Z", true},
+		{"*/15 * * * *", "2026-10-16T09:15:00Z", "2026-10-16T09:30:00Z", true},
+		{"0 0 31 2 *", "2026-10-16T09:12:00Z", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule+" "+tt.from, func(t *testing.T) {
+			c, err := parseCronSchedule(tt.schedule)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, ok := c.next(mustParseTime(t, tt.from))
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %t, want %t", ok, tt.wantOK)
+			}
+			if ok && !got.Equal(mustParseTime(t, tt.want)) {
+				t.Errorf("next = %s, want %s", got.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}
+
+func mustParseTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestMaintenanceExitCode(t *testing.T) {
+	// Saturday 03:00, one hour after the start of the windows.
+	now := mustParseTime(t, "2026-10-17T03:00:00Z")
+	window := func(schedule string, namespaces []string, selector string) MaintenanceWindowConfig {
+		return MaintenanceWindowConfig{
+			Name: "upgrade", Schedule: schedule, Duration: metav1.Duration{Duration: 2 * time.Hour},
+			Namespaces: namespaces, NamespaceSelector: selector,
+		}
+	}
+	tests := []struct {
+		name       string
+		window     MaintenanceWindowConfig
+		suppressed bool
+		want       int
+	}{
+		{"other day", window("0 2 * * 0", nil, ""), false, ExitCodeFindings},
+		{"all namespaces", window("0 2 * * 6", nil, ""), false, 0},
+		{"suppressed findings count", window("0 2 * * 6", nil, ""), true, ExitCodeFindings},
+		{"other namespace", window("0 2 * * 6", []string{"staging"}, ""), false, ExitCodeFindings},
+		{"namespace", window("0 2 * * 6", []string{"shop"}, ""), false, 0},
+		{"matching selector", window("0 2 * * 6", nil, "env=prod"), false, 0},
+		{"other selector", window("0 2 * * 6", nil, "env=staging"), false, ExitCodeFindings},
+		{"window ended", window("0 0 * * 6", nil, ""), false, ExitCodeFindings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				MaintenanceWindows: []MaintenanceWindowConfig{tt.window},
+				ExitPolicies:       []ExitPolicyConfig{{Name: "ci", Suppressed: tt.suppressed}},
+			}
+			if err := config.MaintenanceWindows[0].parse(); err != nil {
+				t.Fatal(err)
+			}
+			if err := config.ExitPolicies[0].validate(); err != nil {
+				t.Fatal(err)
+			}
+			counter := &Counter{
+				findings: []Finding{{
+					Namespace: "shop", Kind: "Pod", Name: "web-1", ConditionType: "Ready", Severity: SeverityCritical,
+				}},
+				namespaces: map[string]namespaceMeta{"shop": {labels: map[string]string{"env": "prod"}}},
+			}
+			markMaintenance(config, counter, now)
+			var stderr bytes.Buffer
+			args := &Arguments{Config: config, ExitPolicy: "ci", stderr: &stderr}
+			if got := exitPolicyCode(args, counter.findings, counter); got != tt.want {
+				t.Errorf("exit code %d, want %d (maintenance %q, stderr %q)", got, tt.want, counter.findings[0].Maintenance, stderr.String())
+			}
+		})
+	}
+}
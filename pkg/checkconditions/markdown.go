@@ -0,0 +1,101 @@
+package checkconditions
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// writeMarkdown writes a Markdown report with a findings table per namespace and a totals
+// section. It can be pasted into GitHub issues or posted by bots.
+func writeMarkdown(w io.Writer, r *report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# check-conditions report\n\nScan at %s, duration %s.\n\n", r.ScanTime.UTC().Format("2006-01-02 15:04:05 UTC"), r.Duration)
+
+	byNamespace := make(map[string][]Finding)
+	bySeverity := make(map[string]int)
+	for _, f := range r.Findings {
+		byNamespace[f.Namespace] = append(byNamespace[f.Namespace], f)
+		bySeverity[f.Severity]++
+	}
+	namespaces := make([]string, 0, len(byNamespace))
+	for ns := range byNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	b.WriteString("## Totals\n\n")
+	b.WriteString("| | Count |\n|---|---:|\n")
+	fmt.Fprintf(&b, "| Findings | %d |\n", len(r.Findings))
+	fmt.Fprintf(&b, "| Critical | %d |\n", bySeverity[SeverityCritical])
+	fmt.Fprintf(&b, "| Warning | %d |\n", bySeverity[SeverityWarning])
+	fmt.Fprintf(&b, "| Pending | %d |\n", len(r.Pending))
+	fmt.Fprintf(&b, "| Namespaces with findings | %d |\n", len(namespaces))
+	fmt.Fprintf(&b, "| Checked resource types | %d |\n", r.CheckedResourceTypes)
+	fmt.Fprintf(&b, "| Checked resources | %d |\n", r.CheckedResources)
+	fmt.Fprintf(&b, "| Checked conditions | %d |\n", r.CheckedConditions)
+	fmt.Fprintf(&b, "| Skipped resource types | %d |\n", len(r.Skipped))
+	fmt.Fprintf(&b, "| Scan errors | %d |\n", len(r.Errors))
+	fmt.Fprintf(&b, "| Health score | %d |\n", r.Score)
+
+	// The columns of --history-file are only added, if the findings have them.
+	seen := false
+	for i := range r.Findings {
+		if r.Findings[i].FirstSeen != nil {
+			seen = true
+			break
+		}
+	}
+	for _, ns := range namespaces {
+		findings := byNamespace[ns]
+		sort.SliceStable(findings, func(i, j int) bool {
+			return findings[i].String() < findings[j].String()
+		})
+		title := ns
+		if title == "" {
+			title = "Cluster-scoped"
+		}
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", markdownEscape(title), len(findings))
+		b.WriteString("| Severity | Resource | Name | Condition | Reason | Message | Since |")
+		if seen {
+			b.WriteString(" First seen | Last seen | Occurrences |")
+		}
+		b.WriteString("\n|---|---|---|---|---|---|---|")
+		if seen {
+			b.WriteString("---|---|---:|")
+		}
+		b.WriteString("\n")
+		for i := range findings {
+			f := &findings[i]
+			since := ""
+			if !f.LastTransitionTime.IsZero() {
+				since = f.LastTransitionTime.UTC().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s=%s | %s | %s | %s |",
+				f.Severity, markdownEscape(f.Resource), markdownEscape(f.Name),
+				markdownEscape(f.ConditionType), markdownEscape(f.ConditionStatus),
+				markdownEscape(f.ConditionReason), markdownEscape(f.ConditionMessage), since)
+			if seen {
+				firstSeen, lastSeen, occurrences := "", "", ""
+				if f.FirstSeen != nil {
+					firstSeen = f.FirstSeen.UTC().Format("2006-01-02 15:04")
+					occurrences = strconv.Itoa(f.Occurrences)
+				}
+				if f.LastSeen != nil {
+					lastSeen = f.LastSeen.UTC().Format("2006-01-02 15:04")
+				}
+				fmt.Fprintf(&b, " %s | %s | %s |", firstSeen, lastSeen, occurrences)
+			}
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownEscape escapes characters which break a Markdown table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "\r", "").Replace(s)
+}
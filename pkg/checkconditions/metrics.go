@@ -0,0 +1,161 @@
+package checkconditions
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metric is a gauge with its samples.
+type metric struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+type sample struct {
+	labels [][2]string
+	value  float64
+}
+
+// collectMetrics returns the metrics of a scan. They are used by the textfile and by remote-write.
+// The findings are passed separately, since --output prometheus writes the filtered findings.
+func collectMetrics(counter *Counter, findings []Finding, now time.Time) []metric {
+	gauge := func(name, help string, value float64) metric {
+		return metric{name: name, help: help, samples: []sample{{value: value}}}
+	}
+	metrics := []metric{
+		findingsMetric("check_conditions_findings", "Number of conditions which need attention.", findings,
+			[]string{"namespace", "resource", "condition_type", "severity"},
+			func(f *Finding) []string { return []string{f.Namespace, f.Resource, f.ConditionType, f.Severity} }),
+		findingsMetric("check_conditions_failing", "Number of failing conditions, regardless of the severity.", findings,
+			[]string{"namespace", "resource", "condition"},
+			func(f *Finding) []string { return []string{f.Namespace, f.Resource, f.ConditionType} }),
+		countMetric("check_conditions_scan_errors", "Number of failed requests of the last scan.", "type",
+			len(counter.errors), func(i int) string { return counter.errors[i].Type }),
+		countMetric("check_conditions_skipped_resource_types", "Number of resource types which were not listed completely.", "reason",
+			len(counter.skipped), func(i int) string { return counter.skipped[i].reason }),
+		gauge("check_conditions_checked_resource_types", "Number of checked resource types.", float64(counter.checkedResourceTypes)),
+		gauge("check_conditions_checked_resources", "Number of checked resource objects.", float64(counter.checkedResources)),
+		gauge("check_conditions_checked_conditions", "Number of checked conditions.", float64(counter.checkedConditions)),
+		gauge("check_conditions_scan_duration_seconds", "Duration of the last scan.", now.Sub(counter.startTime).Seconds()),
+		gauge("check_conditions_health_score", "Health score between 0 (very bad) and 100 (no findings).", float64(counter.score)),
+		gauge("check_conditions_last_scan_timestamp_seconds", "Unix time of the end of the last scan.", float64(now.Unix())),
+	}
+	if len(counter.availabilities) > 0 {
+		availability := metric{
+			name: "check_conditions_availability_ratio",
+			help: "Ratio of the time without critical findings (full scans, weighted by their interval). Namespaces and kinds without critical findings are missing (ratio 1).",
+		}
+		for _, a := range counter.availabilities {
+			availability.samples = append(availability.samples, sample{
+				labels: [][2]string{{a.Scope, a.Name}, {"window", a.Window}},
+				value:  a.Ratio,
+			})
+		}
+		metrics = append(metrics, availability)
+	}
+	return metrics
+}
+
+// findingsMetric counts the findings per values of the labels. The samples are sorted by the values.
+func findingsMetric(name, help string, findings []Finding, labels []string, values func(f *Finding) []string) metric {
+	counts := make(map[string]int)
+	byKey := make(map[string][]string)
+	for i := range findings {
+		v := values(&findings[i])
+		key := strings.Join(v, "\x00")
+		counts[key]++
+		byKey[key] = v
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	m := metric{name: name, help: help}
+	for _, k := range keys {
+		s := sample{value: float64(counts[k])}
+		for i, label := range labels {
+			s.labels = append(s.labels, [2]string{label, byKey[k][i]})
+		}
+		m.samples = append(m.samples, s)
+	}
+	return m
+}
+
+// countMetric counts the n items per value of the label. There is always a sample, so that the
+// metric is 0 if there are no items.
+func countMetric(name, help, label string, n int, value func(i int) string) metric {
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		counts[value(i)]++
+	}
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	m := metric{name: name, help: help}
+	for _, v := range values {
+		m.samples = append(m.samples, sample{labels: [][2]string{{label, v}}, value: float64(counts[v])})
+	}
+	if len(m.samples) == 0 {
+		m.samples = []sample{{value: 0}}
+	}
+	return m
+}
+
+// writeMetrics writes the result of a scan in the Prometheus text format.
+func writeMetrics(w io.Writer, counter *Counter, findings []Finding, now time.Time) error {
+	return writeMetricsText(w, collectMetrics(counter, findings, now))
+}
+
+// writeMetricsText writes the metrics in the Prometheus text format.
+func writeMetricsText(w io.Writer, metrics []metric) error {
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, s := range m.samples {
+			b.WriteString(m.name)
+			if len(s.labels) > 0 {
+				parts := make([]string, 0, len(s.labels))
+				for _, l := range s.labels {
+					parts = append(parts, fmt.Sprintf("%s=\"%s\"", l[0], escapeLabelValue(l[1])))
+				}
+				b.WriteString("{" + strings.Join(parts, ",") + "}")
+			}
+			b.WriteString(" " + strconv.FormatFloat(s.value, 'f', -1, 64) + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes backslashes, double quotes and newlines of a label value.
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
+
+// writePrometheus writes the report in the Prometheus text format, for --output prometheus.
+// The metrics are the same as the ones of the textfile, but with the findings of the report.
+func writePrometheus(w io.Writer, r *report) error {
+	return writeMetrics(w, r.counter, r.Findings, time.Now())
+}
+
+// writeTextfile writes the metrics to the file for the textfile collector of node_exporter.
+// The file gets written atomically (see writeFileAtomic), so that node_exporter never reads a
+// half-written file.
+func writeTextfile(path string, counter *Counter) error {
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, counter, counter.findings, time.Now()); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
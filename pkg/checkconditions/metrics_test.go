@@ -0,0 +1,37 @@
+package checkconditions
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteMetricsFailing(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	findings := []Finding{
+		{Namespace: "a", Resource: "pods", Name: "p1", ConditionType: "Ready", Severity: SeverityCritical},
+		{Namespace: "a", Resource: "pods", Name: "p2", ConditionType: "Ready", Severity: SeverityWarning},
+		{Namespace: "b", Resource: "machines.cluster.x-k8s.io", Name: "m1", ConditionType: `Say "hi"`, Severity: SeverityWarning},
+	}
+	var b strings.Builder
+	if err := writeMetrics(&b, &Counter{startTime: now.Add(-2 * time.Second)}, findings, now); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP check_conditions_failing Number of failing conditions, regardless of the severity.
+# TYPE check_conditions_failing gauge
+check_conditions_failing{namespace="a",resource="pods",condition="Ready"} 2
+check_conditions_failing{namespace="b",resource="machines.cluster.x-k8s.io",condition="Say \"hi\""} 1
+`
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("exposition does not contain\n%s\ngot:\n%s", want, b.String())
+	}
+	for _, line := range []string{
+		`check_conditions_findings{namespace="a",resource="pods",condition_type="Ready",severity="critical"} 1`,
+		`check_conditions_findings{namespace="a",resource="pods",condition_type="Ready",severity="warning"} 1`,
+		"check_conditions_scan_duration_seconds 2\n",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("exposition does not contain %q", line)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package checkconditions
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// listNamespaces lists the objects of a namespaced resource type with one LIST per namespace of
+// Arguments.Namespaces, instead of one cluster-wide LIST. This reduces the payload, and users
+// who may only list objects in some namespaces can scan them. At most
+// Arguments.NamespaceConcurrency LISTs of the resource type run in parallel, and only if the
+// concurrencyLimiter has free slots.
+// Failed LISTs of single namespaces are returned as scan errors. err is only set, if the context
+// expired (--per-type-timeout).
+func listNamespaces(ctx context.Context, client dynamic.NamespaceableResourceInterface, args *Arguments,
+	gvr schema.GroupVersionResource,
+) (list *unstructured.UnstructuredList, skipped string, scanErrors []scanError, err error) {
+	type result struct {
+		list    *unstructured.UnstructuredList
+		skipped string
+		err     error
+	}
+	concurrency := args.NamespaceConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	results := make([]result, len(args.Namespaces))
+	// The calling worker holds a slot of the concurrencyLimiter. It lists the namespaces itself,
+	// if no further slot is free. So waiting for slots can not deadlock the workers.
+	semaphore := make(chan struct{}, concurrency-1)
+	var wg sync.WaitGroup
+	for i, namespace := range args.Namespaces {
+		i, namespace := i, namespace
+		listNamespace := func() {
+			l, s, err := listPages(ctx, client.Namespace(namespace), args)
+			results[i] = result{list: l, skipped: s, err: err}
+		}
+		select {
+		case semaphore <- struct{}{}:
+			if args.limiter.tryAcquire() {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-semaphore }()
+					defer args.limiter.release()
+					listNamespace()
+				}()
+				continue
+			}
+			<-semaphore
+		default:
+		}
+		listNamespace()
+	}
+	wg.Wait()
+
+	list = &unstructured.UnstructuredList{}
+	for i, r := range results {
+		if r.err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return list, "", nil, r.err
+			}
+			scanErr := newScanError(gvr, r.err)
+			scanErr.Namespace = args.Namespaces[i]
+			scanErrors = append(scanErrors, scanErr)
+			continue
+		}
+		list.Items = append(list.Items, r.list.Items...)
+		if r.skipped != "" {
+			skipped = r.skipped
+		}
+	}
+	return list, skipped, scanErrors, nil
+}
+
+// listInScope lists the objects of the resource type in the scope of the scan: all namespaces,
+// Arguments.Namespace or Arguments.Namespaces. See listPages and listNamespaces.
+func listInScope(ctx context.Context, client dynamic.NamespaceableResourceInterface, args *Arguments,
+	gvr schema.GroupVersionResource,
+) (list *unstructured.UnstructuredList, skipped string, scanErrors []scanError, err error) {
+	if len(args.Namespaces) > 0 {
+		return listNamespaces(ctx, client, args, gvr)
+	}
+	list, skipped, err = listPages(ctx, client.Namespace(args.Namespace), args)
+	return list, skipped, nil, err
+}
+
+// namespaceLimited returns true if the scan is limited to some namespaces.
+func (args *Arguments) namespaceLimited() bool {
+	return args.Namespace != "" || len(args.Namespaces) > 0
+}
+
+// fetchNamespaceMetas returns the labels and annotations of the namespaces in the scope of the
+// scan. With --namespace or --namespaces each namespace gets read on its own, since users may be
+// allowed to get their namespaces, but not to list all namespaces.
+func fetchNamespaceMetas(ctx context.Context, clientset kubernetes.Interface, args *Arguments,
+) (map[string]namespaceMeta, error) {
+	metas := make(map[string]namespaceMeta)
+	if !args.namespaceLimited() {
+		list, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			ns := &list.Items[i]
+			metas[ns.Name] = namespaceMeta{labels: ns.Labels, annotations: ns.Annotations}
+		}
+		return metas, nil
+	}
+	names := args.Namespaces
+	if len(names) == 0 {
+		names = []string{args.Namespace}
+	}
+	for _, name := range names {
+		ns, err := clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		metas[ns.Name] = namespaceMeta{labels: ns.Labels, annotations: ns.Annotations}
+	}
+	return metas, nil
+}
+
+// addNamespaceMetas adds the labels and annotations of the namespaces to the counter, if the scan
+// did not list the namespaces (--namespace, --namespaces, --resources or missing RBAC). Teams,
+// maintenance windows and ephemeral namespaces need them. If they can not be read, a scan error
+// gets added, so that it is visible why these do not match.
+func addNamespaceMetas(args *Arguments, counter *Counter, clientset kubernetes.Interface) {
+	metas := args.streamNamespaces
+	if metas == nil {
+		var err error
+		metas, err = fetchNamespaceMetas(context.TODO(), clientset, args)
+		if err != nil {
+			counter.errors = append(counter.errors,
+				newScanError(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, err))
+			return
+		}
+	}
+	for name, meta := range metas {
+		counter.namespaces[name] = meta
+	}
+}
@@ -0,0 +1,150 @@
+package checkconditions
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var namespaceKind = schema.GroupKind{Kind: "Namespace"}
+
+// networkPolicyInfo is a NetworkPolicy with its selectors. Empty selectors match everything,
+// so they are nil.
+type networkPolicyInfo struct {
+	gvr                schema.GroupVersionResource
+	namespace          string
+	name               string
+	uid                types.UID
+	podSelector        labels.Selector
+	namespaceSelectors []labels.Selector
+}
+
+// nonEmptySelector converts the label selector. It returns nil for empty or invalid selectors.
+func nonEmptySelector(v interface{}) labels.Selector {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return nil
+	}
+	var ls metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &ls); err != nil {
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&ls)
+	if err != nil || selector.Empty() {
+		return nil
+	}
+	return selector
+}
+
+// collectNetworkPolicies records NetworkPolicies, and the labels of the pods per namespace.
+func collectNetworkPolicies(_ *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	switch {
+	case gvr.Group == "" && gvr.Resource == "pods":
+		if output.podLabels == nil {
+			output.podLabels = make(map[string][]labels.Set)
+		}
+		output.podLabels[obj.GetNamespace()] = append(output.podLabels[obj.GetNamespace()], obj.GetLabels())
+	case gvr.Group == "networking.k8s.io" && gvr.Resource == "networkpolicies":
+		spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+		np := networkPolicyInfo{
+			gvr:         gvr,
+			namespace:   obj.GetNamespace(),
+			name:        obj.GetName(),
+			uid:         obj.GetUID(),
+			podSelector: nonEmptySelector(spec["podSelector"]),
+		}
+		for _, field := range []struct{ rules, peers string }{{"ingress", "from"}, {"egress", "to"}} {
+			rules, _, _ := unstructured.NestedSlice(spec, field.rules)
+			for _, rule := range rules {
+				peers, _, _ := unstructured.NestedSlice(mapOf(rule), field.peers)
+				for _, peer := range peers {
+					if s := nonEmptySelector(mapOf(peer)["namespaceSelector"]); s != nil {
+						np.namespaceSelectors = append(np.namespaceSelectors, s)
+					}
+				}
+			}
+		}
+		output.networkPolicies = append(output.networkPolicies, np)
+	}
+	return nil
+}
+
+// ineffectiveNetworkPolicies reports NetworkPolicies whose podSelector matches no pods, and
+// namespaceSelectors which match no namespaces. These policies silently do not provide the
+// intended isolation.
+func ineffectiveNetworkPolicies(args *Arguments, counter *Counter) []Finding {
+	if args.LabelSelector != "" {
+		return nil
+	}
+	var findings []Finding
+	for i := range counter.networkPolicies {
+		np := &counter.networkPolicies[i]
+		var problems []string
+		reason := ""
+		if np.podSelector != nil && counter.listedKinds[podKind] && !anyMatches(np.podSelector, counter.podLabels[np.namespace]) {
+			problems = append(problems, fmt.Sprintf("podSelector %q matches no pods", np.podSelector.String()))
+			reason = "NoPodsSelected"
+		}
+		if counter.listedKinds[namespaceKind] && !args.namespaceLimited() {
+			for _, s := range np.namespaceSelectors {
+				if !namespaceMatches(s, counter.namespaces) {
+					problems = append(problems, fmt.Sprintf("namespaceSelector %q matches no namespaces", s.String()))
+					if reason == "" {
+						reason = "NoNamespacesSelected"
+					}
+				}
+			}
+		}
+		if len(problems) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Namespace:          np.namespace,
+			Group:              np.gvr.Group,
+			Version:            np.gvr.Version,
+			Resource:           np.gvr.Resource,
+			Kind:               "NetworkPolicy",
+			Name:               np.name,
+			UID:                np.uid,
+			ConditionType:      "Effective",
+			ConditionStatus:    "False",
+			ConditionReason:    reason,
+			ConditionMessage:   strings.Join(problems, ", "),
+			Severity:           SeverityWarning,
+			MessageFingerprint: messageFingerprint(strings.Join(problems, ", ")),
+			Code:               CodeNetworkPolicyIneffective,
+			Check:              checkNetworkPolicies,
+		})
+	}
+	return findings
+}
+
+func anyMatches(selector labels.Selector, sets []labels.Set) bool {
+	for _, set := range sets {
+		if selector.Matches(set) {
+			return true
+		}
+	}
+	return false
+}
+
+func namespaceMatches(selector labels.Selector, namespaces map[string]namespaceMeta) bool {
+	for name, ns := range namespaces {
+		set := labels.Set{"kubernetes.io/metadata.name": name}
+		for k, v := range ns.labels {
+			set[k] = v
+		}
+		if selector.Matches(set) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package checkconditions
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIneffectiveNetworkPolicies(t *testing.T) {
+	npGVR := schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}
+	podGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	networkPolicy := func(name string, podSelector map[string]interface{}, namespaceSelectors ...map[string]interface{}) *unstructured.Unstructured {
+		var from []interface{}
+		for _, s := range namespaceSelectors {
+			from = append(from, map[string]interface{}{"namespaceSelector": s})
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"namespace": "a", "name": name},
+			"spec": map[string]interface{}{
+				"podSelector": podSelector,
+				"ingress":     []interface{}{map[string]interface{}{"from": from}},
+			},
+		}}
+	}
+	matchLabels := func(key, value string) map[string]interface{} {
+		return map[string]interface{}{"matchLabels": map[string]interface{}{key: value}}
+	}
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"namespace": "a", "name": "web-1", "labels": map[string]interface{}{"app": "web"}},
+	}}
+
+	output := &handleResourceTypeOutput{}
+	collectNetworkPolicies(&Arguments{}, podGVR, pod, output)
+	for _, obj := range []*unstructured.Unstructured{
+		networkPolicy("effective", matchLabels("app", "web"), matchLabels("team", "payments")),
+		networkPolicy("all-pods", map[string]interface{}{}),
+		networkPolicy("no-pods", matchLabels("app", "api")),
+		networkPolicy("no-namespaces", matchLabels("app", "web"), matchLabels("team", "unknown")),
+		networkPolicy("namespace-name", nil, matchLabels("kubernetes.io/metadata.name", "b")),
+		networkPolicy("nothing", matchLabels("app", "api"), matchLabels("team", "unknown")),
+	} {
+		collectNetworkPolicies(&Arguments{}, npGVR, obj, output)
+	}
+	counter := &Counter{
+		networkPolicies: output.networkPolicies,
+		podLabels:       output.podLabels,
+		namespaces: map[string]namespaceMeta{
+			"a": {labels: map[string]string{"team": "payments"}},
+			"b": {},
+		},
+		listedKinds: map[schema.GroupKind]bool{podKind: true, namespaceKind: true},
+	}
+
+	tests := []struct {
+		name   string
+		args   Arguments
+		modify func(*Counter)
+		want   map[string]string
+	}{
+		{
+			name: "complete scan",
+			want: map[string]string{
+				"no-pods":       `podSelector "app=api" matches no pods`,
+				"no-namespaces": `namespaceSelector "team=unknown" matches no namespaces`,
+				"nothing":       `podSelector "app=api" matches no pods, namespaceSelector "team=unknown" matches no namespaces`,
+			},
+		},
+		{
+			name: "namespaces limited",
+			args: Arguments{Namespace: "a"},
+			want: map[string]string{
+				"no-pods": `podSelector "app=api" matches no pods`,
+				"nothing": `podSelector "app=api" matches no pods`,
+			},
+		},
+		{
+			name:   "pods not listed",
+			modify: func(c *Counter) { c.listedKinds[podKind] = false },
+			want: map[string]string{
+				"no-namespaces": `namespaceSelector "team=unknown" matches no namespaces`,
+				"nothing":       `namespaceSelector "team=unknown" matches no namespaces`,
+			},
+		},
+		{name: "label selector", args: Arguments{LabelSelector: "app=web"}, want: map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := *counter
+			c.listedKinds = map[schema.GroupKind]bool{podKind: true, namespaceKind: true}
+			if tt.modify != nil {
+				tt.modify(&c)
+			}
+			got := make(map[string]string)
+			for _, f := range ineffectiveNetworkPolicies(&tt.args, &c) {
+				got[f.Name] = f.ConditionMessage
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%s: got %q, want %q", name, got[name], want)
+				}
+			}
+		})
+	}
+}
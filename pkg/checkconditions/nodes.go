@@ -0,0 +1,50 @@
+package checkconditions
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// collectPodsOnNodes counts the pods per node, which are not finished.
+func collectPodsOnNodes(_ *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	if gvr.Group != "" || gvr.Resource != "pods" {
+		return nil
+	}
+	nodeName, _, _ := unstructured.NestedString(obj.Object, "spec", "nodeName")
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	if nodeName == "" || phase == "Succeeded" || phase == "Failed" {
+		return nil
+	}
+	if output.podsOnNodes == nil {
+		output.podsOnNodes = make(map[string]int)
+	}
+	output.podsOnNodes[nodeName]++
+	return nil
+}
+
+// describeNotReadyNodes sets for the findings of nodes which are NotReady or Unknown since when
+// they are in that state, and how many pods are stranded on them. The start is the lastTransitionTime
+// of the condition, or the first time the finding was seen (history file), if that is earlier.
+// The lastTransitionTime changes if the status switches between False and Unknown.
+// The message is not changed, since it would change with each scan (notifier, history, sinks).
+func describeNotReadyNodes(args *Arguments, counter *Counter) []Finding {
+	for i := range counter.findings {
+		f := &counter.findings[i]
+		if f.Group != "" || f.Resource != "nodes" || f.ConditionType != readyString || f.ConditionStatus == "True" {
+			continue
+		}
+		since := f.LastTransitionTime
+		if args.history != nil {
+			if open, ok := args.history.open[f.ID()]; ok && (since.IsZero() || open.firstSeen.Before(since)) {
+				since = open.firstSeen
+			}
+		}
+		f.NotReady = &NotReadyNode{StrandedPods: counter.podsOnNodes[f.Name]}
+		if !since.IsZero() {
+			f.NotReady.Since = &since
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package checkconditions
+
+import (
+	"time"
+)
+
+// notifier remembers which findings were already reported. This avoids that
+// the same findings get printed again and again if the check runs in a loop.
+type notifier struct {
+	renotifyInterval time.Duration
+	notified         map[string]notifiedFinding
+}
+
+type notifiedFinding struct {
+	finding    Finding
+	firstSeen  time.Time
+	notifiedAt time.Time
+}
+
+func newNotifier(renotifyInterval time.Duration) *notifier {
+	return &notifier{
+		renotifyInterval: renotifyInterval,
+		notified:         make(map[string]notifiedFinding),
+	}
+}
+
+// filter returns the findings which should get reported. A finding gets reported if it is new,
+// if status, reason or message changed, or if the re-notify interval is over.
+// Findings which were reported before, but which are gone now, get returned as resolved lines,
+// if resolvable returns true. See Counter.resolvable.
+func (n *notifier) filter(findings []Finding, now time.Time, resolvable func(f *Finding) bool) (report []Finding, resolved []string) {
+	for i := range findings {
+		if n.notify(&findings[i], now) {
+			report = append(report, findings[i])
+		}
+	}
+	for _, old := range n.forget(findings, resolvable) {
+		r := resolvedFinding{finding: old.finding, firstSeen: old.firstSeen, resolvedAt: now}
+		resolved = append(resolved, r.String())
+	}
+	return report, resolved
+}
+
+// notify returns true, if the finding should get reported (see due). The notification gets remembered.
+func (n *notifier) notify(f *Finding, now time.Time) bool {
+	if !n.due(f, now) {
+		return false
+	}
+	n.mark(f, now)
+	return true
+}
+
+// due returns true, if the finding should get reported: it is new, status, reason or message
+// changed, or the re-notify interval is over. Unlike notify, it does not remember anything, so
+// a sink can call mark after it sent the finding, and a failed send gets retried after the next scan.
+func (n *notifier) due(f *Finding, now time.Time) bool {
+	old, ok := n.notified[f.ID()]
+	return !ok || n.changed(&old.finding, f) ||
+		(n.renotifyInterval != 0 && now.Sub(old.notifiedAt) >= n.renotifyInterval)
+}
+
+// mark remembers that the finding was reported. The first time the finding was seen is kept.
+func (n *notifier) mark(f *Finding, now time.Time) {
+	id := f.ID()
+	firstSeen := now
+	if old, ok := n.notified[id]; ok {
+		firstSeen = old.firstSeen
+	}
+	n.notified[id] = notifiedFinding{finding: *f, firstSeen: firstSeen, notifiedAt: now}
+}
+
+// forget removes and returns the notified findings which are gone, if resolvable returns true.
+// So they get reported again, if they come back.
+func (n *notifier) forget(findings []Finding, resolvable func(f *Finding) bool) []notifiedFinding {
+	seen := make(map[string]bool, len(findings))
+	for i := range findings {
+		seen[findings[i].ID()] = true
+	}
+	var gone []notifiedFinding
+	for id, old := range n.notified {
+		if seen[id] || !resolvable(&old.finding) {
+			continue
+		}
+		delete(n.notified, id)
+		gone = append(gone, old)
+	}
+	return gone
+}
+
+func (n *notifier) changed(a, b *Finding) bool {
+	return a.ConditionStatus != b.ConditionStatus ||
+		a.ConditionReason != b.ConditionReason ||
+		a.ConditionMessage != b.ConditionMessage
+}
@@ -0,0 +1,163 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNotifierNotify(t *testing.T) {
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	f := Finding{Namespace: "a", Resource: "pods", Name: "p1", ConditionType: "Ready", ConditionStatus: "False", ConditionMessage: "x"}
+	changed := f
+	changed.ConditionMessage = "y"
+	type step struct {
+		after   time.Duration
+		finding Finding
+		want    bool
+	}
+	tests := []struct {
+		name     string
+		renotify time.Duration
+		steps    []step
+	}{
+		{
+			name:  "unchanged findings are sent once",
+			steps: []step{{0, f, true}, {time.Minute, f, false}, {24 * time.Hour, f, false}, {25 * time.Hour, changed, true}, {26 * time.Hour, changed, false}},
+		},
+		{
+			name:     "re-notify interval",
+			renotify: time.Hour,
+			steps:    []step{{0, f, true}, {59 * time.Minute, f, false}, {time.Hour, f, true}, {90 * time.Minute, f, false}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := newNotifier(tt.renotify)
+			for i, s := range tt.steps {
+				f := s.finding
+				if got := n.notify(&f, start.Add(s.after)); got != s.want {
+					t.Fatalf("step %d: notify = %t, want %t", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+// TestSendToSinksNotifier checks the sink path of serve: with sink notifiers, Opsgenie gets an
+// alert only for new and changed findings, and again after a finding was resolved.
+func TestSendToSinksNotifier(t *testing.T) {
+	var created []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v2/alerts" {
+			var body struct {
+				Description string `json:"description"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			created = append(created, body.Description)
+		}
+		// No open alerts: without notifier every scan would create the alerts again.
+		_, _ = w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	pod := Finding{
+		Namespace: "a", Version: "v1", Resource: "pods", Kind: "Pod", Name: "p1",
+		ConditionType: "Ready", ConditionStatus: "False", ConditionMessage: "crash", Severity: SeverityCritical,
+	}
+	changed := pod
+	changed.ConditionMessage = "image pull"
+	scans := []struct {
+		findings []Finding
+		want     int
+	}{
+		{findings: []Finding{pod}, want: 1},
+		{findings: []Finding{pod}, want: 0},
+		{findings: []Finding{changed}, want: 1},
+		{findings: nil, want: 0},
+		{findings: []Finding{changed}, want: 1},
+	}
+	for _, withNotifiers := range []bool{true, false} {
+		created = nil
+		args := &Arguments{Config: &Config{}}
+		args.sinks = []sink{newOpsgenieSink(&OpsgenieConfig{APIURL: server.URL})}
+		if withNotifiers {
+			args.sinkNotifiers = make(map[string]*notifier)
+		}
+		total := 0
+		for i, scan := range scans {
+			counter := &Counter{findings: scan.findings, listedKinds: map[schema.GroupKind]bool{{Kind: "Pod"}: true}}
+			before := len(created)
+			sendToSinks(args, counter)
+			want := scan.want
+			if !withNotifiers {
+				want = len(scan.findings)
+			}
+			if got := len(created) - before; got != want {
+				t.Fatalf("notifiers %t, scan %d: %d alerts created, want %d: %s", withNotifiers, i, got, want,
+					strings.Join(created[before:], "; "))
+			}
+			total += want
+		}
+		if len(created) != total {
+			t.Fatalf("created %d alerts, want %d", len(created), total)
+		}
+	}
+}
+
+// TestNotifierRetry checks that a finding stays due until it is marked, so that a sink which failed
+// to send it sends it again after the next scan.
+func TestNotifierRetry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	f := Finding{Namespace: "a", Resource: "pods", Name: "p1", ConditionType: "Ready", ConditionStatus: "False"}
+	n := newNotifier(0)
+	for i := 0; i < 2; i++ {
+		if !n.due(&f, now.Add(time.Duration(i)*time.Minute)) {
+			t.Fatalf("scan %d: a finding which was never sent is not due", i)
+		}
+	}
+	n.mark(&f, now.Add(2*time.Minute))
+	if n.due(&f, now.Add(3*time.Minute)) {
+		t.Fatalf("a sent finding is due again")
+	}
+	n.mark(&f, now.Add(4*time.Minute))
+	if got := n.notified[f.ID()].firstSeen; !got.Equal(now.Add(2 * time.Minute)) {
+		t.Errorf("firstSeen %s, want the time of the first mark", got)
+	}
+}
+
+func TestNotifierForget(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	pod := Finding{Namespace: "a", Resource: "pods", Name: "p1", ConditionType: "Ready", ConditionStatus: "False"}
+	node := Finding{Resource: "nodes", Name: "n1", ConditionType: "Ready", ConditionStatus: "False"}
+	tests := []struct {
+		name       string
+		current    []Finding
+		resolvable bool
+		resolved   int
+	}{
+		{name: "gone and resolvable", resolvable: true, resolved: 2},
+		{name: "gone, but not resolvable", resolvable: false, resolved: 0},
+		{name: "one still there", current: []Finding{pod}, resolvable: true, resolved: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := newNotifier(0)
+			n.filter([]Finding{pod, node}, now, func(*Finding) bool { return true })
+			_, resolved := n.filter(tt.current, now.Add(time.Minute), func(*Finding) bool { return tt.resolvable })
+			if len(resolved) != tt.resolved {
+				t.Fatalf("resolved %v, want %d lines", resolved, tt.resolved)
+			}
+			// A resolved finding which comes back gets reported again, the others not.
+			report, _ := n.filter([]Finding{pod, node}, now.Add(2*time.Minute), func(*Finding) bool { return true })
+			if len(report) != tt.resolved {
+				t.Errorf("reported %d findings again, want %d", len(report), tt.resolved)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// etcdMaxObjectSize is the default maximum size of a request of etcd (--max-request-bytes).
+const etcdMaxObjectSize = 1536 * 1024
+
+// objectSize reports objects whose serialized size is larger than Arguments.ObjectSizeThreshold,
+// since they come close to the limit of etcd. Updates of these objects fail later.
+func objectSize(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	_ *handleResourceTypeOutput,
+) []Finding {
+	data, err := json.Marshal(obj.Object)
+	if err != nil || len(data) < args.ObjectSizeThreshold {
+		return nil
+	}
+	return []Finding{{
+		Namespace:          obj.GetNamespace(),
+		Group:              gvr.Group,
+		Version:            gvr.Version,
+		Resource:           gvr.Resource,
+		Kind:               obj.GetKind(),
+		Name:               obj.GetName(),
+		UID:                obj.GetUID(),
+		ConditionType:      "ObjectSize",
+		ConditionStatus:    "Large",
+		ConditionReason:    "CloseToEtcdLimit",
+		ConditionMessage:   fmt.Sprintf("object has %d KiB, the limit of etcd is about %d KiB", len(data)/1024, etcdMaxObjectSize/1024),
+		Severity:           SeverityWarning,
+		MessageFingerprint: fmt.Sprintf("object has <n> KiB, the limit of etcd is about %d KiB", etcdMaxObjectSize/1024),
+		Code:               CodeObjectSize,
+		Check:              checkObjectSize,
+	}}
+}
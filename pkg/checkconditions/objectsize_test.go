@@ -0,0 +1,46 @@
+package checkconditions
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestObjectSize(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	configMap := func(size int) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind": "ConfigMap",
+			"data": map[string]interface{}{"blob": strings.Repeat("x", size)},
+		}}
+		obj.SetNamespace("a")
+		obj.SetName("cm")
+		return obj
+	}
+	args := &Arguments{ObjectSizeThreshold: 1024 * 1024}
+	tests := []struct {
+		name string
+		size int
+		want string
+	}{
+		{"small", 1024, ""},
+		{"just below the threshold", 1024*1024 - 200, ""},
+		{"large", 1200 * 1024, "object has 1200 KiB, the limit of etcd is about 1536 KiB"},
+	}
+	for _, tt := range tests {
+		findings := objectSize(args, configMaps, configMap(tt.size), nil)
+		switch {
+		case tt.want == "" && len(findings) != 0:
+			t.Errorf("%s: unexpected findings %v", tt.name, findings)
+		case tt.want != "" && len(findings) != 1:
+			t.Errorf("%s: got %d findings, want 1", tt.name, len(findings))
+		case tt.want != "":
+			f := findings[0]
+			if f.ConditionMessage != tt.want || f.ConditionReason != "CloseToEtcdLimit" || f.Code != CodeObjectSize || f.Check != checkObjectSize {
+				t.Errorf("%s: unexpected finding %+v", tt.name, f)
+			}
+		}
+	}
+}
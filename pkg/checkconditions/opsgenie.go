@@ -0,0 +1,170 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// OpsgenieConfig configures the creation of Opsgenie alerts.
+type OpsgenieConfig struct {
+	// APIURL defaults to https://api.opsgenie.com. Use https://api.eu.opsgenie.com for the EU instance.
+	APIURL string `json:"apiURL"`
+	// APIKeyEnv is the name of the environment variable containing the API key. Defaults to OPSGENIE_API_KEY.
+	APIKeyEnv string `json:"apiKeyEnv"`
+	// Priorities maps the severity of a finding to the priority of the alert (P1 to P5).
+	// Findings with a severity which is not in the map don't create alerts.
+	// Defaults to critical: P2.
+	Priorities map[string]string `json:"priorities"`
+}
+
+// opsgenieTag is set on all alerts created by check-conditions.
+const opsgenieTag = "check-conditions"
+
+type opsgenieSink struct {
+	config *OpsgenieConfig
+	header http.Header
+}
+
+func newOpsgenieSink(config *OpsgenieConfig) *opsgenieSink {
+	if config.APIURL == "" {
+		config.APIURL = "https://api.opsgenie.com"
+	}
+	if config.APIKeyEnv == "" {
+		config.APIKeyEnv = "OPSGENIE_API_KEY"
+	}
+	if len(config.Priorities) == 0 {
+		config.Priorities = map[string]string{SeverityCritical: "P2"}
+	}
+	header := make(http.Header)
+	header.Set("Authorization", "GenieKey "+os.Getenv(config.APIKeyEnv))
+	return &opsgenieSink{config: config, header: header}
+}
+
+func (s *opsgenieSink) name() string {
+	return "Opsgenie"
+}
+
+// send creates an alert for each finding. The alias of the alert is the key of the finding (see sinkKey).
+// Opsgenie does not create a new alert if an open alert with the same alias exists.
+// Open alerts of resolved findings get closed.
+// A finding whose alert could not be created gets logged, and is sent again after the next scan.
+func (s *opsgenieSink) send(ctx context.Context, args *Arguments, counter *Counter) error {
+	open, err := s.openAlerts(ctx)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for i := range counter.findings {
+		f := &counter.findings[i]
+		alias := opsgenieTag + "-" + args.sinkKey(f)
+		priority, ok := s.config.Priorities[f.Severity]
+		if !ok || f.Maintenance != "" {
+			continue
+		}
+		if !args.sinkDue(s.name(), f) {
+			continue
+		}
+		if !open[alias] {
+			team := teamConfigOf(args.Config, counter.namespaces, f.Namespace)
+			if err := s.createAlert(ctx, args.diagnostics(), f, team, alias, priority); err != nil {
+				fmt.Fprintf(args.diagnostics(), "WARNING: creating an Opsgenie alert for %s failed: %s\n", f.ID(), err.Error())
+				failed++
+				continue
+			}
+		}
+		args.sinkSent(s.name(), f)
+	}
+	if !closeResolved(args, counter, s.name()) {
+		return sendFailed(failed, "Opsgenie alerts")
+	}
+	current := currentKeys(args, counter, opsgenieTag+"-")
+	for alias := range open {
+		if current[alias] || !args.ownKey(opsgenieTag+"-", alias) {
+			continue
+		}
+		if err := s.closeAlert(ctx, args.diagnostics(), alias); err != nil {
+			return err
+		}
+	}
+	return sendFailed(failed, "Opsgenie alerts")
+}
+
+func (s *opsgenieSink) alertsURL() string {
+	return strings.TrimSuffix(s.config.APIURL, "/") + "/v2/alerts"
+}
+
+// openAlerts returns the aliases of the open alerts created by check-conditions.
+func (s *opsgenieSink) openAlerts(ctx context.Context) (map[string]bool, error) {
+	aliases := make(map[string]bool)
+	query := url.QueryEscape(fmt.Sprintf("status: open AND tag: %s", opsgenieTag))
+	for offset := 0; ; {
+		var result struct {
+			Data []struct {
+				Alias string `json:"alias"`
+			} `json:"data"`
+		}
+		u := fmt.Sprintf("%s?query=%s&limit=100&offset=%d", s.alertsURL(), query, offset)
+		if err := doJSON(ctx, http.MethodGet, u, s.header, nil, &result); err != nil {
+			return nil, err
+		}
+		for _, alert := range result.Data {
+			aliases[alert.Alias] = true
+		}
+		offset += len(result.Data)
+		if len(result.Data) < 100 {
+			return aliases, nil
+		}
+	}
+}
+
+// createAlert creates the alert. If the team of the finding has an opsgenieTeam, it gets set as responder.
+func (s *opsgenieSink) createAlert(ctx context.Context, log io.Writer, f *Finding, team *TeamConfig, alias string, priority string) error {
+	tags := []string{opsgenieTag, "kind:" + f.Kind}
+	if f.Namespace != "" {
+		tags = append(tags, "namespace:"+f.Namespace)
+	}
+	if team != nil {
+		tags = append(tags, "team:"+team.Name)
+	}
+	body := map[string]interface{}{
+		"message": truncate(strings.TrimSpace(fmt.Sprintf("%s %s %s: %s=%s %s", f.Namespace, f.Resource, f.Name,
+			f.ConditionType, f.ConditionStatus, f.ConditionReason)), 130),
+		"alias":       alias,
+		"description": fmt.Sprintf("%s\n\nFinding ID: %s", strings.TrimSpace(f.String()), f.ID()),
+		"priority":    priority,
+		"tags":        tags,
+		"source":      "check-conditions",
+	}
+	if team != nil && team.OpsgenieTeam != "" {
+		body["responders"] = []map[string]string{{"type": "team", "name": team.OpsgenieTeam}}
+	}
+	if err := doJSON(ctx, http.MethodPost, s.alertsURL(), s.header, body, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(log, "Created Opsgenie alert %s for %s\n", alias, f.ID())
+	return nil
+}
+
+func (s *opsgenieSink) closeAlert(ctx context.Context, log io.Writer, alias string) error {
+	u := fmt.Sprintf("%s/%s/close?identifierType=alias", s.alertsURL(), url.PathEscape(alias))
+	body := map[string]string{"source": "check-conditions", "note": "The condition is resolved."}
+	if err := doJSON(ctx, http.MethodPost, u, s.header, body, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(log, "Closed Opsgenie alert %s\n", alias)
+	return nil
+}
+
+// truncate returns s shortened to maxLen runes.
+func truncate(s string, maxLen int) string {
+	r := []rune(s)
+	if len(r) <= maxLen {
+		return s
+	}
+	return string(r[:maxLen])
+}
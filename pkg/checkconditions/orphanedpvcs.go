@@ -0,0 +1,183 @@
+package checkconditions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxOrphanedPVCNames is the number of PVC names in the message of an orphanedpvcs finding.
+const maxOrphanedPVCNames = 5
+
+// statefulSetPVCName matches names of PVCs created from volumeClaimTemplates: TEMPLATE-STATEFULSET-ORDINAL.
+var statefulSetPVCName = regexp.MustCompile(`^.+-.+-(0|[1-9][0-9]*)$`)
+
+var (
+	statefulSetKind = schema.GroupKind{Group: "apps", Kind: "StatefulSet"}
+	podKind         = schema.GroupKind{Kind: "Pod"}
+	pvcKind         = schema.GroupKind{Kind: "PersistentVolumeClaim"}
+)
+
+type statefulSetInfo struct {
+	namespace string
+	name      string
+	replicas  int64
+	templates []string
+
+	// ordinalStart is spec.ordinals.start. The ordinals of the pods are ordinalStart to ordinalStart+replicas-1.
+	ordinalStart int64
+}
+
+type pvcInfo struct {
+	namespace string
+	name      string
+	size      resource.Quantity
+}
+
+// collectStatefulSetPVCs records StatefulSets, PVCs without owner, and the PVCs used by pods.
+func collectStatefulSetPVCs(_ *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	switch {
+	case gvr.Group == "apps" && gvr.Resource == "statefulsets":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		start, _, _ := unstructured.NestedInt64(obj.Object, "spec", "ordinals", "start")
+		sts := statefulSetInfo{namespace: obj.GetNamespace(), name: obj.GetName(), replicas: replicas, ordinalStart: start}
+		templates, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates")
+		for _, t := range templates {
+			if m, ok := t.(map[string]interface{}); ok {
+				name, _, _ := unstructured.NestedString(m, "metadata", "name")
+				sts.templates = append(sts.templates, name)
+			}
+		}
+		output.statefulSets = append(output.statefulSets, sts)
+	case gvr.Group == "" && gvr.Resource == "persistentvolumeclaims":
+		if len(obj.GetOwnerReferences()) > 0 || !statefulSetPVCName.MatchString(obj.GetName()) {
+			return nil
+		}
+		pvc := pvcInfo{namespace: obj.GetNamespace(), name: obj.GetName()}
+		size, _, _ := unstructured.NestedString(obj.Object, "spec", "resources", "requests", "storage")
+		if q, err := resource.ParseQuantity(size); err == nil {
+			pvc.size = q
+		}
+		output.pvcs = append(output.pvcs, pvc)
+	case gvr.Group == "" && gvr.Resource == "pods":
+		volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumes")
+		for _, v := range volumes {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if claim, _, _ := unstructured.NestedString(m, "persistentVolumeClaim", "claimName"); claim != "" {
+				output.usedPVCs = append(output.usedPVCs, obj.GetNamespace()+"/"+claim)
+			}
+		}
+	}
+	return nil
+}
+
+// orphanedPVCReason returns why the PVC is orphaned, or "" if it is not. PVCs used by a pod are not
+// orphaned. The name of the PVC gets matched against TEMPLATE-STATEFULSET-ORDINAL of the existing
+// StatefulSets: the PVC is orphaned, if the ordinal is outside of the ordinals of the replicas. A PVC
+// without owner, which is named like that but matches no StatefulSet, is left from a deleted StatefulSet.
+func orphanedPVCReason(pvc *pvcInfo, statefulSets []statefulSetInfo, used map[string]bool) string {
+	if used[pvc.namespace+"/"+pvc.name] {
+		return ""
+	}
+	for i := range statefulSets {
+		sts := &statefulSets[i]
+		if sts.namespace != pvc.namespace {
+			continue
+		}
+		for _, t := range sts.templates {
+			ordinal, ok := strings.CutPrefix(pvc.name, t+"-"+sts.name+"-")
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseInt(ordinal, 10, 64)
+			if err != nil || strconv.FormatInt(n, 10) != ordinal {
+				continue
+			}
+			if end := sts.ordinalStart + sts.replicas; n >= end {
+				if sts.ordinalStart == 0 {
+					return fmt.Sprintf("ordinal %d >= replicas %d of StatefulSet %s", n, sts.replicas, sts.name)
+				}
+				return fmt.Sprintf("ordinal %d >= ordinals.start + replicas %d of StatefulSet %s", n, end, sts.name)
+			}
+			if n < sts.ordinalStart {
+				return fmt.Sprintf("ordinal %d < ordinals.start %d of StatefulSet %s", n, sts.ordinalStart, sts.name)
+			}
+			return ""
+		}
+	}
+	return "no StatefulSet, not used by a pod"
+}
+
+// orphanedPVCs reports per namespace the PVCs of deleted StatefulSets, or of ordinals outside the
+// replicas (scaled down), and the storage which could be reclaimed. Only checked if StatefulSets,
+// PVCs and pods were listed completely.
+func orphanedPVCs(args *Arguments, counter *Counter) []Finding {
+	if args.LabelSelector != "" {
+		return nil
+	}
+	for _, kind := range []schema.GroupKind{statefulSetKind, podKind, pvcKind} {
+		if !counter.listedKinds[kind] {
+			return nil
+		}
+	}
+	used := make(map[string]bool, len(counter.usedPVCs))
+	for _, claim := range counter.usedPVCs {
+		used[claim] = true
+	}
+	type namespaceOrphans struct {
+		names []string
+		size  resource.Quantity
+	}
+	orphans := make(map[string]*namespaceOrphans)
+	for i := range counter.pvcs {
+		pvc := &counter.pvcs[i]
+		reason := orphanedPVCReason(pvc, counter.statefulSets, used)
+		if reason == "" {
+			continue
+		}
+		o := orphans[pvc.namespace]
+		if o == nil {
+			o = &namespaceOrphans{}
+			orphans[pvc.namespace] = o
+		}
+		o.names = append(o.names, fmt.Sprintf("%s (%s)", pvc.name, reason))
+		o.size.Add(pvc.size)
+	}
+	var findings []Finding
+	for ns, o := range orphans {
+		sort.Strings(o.names)
+		names := o.names
+		if len(names) > maxOrphanedPVCNames {
+			names = append(names[:maxOrphanedPVCNames:maxOrphanedPVCNames], "...")
+		}
+		findings = append(findings, Finding{
+			Version:            "v1",
+			Resource:           "namespaces",
+			Kind:               "Namespace",
+			Name:               ns,
+			ConditionType:      "OrphanedPVCs",
+			ConditionStatus:    "True",
+			ConditionReason:    "StatefulSetPVCsNotUsed",
+			ConditionMessage:   fmt.Sprintf("%d PVCs with %s could be reclaimed: %s", len(o.names), o.size.String(), strings.Join(names, ", ")),
+			Severity:           SeverityWarning,
+			MessageFingerprint: "<n> PVCs with <size> could be reclaimed",
+			Code:               CodePVCOrphaned,
+			Check:              checkOrphanedPVCs,
+		})
+	}
+	return findings
+}
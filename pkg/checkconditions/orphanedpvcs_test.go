@@ -0,0 +1,103 @@
+package checkconditions
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestOrphanedPVCReason(t *testing.T) {
+	statefulSets := []statefulSetInfo{
+		{namespace: "db", name: "pg", replicas: 3, templates: []string{"data"}},
+		{namespace: "db", name: "kafka", replicas: 2, ordinalStart: 5, templates: []string{"logs", "data"}},
+	}
+	tests := []struct {
+		name string
+		pvc  string
+		used bool
+		want string
+	}{
+		{name: "within the replicas", pvc: "data-pg-2"},
+		{name: "scaled down", pvc: "data-pg-3", want: "ordinal 3 >= replicas 3 of StatefulSet pg"},
+		{name: "scaled down and used", pvc: "data-pg-3", used: true},
+		{name: "within ordinals.start", pvc: "logs-kafka-6"},
+		{name: "above ordinals.start", pvc: "logs-kafka-7", want: "ordinal 7 >= ordinals.start + replicas 7 of StatefulSet kafka"},
+		{name: "below ordinals.start", pvc: "data-kafka-4", want: "ordinal 4 < ordinals.start 5 of StatefulSet kafka"},
+		{name: "deleted StatefulSet", pvc: "data-redis-0", want: "no StatefulSet, not used by a pod"},
+		{name: "deleted StatefulSet, used", pvc: "data-redis-0", used: true},
+		{name: "leading zero is no ordinal", pvc: "data-pg-01", want: "no StatefulSet, not used by a pod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pvc := pvcInfo{namespace: "db", name: tt.pvc}
+			used := map[string]bool{"db/" + tt.pvc: tt.used}
+			if got := orphanedPVCReason(&pvc, statefulSets, used); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrphanedPVCs(t *testing.T) {
+	pvcGVR := schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	pvc := func(name, size string, owned bool) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"namespace": "db", "name": name},
+			"spec":     map[string]interface{}{"resources": map[string]interface{}{"requests": map[string]interface{}{"storage": size}}},
+		}}
+		if owned {
+			obj.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Foo", Name: "x"}})
+		}
+		return obj
+	}
+	output := &handleResourceTypeOutput{}
+	args := &Arguments{}
+	for _, obj := range []*unstructured.Unstructured{
+		pvc("data-pg-0", "10Gi", false),
+		pvc("data-pg-3", "10Gi", false),
+		pvc("data-redis-0", "20Gi", false),
+		pvc("data-owned-0", "20Gi", true),
+		pvc("scratch", "5Gi", false),
+		pvc("cache-1", "5Gi", false),
+	} {
+		collectStatefulSetPVCs(args, pvcGVR, obj, output)
+	}
+	sts := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"namespace": "db", "name": "pg"},
+		"spec": map[string]interface{}{
+			"replicas":             int64(1),
+			"volumeClaimTemplates": []interface{}{map[string]interface{}{"metadata": map[string]interface{}{"name": "data"}}},
+		},
+	}}
+	collectStatefulSetPVCs(args, schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, sts, output)
+	if len(output.pvcs) != 3 {
+		t.Fatalf("got %d PVCs named like PVCs of StatefulSets without owner, want 3: %v", len(output.pvcs), output.pvcs)
+	}
+
+	counter := &Counter{
+		listedKinds:  map[schema.GroupKind]bool{statefulSetKind: true, podKind: true, pvcKind: true},
+		statefulSets: output.statefulSets,
+		pvcs:         output.pvcs,
+	}
+	findings := orphanedPVCs(args, counter)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	for _, want := range []string{"2 PVCs with 30Gi", "data-pg-3 (ordinal 3 >= replicas 1 of StatefulSet pg)", "data-redis-0 (no StatefulSet"} {
+		if !strings.Contains(f.ConditionMessage, want) {
+			t.Errorf("message %q does not contain %q", f.ConditionMessage, want)
+		}
+	}
+	if f.Name != "db" || f.Check != checkOrphanedPVCs {
+		t.Errorf("unexpected finding %+v", f)
+	}
+
+	counter.listedKinds[podKind] = false
+	if findings := orphanedPVCs(args, counter); len(findings) != 0 {
+		t.Errorf("got findings without a complete list of pods: %v", findings)
+	}
+}
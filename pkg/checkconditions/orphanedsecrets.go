@@ -0,0 +1,198 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// largeUnownedSecretSize is the minimum size of unowned Secrets which get reported, if their
+// name matches Arguments.UnownedSecretPatterns.
+const largeUnownedSecretSize = 64 * 1024
+
+const (
+	helmReleaseType = "helm.sh/release.v1"
+	helmReleaseName = "meta.helm.sh/release-name"
+	helmReleaseNS   = "meta.helm.sh/release-namespace"
+	certificateName = "cert-manager.io/certificate-name"
+)
+
+var (
+	// Helm stores releases in Secrets of type helm.sh/release.v1 (default), or in ConfigMaps with
+	// the label owner=helm (HELM_DRIVER=configmap). Both have the label name=RELEASE.
+	helmReleaseKind = schema.GroupKind{Group: "helm.sh", Kind: "Release"}
+	certificateKind = schema.GroupKind{Group: "cert-manager.io", Kind: "Certificate"}
+	configMapKind   = schema.GroupKind{Kind: "ConfigMap"}
+	secretKind      = schema.GroupKind{Kind: "Secret"}
+)
+
+// helmReleaseStorage returns true if the Secret or ConfigMap stores a Helm release.
+func helmReleaseStorage(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) bool {
+	if gvr.Group != "" {
+		return false
+	}
+	switch gvr.Resource {
+	case "secrets":
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return secretType == helmReleaseType
+	case "configmaps":
+		labels := obj.GetLabels()
+		return labels["owner"] == "helm" && labels["name"] != ""
+	}
+	return false
+}
+
+// orphanCandidate is a Secret or ConfigMap which belongs to a Helm release or a Certificate.
+// After the scan it gets checked whether the owner still exists.
+type orphanCandidate struct {
+	gvr       schema.GroupVersionResource
+	kind      string
+	namespace string
+	name      string
+	uid       types.UID
+
+	// ownerKind is helmReleaseKind or certificateKind. owner is "namespace/name".
+	ownerKind schema.GroupKind
+	owner     string
+}
+
+// collectOrphanedSecrets records Helm releases, Certificates, and Secrets and ConfigMaps which
+// belong to them. Large unowned Secrets matching Arguments.UnownedSecretPatterns get reported directly.
+func collectOrphanedSecrets(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	if gvr.Group == certificateKind.Group && gvr.Resource == "certificates" {
+		output.orphanOwners = append(output.orphanOwners, certificateKind.String()+" "+obj.GetNamespace()+"/"+obj.GetName())
+		return nil
+	}
+	if gvr.Group != "" || (gvr.Resource != "secrets" && gvr.Resource != "configmaps") {
+		return nil
+	}
+	if helmReleaseStorage(gvr, obj) {
+		output.orphanOwners = append(output.orphanOwners,
+			helmReleaseKind.String()+" "+obj.GetNamespace()+"/"+obj.GetLabels()["name"])
+		return nil
+	}
+	candidate := orphanCandidate{
+		gvr:       gvr,
+		kind:      obj.GetKind(),
+		namespace: obj.GetNamespace(),
+		name:      obj.GetName(),
+		uid:       obj.GetUID(),
+	}
+	annotations := obj.GetAnnotations()
+	switch {
+	case annotations[helmReleaseName] != "":
+		ns := annotations[helmReleaseNS]
+		if ns == "" {
+			ns = obj.GetNamespace()
+		}
+		candidate.ownerKind = helmReleaseKind
+		candidate.owner = ns + "/" + annotations[helmReleaseName]
+	case annotations[certificateName] != "":
+		candidate.ownerKind = certificateKind
+		candidate.owner = obj.GetNamespace() + "/" + annotations[certificateName]
+	default:
+		if gvr.Resource == "secrets" && len(obj.GetOwnerReferences()) == 0 {
+			return largeUnownedSecret(args, gvr, obj)
+		}
+		return nil
+	}
+	output.orphanCandidates = append(output.orphanCandidates, candidate)
+	return nil
+}
+
+func largeUnownedSecret(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) []Finding {
+	matched := false
+	for _, pattern := range args.UnownedSecretPatterns {
+		if ok, _ := path.Match(pattern, obj.GetName()); ok {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return nil
+	}
+	data, err := json.Marshal(obj.Object)
+	if err != nil || len(data) < largeUnownedSecretSize {
+		return nil
+	}
+	return []Finding{{
+		Namespace:          obj.GetNamespace(),
+		Group:              gvr.Group,
+		Version:            gvr.Version,
+		Resource:           gvr.Resource,
+		Kind:               obj.GetKind(),
+		Name:               obj.GetName(),
+		UID:                obj.GetUID(),
+		ConditionType:      "Owner",
+		ConditionStatus:    "Missing",
+		ConditionReason:    "Unowned",
+		ConditionMessage:   fmt.Sprintf("secret has no owner and %d KiB", len(data)/1024),
+		Severity:           SeverityWarning,
+		MessageFingerprint: "secret has no owner and <n> KiB",
+		Code:               CodeSecretOrphaned,
+		Check:              checkOrphanedSecrets,
+	}}
+}
+
+// orphanedSecrets reports Secrets and ConfigMaps of Helm releases and Certificates which do not exist.
+// Owners are only checked if their resource type was listed completely. Helm releases are only
+// checked if Secrets and ConfigMaps were listed completely, and if the storage of at least one
+// release was found. Otherwise Helm might use another storage driver (SQL). With a label selector
+// the owners might not be listed, so nothing gets checked.
+func orphanedSecrets(args *Arguments, counter *Counter) []Finding {
+	if args.LabelSelector != "" {
+		return nil
+	}
+	owners := make(map[string]bool, len(counter.orphanOwners))
+	helmReleases := false
+	for _, o := range counter.orphanOwners {
+		owners[o] = true
+		if strings.HasPrefix(o, helmReleaseKind.String()+" ") {
+			helmReleases = true
+		}
+	}
+	listed := map[schema.GroupKind]bool{
+		certificateKind: counter.listedKinds[certificateKind],
+		helmReleaseKind: helmReleases && counter.listedKinds[secretKind] && counter.listedKinds[configMapKind],
+	}
+	var findings []Finding
+	for i := range counter.orphanCandidates {
+		c := &counter.orphanCandidates[i]
+		if !listed[c.ownerKind] || owners[c.ownerKind.String()+" "+c.owner] {
+			continue
+		}
+		ownerNamespace, _, _ := strings.Cut(c.owner, "/")
+		if !args.namespaceInScope(ownerNamespace) {
+			continue
+		}
+		reason, what := "HelmReleaseNotFound", "Helm release"
+		if c.ownerKind == certificateKind {
+			reason, what = "CertificateNotFound", "Certificate"
+		}
+		findings = append(findings, Finding{
+			Namespace:          c.namespace,
+			Group:              c.gvr.Group,
+			Version:            c.gvr.Version,
+			Resource:           c.gvr.Resource,
+			Kind:               c.kind,
+			Name:               c.name,
+			UID:                c.uid,
+			ConditionType:      "Owner",
+			ConditionStatus:    "Missing",
+			ConditionReason:    reason,
+			ConditionMessage:   fmt.Sprintf("%s %s does not exist", what, c.owner),
+			Severity:           SeverityWarning,
+			MessageFingerprint: fmt.Sprintf("%s <name> does not exist", what),
+			Code:               CodeSecretOrphaned,
+			Check:              checkOrphanedSecrets,
+		})
+	}
+	return findings
+}
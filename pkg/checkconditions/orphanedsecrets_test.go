@@ -0,0 +1,91 @@
+package checkconditions
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestOrphanedSecrets(t *testing.T) {
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	certificates := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	object := func(kind, name string, annotations, labels map[string]string, fields map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": kind}}
+		for k, v := range fields {
+			obj.Object[k] = v
+		}
+		obj.SetNamespace("a")
+		obj.SetName(name)
+		obj.SetAnnotations(annotations)
+		obj.SetLabels(labels)
+		return obj
+	}
+	helm := func(release string) map[string]string {
+		return map[string]string{helmReleaseName: release, helmReleaseNS: "a"}
+	}
+	certificate := func(name string) map[string]string {
+		return map[string]string{certificateName: name}
+	}
+	large := map[string]interface{}{"data": map[string]interface{}{"dump": strings.Repeat("x", 2*largeUnownedSecretSize)}}
+	args := &Arguments{UnownedSecretPatterns: []string{"backup-*"}}
+	output := &handleResourceTypeOutput{}
+	var direct []Finding
+	for _, o := range []struct {
+		gvr schema.GroupVersionResource
+		obj *unstructured.Unstructured
+	}{
+		{secrets, object("Secret", "sh.helm.release.v1.web.v1", nil, map[string]string{"name": "web"},
+			map[string]interface{}{"type": helmReleaseType})},
+		{certificates, object("Certificate", "tls", nil, nil, nil)},
+		{secrets, object("Secret", "web-config", helm("web"), nil, nil)},
+		{configMaps, object("ConfigMap", "shop-config", helm("shop"), nil, nil)},
+		{secrets, object("Secret", "tls", certificate("tls"), nil, nil)},
+		{secrets, object("Secret", "old-tls", certificate("old"), nil, nil)},
+		{secrets, object("Secret", "backup-2026", nil, nil, large)},
+		{secrets, object("Secret", "dump", nil, nil, large)},
+		{secrets, object("Secret", "backup-small", nil, nil, nil)},
+	} {
+		direct = append(direct, collectOrphanedSecrets(args, o.gvr, o.obj, output)...)
+	}
+	if len(direct) != 1 || direct[0].Name != "backup-2026" || direct[0].ConditionReason != "Unowned" {
+		t.Errorf("unexpected findings of large unowned secrets %v", direct)
+	}
+
+	listedKinds := map[schema.GroupKind]bool{secretKind: true, configMapKind: true, certificateKind: true}
+	counter := &Counter{orphanOwners: output.orphanOwners, orphanCandidates: output.orphanCandidates, listedKinds: listedKinds}
+	got := make(map[string]string)
+	for _, f := range orphanedSecrets(args, counter) {
+		if f.Code != CodeSecretOrphaned || f.Check != checkOrphanedSecrets {
+			t.Errorf("unexpected code or check %+v", f)
+		}
+		got[f.Kind+" "+f.Name] = f.ConditionReason + ": " + f.ConditionMessage
+	}
+	want := map[string]string{
+		"ConfigMap shop-config": "HelmReleaseNotFound: Helm release a/shop does not exist",
+		"Secret old-tls":        "CertificateNotFound: Certificate a/old does not exist",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for k, w := range want {
+		if got[k] != w {
+			t.Errorf("%s: got %q, want %q", k, got[k], w)
+		}
+	}
+
+	// Owners of resource types which were not listed completely do not get checked.
+	counter.listedKinds = map[schema.GroupKind]bool{secretKind: true}
+	if findings := orphanedSecrets(args, counter); len(findings) != 0 {
+		t.Errorf("got findings without listing Certificates and ConfigMaps: %v", findings)
+	}
+	counter.listedKinds = listedKinds
+	if findings := orphanedSecrets(&Arguments{LabelSelector: "app=web"}, counter); len(findings) != 0 {
+		t.Errorf("got findings with a label selector: %v", findings)
+	}
+	if findings := orphanedSecrets(&Arguments{Namespace: "b"}, counter); len(findings) != 0 {
+		t.Errorf("got findings of owners outside of the namespace: %v", findings)
+	}
+}
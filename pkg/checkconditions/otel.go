@@ -0,0 +1,184 @@
+package checkconditions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// resourceTypeSpan is the work of a worker on one resource type: listing and checking the objects.
+type resourceTypeSpan struct {
+	gvr        schema.GroupVersionResource
+	workerID   int32
+	start      time.Time
+	end        time.Time
+	objects    int32
+	conditions int32
+	findings   int
+	err        string
+}
+
+// otlpEndpoint returns --otlp-endpoint, or the environment variable of the OpenTelemetry SDKs.
+func (args *Arguments) otlpEndpoint() string {
+	if args.NoWrite {
+		// OTEL_EXPORTER_OTLP_ENDPOINT is ignored. --otlp-endpoint is refused, see validateNoWrite.
+		return ""
+	}
+	if args.OTLPEndpoint != "" {
+		return args.OTLPEndpoint
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+// exportOTLP sends the scan as trace (one span per resource type, below a span of the whole scan)
+// and metrics via OTLP/HTTP with JSON encoding. Headers are read from OTEL_EXPORTER_OTLP_HEADERS.
+func exportOTLP(args *Arguments, counter *Counter, now time.Time) error {
+	endpoint := strings.TrimSuffix(args.otlpEndpoint(), "/")
+	header := make(http.Header)
+	for _, h := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if k, v, ok := strings.Cut(h, "="); ok {
+			header.Set(strings.TrimSpace(k), strings.TrimSpace(v))
+		}
+	}
+	resource := map[string]interface{}{
+		"attributes": []map[string]interface{}{
+			otlpAttribute("service.name", "check-conditions"),
+			otlpAttribute("service.version", newBuildMetadata().Version),
+		},
+	}
+	scope := map[string]interface{}{"name": "github.com/guettli/check-conditions"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	traces := map[string]interface{}{
+		"resourceSpans": []map[string]interface{}{{
+			"resource":   resource,
+			"scopeSpans": []map[string]interface{}{{"scope": scope, "spans": otlpSpans(counter, now)}},
+		}},
+	}
+	if err := doJSON(ctx, http.MethodPost, endpoint+"/v1/traces", header, traces, nil); err != nil {
+		return fmt.Errorf("exporting OTLP traces failed: %w", err)
+	}
+	metrics := map[string]interface{}{
+		"resourceMetrics": []map[string]interface{}{{
+			"resource":     resource,
+			"scopeMetrics": []map[string]interface{}{{"scope": scope, "metrics": otlpMetrics(counter, now)}},
+		}},
+	}
+	if err := doJSON(ctx, http.MethodPost, endpoint+"/v1/metrics", header, metrics, nil); err != nil {
+		return fmt.Errorf("exporting OTLP metrics failed: %w", err)
+	}
+	return nil
+}
+
+func otlpSpans(counter *Counter, now time.Time) []map[string]interface{} {
+	traceID := randomHex(16) //nolint:gomnd
+	rootID := randomHex(8)   //nolint:gomnd
+	spans := []map[string]interface{}{{
+		"traceId":           traceID,
+		"spanId":            rootID,
+		"name":              "scan",
+		"kind":              1, // internal
+		"startTimeUnixNano": otlpTime(counter.startTime),
+		"endTimeUnixNano":   otlpTime(now),
+		"attributes": []map[string]interface{}{
+			otlpIntAttribute("check_conditions.resource_types", int64(counter.checkedResourceTypes)),
+			otlpIntAttribute("check_conditions.resources", int64(counter.checkedResources)),
+			otlpIntAttribute("check_conditions.findings", int64(len(counter.findings))),
+		},
+	}}
+	for _, s := range counter.spans {
+		span := map[string]interface{}{
+			"traceId":           traceID,
+			"spanId":            randomHex(8), //nolint:gomnd
+			"parentSpanId":      rootID,
+			"name":              "check " + schema.GroupResource{Group: s.gvr.Group, Resource: s.gvr.Resource}.String(),
+			"kind":              3, // client
+			"startTimeUnixNano": otlpTime(s.start),
+			"endTimeUnixNano":   otlpTime(s.end),
+			"attributes": []map[string]interface{}{
+				otlpAttribute("k8s.group", s.gvr.Group),
+				otlpAttribute("k8s.version", s.gvr.Version),
+				otlpAttribute("k8s.resource", s.gvr.Resource),
+				otlpIntAttribute("check_conditions.worker", int64(s.workerID)),
+				otlpIntAttribute("check_conditions.resources", int64(s.objects)),
+				otlpIntAttribute("check_conditions.findings", int64(s.findings)),
+			},
+		}
+		if s.err != "" {
+			span["status"] = map[string]interface{}{"code": 2, "message": s.err} // error
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+func otlpMetrics(counter *Counter, now time.Time) []map[string]interface{} {
+	ts := otlpTime(now)
+	gauge := func(name, unit, description string, points []map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"name":        name,
+			"unit":        unit,
+			"description": description,
+			"gauge":       map[string]interface{}{"dataPoints": points},
+		}
+	}
+	point := func(value float64, attributes ...map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"timeUnixNano": ts, "asDouble": value, "attributes": attributes}
+	}
+	bySeverity := make(map[string]int)
+	for i := range counter.findings {
+		bySeverity[counter.findings[i].Severity]++
+	}
+	var findingPoints []map[string]interface{}
+	for _, severity := range []string{SeverityCritical, SeverityWarning} {
+		findingPoints = append(findingPoints, point(float64(bySeverity[severity]), otlpAttribute("severity", severity)))
+	}
+	durations := make([]map[string]interface{}, 0, len(counter.spans))
+	spans := append([]resourceTypeSpan(nil), counter.spans...)
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].gvr.String() < spans[j].gvr.String()
+	})
+	for _, s := range spans {
+		durations = append(durations, point(s.end.Sub(s.start).Seconds(),
+			otlpAttribute("k8s.group", s.gvr.Group), otlpAttribute("k8s.resource", s.gvr.Resource)))
+	}
+	return []map[string]interface{}{
+		gauge("check_conditions.findings", "{finding}", "Number of conditions which need attention.", findingPoints),
+		gauge("check_conditions.scan.duration", "s", "Duration of the scan.",
+			[]map[string]interface{}{point(now.Sub(counter.startTime).Seconds())}),
+		gauge("check_conditions.resource_type.duration", "s", "Time for listing and checking a resource type.", durations),
+		gauge("check_conditions.resources", "{resource}", "Number of checked resource objects.",
+			[]map[string]interface{}{point(float64(counter.checkedResources))}),
+		gauge("check_conditions.health_score", "1", "Health score between 0 (very bad) and 100 (no findings).",
+			[]map[string]interface{}{point(float64(counter.score))}),
+	}
+}
+
+func otlpAttribute(key, value string) map[string]interface{} {
+	return map[string]interface{}{"key": key, "value": map[string]interface{}{"stringValue": value}}
+}
+
+func otlpIntAttribute(key string, value int64) map[string]interface{} {
+	// int64 values are strings in the JSON encoding of OTLP.
+	return map[string]interface{}{"key": key, "value": map[string]interface{}{"intValue": strconv.FormatInt(value, 10)}}
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,383 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// OutputText prints one line per finding. This is the default.
+	OutputText = "text"
+
+	// OutputWide prints one line per finding like text, with the age of the object and the
+	// lastTransitionTime of the condition.
+	OutputWide = "wide"
+
+	// OutputJSON prints the result as one JSON object. This is the default of --output-file.
+	OutputJSON = "json"
+
+	// OutputNDJSON prints one finding per line as JSON, as soon as a worker found it.
+	OutputNDJSON = "ndjson"
+
+	// OutputYAML prints the result as one YAML document, for example as snapshot in a GitOps repo.
+	OutputYAML = "yaml"
+
+	// OutputJUnit prints the result as JUnit XML for CI pipelines. Each resource type is a test suite.
+	OutputJUnit = "junit"
+
+	// OutputSARIF prints the findings as SARIF 2.1.0, for example for GitHub code scanning.
+	OutputSARIF = "sarif"
+
+	// OutputCSV prints one line per finding, for import into spreadsheets.
+	OutputCSV = "csv"
+
+	// OutputMarkdown prints a table of findings per namespace and the totals, for GitHub issues.
+	OutputMarkdown = "markdown"
+
+	// OutputGitHub prints the findings as workflow commands, which GitHub Actions shows as annotations.
+	OutputGitHub = "github"
+
+	// OutputPrometheus prints the metrics in the Prometheus text format, like /metrics of "serve".
+	OutputPrometheus = "prometheus"
+)
+
+// OutputValues contains the valid values of Arguments.Output.
+var OutputValues = []string{
+	OutputText, OutputWide, OutputJSON, OutputNDJSON, OutputYAML, OutputJUnit, OutputSARIF, OutputCSV, OutputMarkdown,
+	OutputGitHub, OutputPrometheus,
+}
+
+// textOutput returns true if the result gets printed as text.
+func (args *Arguments) textOutput() bool {
+	return args.Output == "" || args.Output == OutputText || args.Output == OutputWide
+}
+
+// fileFormat returns the format of --output-file: the format of --output, or json.
+func (args *Arguments) fileFormat() string {
+	if args.OutputFileFormat != "" {
+		return args.OutputFileFormat
+	}
+	if args.textOutput() {
+		return OutputJSON
+	}
+	return args.Output
+}
+
+// writeReport writes the report in the structured format.
+func writeReport(w io.Writer, format string, r *report) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case OutputNDJSON:
+		enc := json.NewEncoder(w)
+		for i := range r.Findings {
+			if err := enc.Encode(&r.Findings[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputYAML:
+		data, err := yaml.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case OutputJUnit:
+		return writeJUnit(w, r)
+	case OutputSARIF:
+		return writeSARIF(w, r)
+	case OutputCSV:
+		return writeCSV(w, r)
+	case OutputMarkdown:
+		return writeMarkdown(w, r)
+	case OutputGitHub:
+		return writeGitHub(w, r)
+	case OutputPrometheus:
+		return writePrometheus(w, r)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+const (
+	// GroupByOwner groups the findings by the top-level owner of the resource objects.
+	GroupByOwner = "owner"
+
+	// GroupByTeam groups the findings by the team of the namespace. See Config.Teams.
+	GroupByTeam = "team"
+
+	// GroupByMessage groups the findings by kind, condition, reason and fingerprint of the message.
+	// Messages which only differ in UUIDs, timestamps, IPs or numbers are in the same group.
+	GroupByMessage = "message"
+
+	// GroupByZone groups the findings of pods and nodes by the zone of the node.
+	GroupByZone = "zone"
+
+	// GroupByNodePool groups the findings of pods and nodes by the node pool (or instance group) of the node.
+	GroupByNodePool = "nodepool"
+
+	// GroupByNamespace groups the findings by namespace. Cluster-scoped resources are a group, too.
+	GroupByNamespace = "namespace"
+
+	// GroupByResource groups the findings by resource type: "deployments.apps".
+	GroupByResource = "resource"
+)
+
+// GroupByValues contains the valid values of Arguments.GroupBy.
+var GroupByValues = []string{
+	GroupByOwner, GroupByTeam, GroupByMessage, GroupByZone, GroupByNodePool, GroupByNamespace,
+	GroupByResource,
+}
+
+// reportWriter returns Arguments.stdout, and diagnostics returns Arguments.stderr. They default
+// to os.Stdout and os.Stderr.
+func (args *Arguments) reportWriter() io.Writer {
+	if args.stdout == nil {
+		return os.Stdout
+	}
+	return args.stdout
+}
+
+func (args *Arguments) diagnostics() io.Writer {
+	if args.stderr == nil {
+		return os.Stderr
+	}
+	return args.stderr
+}
+
+// verboseLog returns the writer for the messages of --verbose, or nil without --verbose.
+func (args *Arguments) verboseLog() io.Writer {
+	if !args.Verbose {
+		return nil
+	}
+	return args.diagnostics()
+}
+
+// structuredStdout returns true if the structured output (--output json, ...) goes to stdout.
+func (args *Arguments) structuredStdout() bool {
+	return !args.textOutput() && args.OutputFile == ""
+}
+
+// streamFindings prints the findings as JSON lines, if Arguments.Output is ndjson. The filters of
+// the other outputs get applied per finding: findings within the grace period and of other teams
+// are not printed, maintenance windows and ephemeral namespaces get marked (or excluded). The
+// namespaces were fetched before the scan, see fetchNamespaceMetas. Sorting is not possible.
+func streamFindings(args *Arguments, findings []Finding) {
+	if args.Output != OutputNDJSON || !args.structuredStdout() {
+		return
+	}
+	now := time.Now()
+	var maintenance []*MaintenanceWindowConfig
+	for i := range args.Config.MaintenanceWindows {
+		if args.Config.MaintenanceWindows[i].active(now) {
+			maintenance = append(maintenance, &args.Config.MaintenanceWindows[i])
+		}
+	}
+	enc := json.NewEncoder(args.reportWriter())
+	for i := range findings {
+		f := findings[i]
+		if args.GracePeriod > 0 && !f.LastTransitionTime.IsZero() && now.Sub(f.LastTransitionTime) < args.GracePeriod {
+			continue
+		}
+		if args.Team != "" && teamOf(args.Config, args.streamNamespaces, f.Namespace) != args.Team {
+			continue
+		}
+		for _, w := range maintenance {
+			if w.covers(f.Namespace, args.streamNamespaces) {
+				f.Maintenance = w.Name
+				break
+			}
+		}
+		if e := args.Config.Ephemeral; e != nil && e.matches(f.Namespace, args.streamNamespaces) {
+			if e.Mode == EphemeralExclude {
+				continue
+			}
+			f.Ephemeral = true
+		}
+		if args.history != nil {
+			args.history.projectSeen(&f, now)
+		}
+		if err := enc.Encode(&f); err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: writing finding failed: %s\n", err.Error())
+			return
+		}
+	}
+}
+
+// needsNamespaceMetas returns true if the streamed findings need the labels and annotations of
+// the namespaces: for teams, maintenance windows and ephemeral namespaces.
+func (args *Arguments) needsNamespaceMetas() bool {
+	return args.Team != "" || len(args.Config.MaintenanceWindows) > 0 || args.Config.Ephemeral != nil
+}
+
+// printFindings prints the findings sorted, and grouped if Arguments.GroupBy is set.
+func printFindings(w io.Writer, args *Arguments, findings []Finding, counter *Counter) {
+	switch args.GroupBy {
+	case GroupByOwner:
+		printFindingsGrouped(w, args, findings, counter, func(f *Finding) string {
+			if root, ok := counter.owners.root(f.UID); ok {
+				return root.String()
+			}
+			return f.Namespace + " " + f.Resource + " " + f.Name
+		})
+		return
+	case GroupByTeam:
+		printFindingsGrouped(w, args, findings, counter, func(f *Finding) string {
+			return teamOf(args.Config, counter.namespaces, f.Namespace)
+		})
+		return
+	case GroupByMessage:
+		printFindingsGrouped(w, args, findings, counter, func(f *Finding) string {
+			return fmt.Sprintf("%s %s=%s %s %q", f.Kind, f.ConditionType, f.ConditionStatus, f.ConditionReason, f.MessageFingerprint)
+		})
+		return
+	case GroupByZone:
+		printFindingsGrouped(w, args, findings, counter, func(f *Finding) string {
+			return "zone " + valueOr(f.Zone, "unknown")
+		})
+		return
+	case GroupByNodePool:
+		printFindingsGrouped(w, args, findings, counter, func(f *Finding) string {
+			return "nodepool " + valueOr(f.NodePool, "unknown")
+		})
+		return
+	case GroupByNamespace:
+		printFindingsGrouped(w, args, findings, counter, func(f *Finding) string {
+			return "namespace " + valueOr(f.Namespace, "(cluster-scoped)")
+		})
+		return
+	case GroupByResource:
+		printFindingsGrouped(w, args, findings, counter, func(f *Finding) string {
+			return schema.GroupResource{Group: f.Group, Resource: f.Resource}.String()
+		})
+		return
+	}
+	for _, line := range findingsLines(args, findings, counter.owners, "") {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// valueOr returns s, or def if s is empty.
+func valueOr(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+func printFindingsGrouped(w io.Writer, args *Arguments, findings []Finding, counter *Counter, groupKey func(f *Finding) string) {
+	groups := make(map[string][]Finding)
+	for i := range findings {
+		key := groupKey(&findings[i])
+		groups[key] = append(groups[key], findings[i])
+	}
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %s (%d)\n", key, len(groups[key]))
+		for _, line := range findingsLines(args, groups[key], counter.owners, "  ") {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// findingsLines returns the output lines of the findings, sorted by Arguments.SortBy. If Arguments.OwnerChain is set,
+// each finding is followed by the chain of its owners, and then by its Events (--attach-events).
+// With Arguments.Dedup see dedupLines.
+func findingsLines(args *Arguments, findings []Finding, owners ownerIndex, indent string) []string {
+	if args.Dedup {
+		return dedupLines(args, findings, owners, indent)
+	}
+	sorted := make([]Finding, len(findings))
+	copy(sorted, findings)
+	sortFindings(sorted, args.SortBy)
+	now := time.Now()
+	lines := make([]string, 0, len(sorted))
+	for i := range sorted {
+		f := &sorted[i]
+		line := indent + f.text(args.color)
+		if args.Output == OutputWide {
+			line += f.wideColumns(now)
+		}
+		lines = append(lines, line)
+		if chain := owners.chain(f.UID); args.OwnerChain && len(chain) >= 2 {
+			parts := make([]string, 0, len(chain))
+			for _, node := range chain {
+				parts = append(parts, node.Kind+" "+node.Name)
+			}
+			lines = append(lines, indent+"    owners: "+strings.Join(parts, " ← "))
+		}
+		lines = append(lines, eventLines(f, indent, now)...)
+	}
+	return lines
+}
+
+// printSections prints the result in sections with counts in the headers: Critical, Warning,
+// Info (resolved findings), Suppressed (pending findings and findings in maintenance windows) and
+// Scan Errors (including skipped resource types).
+func printSections(w io.Writer, args *Arguments, findings []Finding, resolved []string, counter *Counter) {
+	var critical, warning, suppressed []Finding
+	for i := range findings {
+		switch {
+		case findings[i].Maintenance != "":
+			suppressed = append(suppressed, findings[i])
+		case findings[i].Severity == SeverityCritical:
+			critical = append(critical, findings[i])
+		default:
+			warning = append(warning, findings[i])
+		}
+	}
+	suppressed = append(suppressed, counter.pending...)
+	fmt.Fprintf(w, "== Critical (%d)\n", len(critical))
+	printFindings(w, args, critical, counter)
+	fmt.Fprintf(w, "== Warning (%d)\n", len(warning))
+	printFindings(w, args, warning, counter)
+	fmt.Fprintf(w, "== Info (%d)\n", len(resolved))
+	for _, line := range resolved {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "== Suppressed (%d)\n", len(suppressed))
+	for _, line := range findingsLines(args, suppressed, counter.owners, "") {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "== Scan Errors (%d)\n", len(counter.errors)+len(counter.skipped))
+	for i := range counter.errors {
+		e := &counter.errors[i]
+		fmt.Fprintf(w, "  %s %s %s %s: %s\n", e.Type, e.Resource, e.Group, e.Version, e.Message)
+	}
+	printSkipped(w, counter.skipped)
+}
+
+// printPerType prints a table with the objects, conditions, findings and the duration per
+// resource type. The slowest resource types come first.
+func printPerType(out io.Writer, spans []resourceTypeSpan) {
+	spans = append([]resourceTypeSpan(nil), spans...)
+	sort.Slice(spans, func(i, j int) bool {
+		di, dj := spans[i].end.Sub(spans[i].start), spans[j].end.Sub(spans[j].start)
+		if di != dj {
+			return di > dj
+		}
+		return spans[i].gvr.String() < spans[j].gvr.String()
+	})
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:gomnd
+	fmt.Fprintln(w, "RESOURCE\tOBJECTS\tCONDITIONS\tFINDINGS\tDURATION")
+	for _, s := range spans {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", schema.GroupResource{Group: s.gvr.Group, Resource: s.gvr.Resource}.String(),
+			s.objects, s.conditions, s.findings, s.end.Sub(s.start).Round(time.Millisecond))
+	}
+	w.Flush()
+}
@@ -0,0 +1,292 @@
+package checkconditions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// testReport returns a report with a critical finding of a pod, a warning of a node, a skipped
+// resource type and a scan error.
+func testReport() *report {
+	scanTime := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	counter := &Counter{
+		startTime:            scanTime,
+		checkedResourceTypes: 3,
+		checkedResources:     10,
+		checkedConditions:    20,
+		findings: []Finding{
+			{
+				Namespace: "shop", Version: "v1", Resource: "pods", Kind: "Pod", Name: "web-1", UID: "uid-web-1",
+				ConditionType: "Ready", ConditionStatus: "False", ConditionReason: "ContainersNotReady",
+				ConditionMessage: "containers with unready status: [web]", Severity: SeverityCritical,
+				LastTransitionTime: scanTime.Add(-time.Hour), Code: "CONDITION_NEGATIVE", Check: checkConditions,
+			},
+			{
+				Version: "v1", Resource: "nodes", Kind: "Node", Name: "node-1", UID: "uid-node-1",
+				ConditionType: "MemoryPressure", ConditionStatus: "True", ConditionReason: "KubeletHasInsufficientMemory",
+				ConditionMessage: "kubelet has insufficient memory available", Severity: SeverityWarning,
+				Code: "CONDITION_NEGATIVE", Check: checkConditions,
+			},
+		},
+		skipped: []skippedResourceType{{
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, reason: skipReasonMaxDuration,
+		}},
+		errors: []scanError{{Time: scanTime, Type: "forbidden", Group: "batch", Version: "v1", Resource: "jobs", Message: "jobs is forbidden"}},
+	}
+	return newReport(&Arguments{Config: &Config{}}, counter, counter.findings)
+}
+
+func TestWriteReportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, OutputJSON, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		ScanTime          time.Time       `json:"scanTime"`
+		CheckedConditions int32           `json:"checkedConditions"`
+		Findings          []Finding       `json:"findings"`
+		Pending           []Finding       `json:"pending"`
+		Skipped           []reportSkipped `json:"skipped"`
+		Errors            []scanError     `json:"errors"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got.CheckedConditions != 20 || len(got.Findings) != 2 || len(got.Skipped) != 1 || len(got.Errors) != 1 {
+		t.Fatalf("unexpected report: %+v", got)
+	}
+	if got.Pending == nil || !strings.Contains(buf.String(), `"pending": []`) {
+		t.Errorf("pending is not an empty list: %s", buf.String())
+	}
+	if f := got.Findings[0]; f.ID() != "/pods/shop/web-1/Ready" || f.Severity != SeverityCritical {
+		t.Errorf("unexpected finding %+v", f)
+	}
+}
+
+// failingSink fails to send the findings.
+type failingSink struct{}
+
+func (failingSink) name() string { return "failing" }
+
+func (failingSink) send(context.Context, *Arguments, *Counter) error {
+	return errors.New("unreachable")
+}
+
+func TestReportAndDiagnosticsWriters(t *testing.T) {
+	for _, tt := range []struct {
+		args       Arguments
+		structured bool
+	}{
+		{args: Arguments{}},
+		{args: Arguments{Output: OutputWide}},
+		{args: Arguments{Output: OutputJSON}, structured: true},
+		{args: Arguments{Output: OutputJSON, OutputFile: "report.json"}},
+	} {
+		if got := tt.args.structuredStdout(); got != tt.structured {
+			t.Errorf("output %q, output file %q: structuredStdout %t, want %t", tt.args.Output, tt.args.OutputFile, got, tt.structured)
+		}
+	}
+
+	if empty := (&Arguments{}); empty.reportWriter() != os.Stdout || empty.diagnostics() != os.Stderr {
+		t.Error("the report does not default to stdout, or warnings and errors do not default to stderr")
+	}
+
+	var report, diagnostics bytes.Buffer
+	args := &Arguments{Config: &Config{}, stdout: &report, stderr: &diagnostics, sinks: []sink{failingSink{}}, PerType: true}
+	counter := &Counter{
+		findings:              []Finding{{Namespace: "a", Version: "v1", Resource: "pods", Kind: "Pod", Name: "p1", ConditionType: "Ready", ConditionStatus: "False"}},
+		unknownConditionTypes: map[string]int{"Foo": 2},
+	}
+	printText(args.reportWriter(), args, counter.findings, []string{"resolved: a pods p0"}, counter)
+	sendToSinks(args, counter)
+	for _, want := range []string{"a pods p1 Condition Ready=False", "resolved: a pods p0", "RESOURCE", "Foo (2 conditions)"} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("report %q does not contain %q", report.String(), want)
+		}
+	}
+	if want := "WARNING: sending findings to failing failed: unreachable\n"; diagnostics.String() != want {
+		t.Errorf("diagnostics %q, want %q", diagnostics.String(), want)
+	}
+}
+
+func TestWriteReportNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, OutputNDJSON, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want one per finding: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var f Finding
+		if err := json.Unmarshal([]byte(line), &f); err != nil {
+			t.Errorf("invalid JSON line %q: %v", line, err)
+		}
+	}
+}
+
+func TestStreamFindings(t *testing.T) {
+	finding := func(namespace, name string, age time.Duration) Finding {
+		return Finding{
+			Namespace: namespace, Version: "v1", Resource: "pods", Kind: "Pod", Name: name, ConditionType: "Ready",
+			LastTransitionTime: time.Now().Add(-age),
+		}
+	}
+	findings := []Finding{
+		finding("shop", "old", time.Hour),
+		finding("shop", "new", time.Second),
+		finding("pr-1", "preview", time.Hour),
+	}
+	var buf bytes.Buffer
+	args := &Arguments{
+		Output:      OutputNDJSON,
+		GracePeriod: time.Minute,
+		Config:      &Config{Ephemeral: &EphemeralConfig{Names: []string{"pr-*"}, Mode: EphemeralExclude}},
+		stdout:      &buf,
+	}
+	streamFindings(args, findings)
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var f Finding
+		if err := json.Unmarshal([]byte(line), &f); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		names = append(names, f.Name)
+	}
+	if len(names) != 1 || names[0] != "old" {
+		t.Errorf("streamed %v, want [old]", names)
+	}
+
+	buf.Reset()
+	args.OutputFile = "report.ndjson"
+	streamFindings(args, findings)
+	if buf.Len() != 0 {
+		t.Errorf("findings were streamed to stdout with --output-file: %s", buf.String())
+	}
+}
+
+func TestWriteReportYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, OutputYAML, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		CheckedResources int32     `json:"checkedResources"`
+		Findings         []Finding `json:"findings"`
+	}
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid YAML: %v\n%s", err, buf.String())
+	}
+	if got.CheckedResources != 10 || len(got.Findings) != 2 || got.Findings[1].Name != "node-1" {
+		t.Errorf("unexpected report: %+v", got)
+	}
+	if !strings.Contains(buf.String(), "conditionType: MemoryPressure\n") {
+		t.Errorf("YAML does not use the JSON field names:\n%s", buf.String())
+	}
+}
+
+func TestWriteReportJUnit(t *testing.T) {
+	r := testReport()
+	r.counter.spans = []resourceTypeSpan{
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "pods"}},
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "services"}},
+		{gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, err: "forbidden"},
+	}
+	var buf bytes.Buffer
+	if err := writeReport(&buf, OutputJUnit, r); err != nil {
+		t.Fatal(err)
+	}
+	var got junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if got.Tests != 6 || got.Failures != 2 || got.Errors != 1 || got.Skipped != 1 {
+		t.Errorf("tests %d, failures %d, errors %d, skipped %d, want 6, 2, 1, 1", got.Tests, got.Failures, got.Errors, got.Skipped)
+	}
+	var suites []string
+	for _, s := range got.Suites {
+		var cases []string
+		for _, c := range s.Cases {
+			cases = append(cases, c.Name)
+		}
+		suites = append(suites, s.Name+": "+strings.Join(cases, ", "))
+	}
+	want := []string{
+		"jobs.batch/v1: forbidden",
+		"nodes/v1: node-1 MemoryPressure",
+		"pods/v1: list, shop/web-1 Ready",
+		"replicasets.apps/v1: list",
+		"services/v1: list",
+	}
+	if strings.Join(suites, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got suites\n%s\nwant\n%s", strings.Join(suites, "\n"), strings.Join(want, "\n"))
+	}
+	if f := got.Suites[2].Cases[1].Failure; f == nil || f.Type != SeverityCritical ||
+		f.Message != "Ready=False ContainersNotReady containers with unready status: [web]" {
+		t.Errorf("unexpected failure %+v", f)
+	}
+}
+
+func TestWriteReportSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, OutputSARIF, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got.Version != "2.1.0" || len(got.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", got)
+	}
+	run := got.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != "MemoryPressure" || run.Tool.Driver.Rules[1].ID != "Ready" {
+		t.Errorf("unexpected rules %+v", run.Tool.Driver.Rules)
+	}
+	want := []struct{ level, uri, message string }{
+		{"error", "api/v1/namespaces/shop/pods/web-1", "Pod web-1 Ready=False ContainersNotReady containers with unready status: [web]"},
+		{"warning", "api/v1/nodes/node-1", "Node node-1 MemoryPressure=True KubeletHasInsufficientMemory kubelet has insufficient memory available"},
+	}
+	if len(run.Results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(run.Results), len(want))
+	}
+	for i, w := range want {
+		res := run.Results[i]
+		if res.Level != w.level || res.Locations[0].PhysicalLocation.ArtifactLocation.URI != w.uri || res.Message.Text != w.message {
+			t.Errorf("result %d: got %+v, want %+v", i, res, w)
+		}
+		if res.PartialFingerprints["findingID/v1"] == "" {
+			t.Errorf("result %d has no fingerprint", i)
+		}
+	}
+}
+
+func TestWriteReportCSV(t *testing.T) {
+	r := testReport()
+	r.Scope.Cluster = "prod"
+	firstSeen, lastSeen := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC), time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	r.Findings[0].FirstSeen, r.Findings[0].LastSeen, r.Findings[0].Occurrences = &firstSeen, &lastSeen, 3
+	r.Findings[1].ConditionMessage = `kubelet has insufficient memory, "node-1"`
+	var buf bytes.Buffer
+	if err := writeReport(&buf, OutputCSV, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `cluster,namespace,resource,name,condition,status,reason,message,lastTransitionTime,firstSeen,lastSeen,occurrences
+prod,shop,pods,web-1,Ready,False,ContainersNotReady,containers with unready status: [web],2026-10-16T07:00:00Z,2026-10-15T08:00:00Z,2026-10-16T08:00:00Z,3
+prod,,nodes,node-1,MemoryPressure,True,KubeletHasInsufficientMemory,"kubelet has insufficient memory, ""node-1""",,,,
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
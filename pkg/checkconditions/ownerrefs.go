@@ -0,0 +1,70 @@
+package checkconditions
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ownerRefCandidate is an ownerReference, which gets checked after all objects were listed.
+type ownerRefCandidate struct {
+	gvr schema.GroupVersionResource
+	obj ownerNode
+	uid types.UID
+	ref metav1.OwnerReference
+}
+
+func collectOwnerRefs(_ *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	for _, ref := range obj.GetOwnerReferences() {
+		output.ownerRefs = append(output.ownerRefs, ownerRefCandidate{
+			gvr: gvr,
+			obj: ownerNode{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()},
+			uid: obj.GetUID(),
+			ref: ref,
+		})
+	}
+	return nil
+}
+
+// danglingOwnerRefs returns a finding for each ownerReference to an object which does not exist.
+// Only owners of resource types which were listed completely are checked. With a label selector
+// the owners might not be listed, so nothing gets checked.
+func danglingOwnerRefs(args *Arguments, counter *Counter) []Finding {
+	if args.LabelSelector != "" {
+		return nil
+	}
+	var findings []Finding
+	for i := range counter.ownerRefs {
+		c := &counter.ownerRefs[i]
+		if _, ok := counter.owners[c.ref.UID]; ok {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(c.ref.APIVersion)
+		if err != nil || !counter.listedKinds[schema.GroupKind{Group: gv.Group, Kind: c.ref.Kind}] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Namespace:          c.obj.Namespace,
+			Group:              c.gvr.Group,
+			Version:            c.gvr.Version,
+			Resource:           c.gvr.Resource,
+			Kind:               c.obj.Kind,
+			Name:               c.obj.Name,
+			UID:                c.uid,
+			ConditionType:      "OwnerReference",
+			ConditionStatus:    "Missing",
+			ConditionReason:    "OwnerNotFound",
+			ConditionMessage:   fmt.Sprintf("owner %s %s (uid %s) does not exist", c.ref.Kind, c.ref.Name, c.ref.UID),
+			Severity:           SeverityWarning,
+			MessageFingerprint: fmt.Sprintf("owner %s <name> (uid <uuid>) does not exist", c.ref.Kind),
+			Code:               CodeOwnerRefDangling,
+			Check:              checkOwnerRefs,
+		})
+	}
+	return findings
+}
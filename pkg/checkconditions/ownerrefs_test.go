@@ -0,0 +1,60 @@
+package checkconditions
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDanglingOwnerRefs(t *testing.T) {
+	if (&Arguments{}).checkEnabled(checkOwnerRefs) {
+		t.Errorf("check %s is enabled by default", checkOwnerRefs)
+	}
+	podGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	pod := func(name string, refs ...metav1.OwnerReference) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetKind("Pod")
+		obj.SetNamespace("a")
+		obj.SetName(name)
+		obj.SetUID(types.UID("uid-" + name))
+		obj.SetOwnerReferences(refs)
+		return obj
+	}
+	replicaSet := func(uid string) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs-" + uid, UID: types.UID(uid)}
+	}
+	output := &handleResourceTypeOutput{}
+	for _, obj := range []*unstructured.Unstructured{
+		pod("existing-owner", replicaSet("rs1")),
+		pod("deleted-owner", replicaSet("rs2")),
+		pod("kind-not-listed", metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "Widget", Name: "w", UID: "w1"}),
+		pod("invalid-api-version", metav1.OwnerReference{APIVersion: "a/b/c", Kind: "ReplicaSet", Name: "x", UID: "x1"}),
+		pod("no-owner"),
+	} {
+		collectOwnerRefs(&Arguments{}, podGVR, obj, output)
+	}
+	if len(output.ownerRefs) != 4 {
+		t.Fatalf("got %d ownerReferences, want 4", len(output.ownerRefs))
+	}
+	counter := &Counter{
+		ownerRefs:   output.ownerRefs,
+		owners:      ownerIndex{"rs1": {Kind: "ReplicaSet", Namespace: "a", Name: "rs-rs1"}},
+		listedKinds: map[schema.GroupKind]bool{{Group: "apps", Kind: "ReplicaSet"}: true},
+	}
+	findings := danglingOwnerRefs(&Arguments{}, counter)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Name != "deleted-owner" || f.UID != "uid-deleted-owner" || f.Code != CodeOwnerRefDangling ||
+		f.ConditionMessage != "owner ReplicaSet rs-rs2 (uid rs2) does not exist" {
+		t.Errorf("unexpected finding %+v", f)
+	}
+
+	if findings := danglingOwnerRefs(&Arguments{LabelSelector: "app=web"}, counter); len(findings) != 0 {
+		t.Errorf("got findings with a label selector: %v", findings)
+	}
+}
@@ -0,0 +1,114 @@
+package checkconditions
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ownerNode contains the parts of a resource object which are needed to follow the ownerReferences.
+type ownerNode struct {
+	Kind      string
+	Namespace string
+	Name      string
+	owner     *metav1.OwnerReference
+
+	// deletionTimestamp is set if the object is terminating.
+	deletionTimestamp time.Time
+}
+
+// ownerIndex maps the UID of all checked resource objects to their ownerNode.
+type ownerIndex map[types.UID]ownerNode
+
+func newOwnerNode(obj *unstructured.Unstructured) ownerNode {
+	node := ownerNode{
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+	if t := obj.GetDeletionTimestamp(); t != nil {
+		node.deletionTimestamp = t.Time
+	}
+	refs := obj.GetOwnerReferences()
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			node.owner = &refs[i]
+			return node
+		}
+	}
+	// No controller. Use the first owner.
+	if len(refs) > 0 {
+		node.owner = &refs[0]
+	}
+	return node
+}
+
+func (n ownerNode) String() string {
+	if n.Namespace == "" {
+		return n.Kind + " " + n.Name
+	}
+	return n.Namespace + " " + n.Kind + " " + n.Name
+}
+
+// chain returns the object with the given UID, its owner, the owner of the owner, and so on.
+// If an owner is not in the index, the chain ends with the data of the ownerReference.
+func (idx ownerIndex) chain(uid types.UID) []ownerNode {
+	node, ok := idx[uid]
+	if !ok {
+		return nil
+	}
+	chain := []ownerNode{node}
+	seen := map[types.UID]bool{uid: true}
+	for node.owner != nil {
+		ref := node.owner
+		if seen[ref.UID] {
+			// ownerReferences contain a cycle.
+			break
+		}
+		seen[ref.UID] = true
+		owner, ok := idx[ref.UID]
+		if !ok {
+			chain = append(chain, ownerNode{
+				Kind:      ref.Kind,
+				Namespace: node.Namespace,
+				Name:      ref.Name,
+			})
+			break
+		}
+		chain = append(chain, owner)
+		node = owner
+	}
+	return chain
+}
+
+// root returns the top-level owner of the object with the given UID.
+func (idx ownerIndex) root(uid types.UID) (ownerNode, bool) {
+	chain := idx.chain(uid)
+	if len(chain) == 0 {
+		return ownerNode{}, false
+	}
+	return chain[len(chain)-1], true
+}
+
+// markTerminatingOwners sets TerminatingOwner of findings whose owner (or an owner of the owner) is
+// terminating. These findings usually disappear when the garbage collector deletes the object.
+// If the owner is stuck, the owner (for example its finalizers) needs attention.
+func markTerminatingOwners(findings []Finding, owners ownerIndex) {
+	for i := range findings {
+		f := &findings[i]
+		chain := owners.chain(f.UID)
+		for j := 1; j < len(chain); j++ {
+			if chain[j].deletionTimestamp.IsZero() {
+				continue
+			}
+			f.TerminatingOwner = &TerminatingOwner{
+				Kind:  chain[j].Kind,
+				Name:  chain[j].Name,
+				Since: chain[j].deletionTimestamp,
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package checkconditions
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// testOwnerObject returns an object of the namespace "shop" which is controlled by owner, if owner is not nil.
+func testOwnerObject(kind, name string, uid types.UID, owner *unstructured.Unstructured) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetKind(kind)
+	obj.SetNamespace("shop")
+	obj.SetName(name)
+	obj.SetUID(uid)
+	if owner != nil {
+		controller := true
+		obj.SetOwnerReferences([]metav1.OwnerReference{{
+			Kind: owner.GetKind(), Name: owner.GetName(), UID: owner.GetUID(), Controller: &controller,
+		}})
+	}
+	return obj
+}
+
+// testOwners returns the owners of two pods of the Deployment web, and of a pod without owner.
+func testOwners() ownerIndex {
+	deployment := testOwnerObject("Deployment", "web", "uid-deploy", nil)
+	replicaSet := testOwnerObject("ReplicaSet", "web-5d4f", "uid-rs", deployment)
+	owners := make(ownerIndex)
+	for _, obj := range []*unstructured.Unstructured{
+		deployment, replicaSet,
+		testOwnerObject("Pod", "web-5d4f-a", "uid-pod-a", replicaSet),
+		testOwnerObject("Pod", "web-5d4f-b", "uid-pod-b", replicaSet),
+		testOwnerObject("Pod", "debug", "uid-debug", nil),
+	} {
+		owners[obj.GetUID()] = newOwnerNode(obj)
+	}
+	return owners
+}
+
+// testOwnerFindings returns a finding for each pod of testOwners.
+func testOwnerFindings() []Finding {
+	findings := make([]Finding, 0, 3)
+	for _, pod := range []struct {
+		name string
+		uid  types.UID
+	}{{"web-5d4f-a", "uid-pod-a"}, {"web-5d4f-b", "uid-pod-b"}, {"debug", "uid-debug"}} {
+		findings = append(findings, Finding{
+			Namespace: "shop", Version: "v1", Resource: "pods", Kind: "Pod", Name: pod.name, UID: pod.uid,
+			ConditionType: "Ready", ConditionStatus: "False", Severity: SeverityCritical,
+		})
+	}
+	return findings
+}
+
+func TestGroupByOwner(t *testing.T) {
+	owners := testOwners()
+	if root, ok := owners.root("uid-pod-a"); !ok || root.String() != "shop Deployment web" {
+		t.Errorf("root of pod web-5d4f-a is %q, want the Deployment", root.String())
+	}
+	if _, ok := owners.root("uid-unknown"); ok {
+		t.Error("an object which is not in the index has a root")
+	}
+
+	var buf bytes.Buffer
+	args := &Arguments{GroupBy: GroupByOwner}
+	printFindings(&buf, args, testOwnerFindings(), &Counter{owners: owners})
+	out := buf.String()
+	for _, want := range []string{"  shop Deployment web (2)\n", "  shop Pod debug (1)\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing group %q in output:\n%s", want, out)
+		}
+	}
+	deployment := strings.Index(out, "Deployment web")
+	if a, b := strings.Index(out, "web-5d4f-a"), strings.Index(out, "web-5d4f-b"); a < deployment || b < deployment ||
+		strings.Index(out, "Pod debug") < a {
+		t.Errorf("the pods are not listed beneath their Deployment:\n%s", out)
+	}
+}
+
+func TestOwnerChain(t *testing.T) {
+	owners := testOwners()
+	var buf bytes.Buffer
+	printFindings(&buf, &Arguments{OwnerChain: true}, testOwnerFindings(), &Counter{owners: owners})
+	out := buf.String()
+	if n := strings.Count(out, "    owners: Pod web-5d4f-a ← ReplicaSet web-5d4f ← Deployment web\n"); n != 1 {
+		t.Errorf("owner chain of pod web-5d4f-a printed %d times:\n%s", n, out)
+	}
+	if strings.Contains(out, "owners: Pod debug") {
+		t.Errorf("owner chain printed for a pod without owner:\n%s", out)
+	}
+
+	// The chain ends with the ownerReference of an owner which is not in the index.
+	missing := testOwnerObject("HelmRelease", "web", "uid-helmrelease", nil)
+	deployment := testOwnerObject("Deployment", "web", "uid-deploy", missing)
+	owners["uid-deploy"] = newOwnerNode(deployment)
+	if got := chainString(owners.chain("uid-pod-a")); got != "Pod web-5d4f-a ← ReplicaSet web-5d4f ← Deployment web ← HelmRelease web" {
+		t.Errorf("unexpected chain %q", got)
+	}
+
+	// A cycle in the ownerReferences ends the chain.
+	owners["uid-deploy"] = newOwnerNode(testOwnerObject("Deployment", "web", "uid-deploy",
+		testOwnerObject("Pod", "web-5d4f-a", "uid-pod-a", nil)))
+	if got := chainString(owners.chain("uid-pod-a")); got != "Pod web-5d4f-a ← ReplicaSet web-5d4f ← Deployment web" {
+		t.Errorf("unexpected chain with cycle %q", got)
+	}
+}
+
+func chainString(chain []ownerNode) string {
+	parts := make([]string, 0, len(chain))
+	for _, node := range chain {
+		parts = append(parts, node.Kind+" "+node.Name)
+	}
+	return strings.Join(parts, " ← ")
+}
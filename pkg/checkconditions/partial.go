@@ -0,0 +1,95 @@
+package checkconditions
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// partialReason returns why the scan did not check all objects of the cluster, or "". Findings
+// which are missing in a partial scan are not necessarily resolved: a LIST failed, a resource type
+// was skipped (--max-objects, --max-duration, --per-type-timeout), or the scope was narrowed.
+func (c *Counter) partialReason(args *Arguments) string {
+	switch {
+	case len(c.errors) > 0:
+		return fmt.Sprintf("%d scan errors", len(c.errors))
+	case len(c.skipped) > 0:
+		return fmt.Sprintf("%d skipped resource types", len(c.skipped))
+	case args.namespaceLimited():
+		return "limited to namespaces"
+	case args.LabelSelector != "":
+		return "limited by label selector"
+	case len(args.Resources) > 0:
+		return "limited to resource types"
+	case args.Team != "":
+		return "limited to team " + args.Team
+	}
+	return ""
+}
+
+// resolvable returns a func, which returns true, if a finding of a previous scan which is missing
+// in this scan is resolved: its resource type was listed completely, its namespace was in the scope
+// of the scan (and belongs to --team), and it is not held back by --grace-period. Nothing is
+// resolvable after scan errors or skipped resource types.
+func (c *Counter) resolvable(args *Arguments) func(f *Finding) bool {
+	if len(c.errors) > 0 || len(c.skipped) > 0 || args.LabelSelector != "" {
+		return func(*Finding) bool { return false }
+	}
+	pending := make(map[string]bool, len(c.pending))
+	for i := range c.pending {
+		pending[c.pending[i].ID()] = true
+	}
+	return func(f *Finding) bool {
+		if pending[f.ID()] || !c.listedKinds[schema.GroupKind{Group: f.Group, Kind: f.Kind}] {
+			return false
+		}
+		if args.Team != "" && teamOf(args.Config, c.namespaces, f.Namespace) != args.Team {
+			return false
+		}
+		return args.namespaceInScope(f.Namespace)
+	}
+}
+
+// namespaceInScope returns true if the objects of the namespace get scanned. "" is the namespace
+// of cluster-scoped objects, which are skipped with --namespace and --namespaces.
+func (args *Arguments) namespaceInScope(namespace string) bool {
+	switch {
+	case args.Namespace != "":
+		return namespace == args.Namespace
+	case len(args.Namespaces) > 0:
+		for _, ns := range args.Namespaces {
+			if ns == namespace {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// closeResolved returns true if sinks may close the issues and alerts of findings which are
+// missing in this scan. Sinks only know the hash of the finding of an issue, so they do not close
+// anything after a partial scan.
+func closeResolved(args *Arguments, counter *Counter, sinkName string) bool {
+	reason := counter.partialReason(args)
+	if reason == "" {
+		return true
+	}
+	if args.Verbose {
+		fmt.Fprintf(args.diagnostics(), "%s: not closing resolved findings, since the scan was partial: %s\n", sinkName, reason)
+	}
+	return false
+}
+
+// currentKeys returns the keys (see sinkKey) of the findings and of the pending findings
+// (--grace-period). Issues of pending findings must not be closed.
+func currentKeys(args *Arguments, counter *Counter, prefix string) map[string]bool {
+	current := make(map[string]bool, len(counter.findings)+len(counter.pending))
+	for i := range counter.pending {
+		current[prefix+args.sinkKey(&counter.pending[i])] = true
+	}
+	for i := range counter.findings {
+		current[prefix+args.sinkKey(&counter.findings[i])] = true
+	}
+	return current
+}
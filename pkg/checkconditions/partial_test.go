@@ -0,0 +1,76 @@
+package checkconditions
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestResolvable(t *testing.T) {
+	pod := Finding{Namespace: "a", Resource: "pods", Kind: "Pod", Name: "p1", ConditionType: "Ready"}
+	node := Finding{Resource: "nodes", Kind: "Node", Name: "n1", ConditionType: "Ready"}
+	deployment := Finding{Namespace: "a", Group: "apps", Resource: "deployments", Kind: "Deployment", Name: "d1", ConditionType: "Available"}
+	config := &Config{Teams: []TeamConfig{{Name: "payments", NamespaceLabels: map[string]string{"team": "payments"}}}}
+	newCounter := func() *Counter {
+		return &Counter{
+			listedKinds: map[schema.GroupKind]bool{{Kind: "Pod"}: true, {Kind: "Node"}: true},
+			namespaces: map[string]namespaceMeta{
+				"a": {labels: map[string]string{"team": "payments"}},
+				"b": {},
+			},
+		}
+	}
+	tests := []struct {
+		name        string
+		args        Arguments
+		modify      func(*Counter)
+		finding     Finding
+		resolvable  bool
+		closeIssues bool
+	}{
+		{name: "complete scan", finding: pod, resolvable: true, closeIssues: true},
+		{name: "cluster-scoped", finding: node, resolvable: true, closeIssues: true},
+		{name: "kind not listed", finding: deployment, closeIssues: true},
+		{
+			name:    "scan error",
+			modify:  func(c *Counter) { c.errors = []scanError{{Type: "forbidden"}} },
+			finding: pod,
+		},
+		{
+			name:    "skipped resource type",
+			modify:  func(c *Counter) { c.skipped = []skippedResourceType{{reason: skipReasonMaxDuration}} },
+			finding: pod,
+		},
+		{
+			name:    "pending",
+			modify:  func(c *Counter) { c.pending = []Finding{pod} },
+			finding: pod, closeIssues: true,
+		},
+		{name: "label selector", args: Arguments{LabelSelector: "app=web"}, finding: pod},
+		{name: "namespace in scope", args: Arguments{Namespace: "a"}, finding: pod, resolvable: true},
+		{name: "namespace out of scope", args: Arguments{Namespaces: []string{"b"}}, finding: pod},
+		{name: "cluster-scoped with namespace", args: Arguments{Namespace: "a"}, finding: node},
+		{name: "finding of the team", args: Arguments{Team: "payments", Config: config}, finding: pod, resolvable: true},
+		{name: "finding of another team", args: Arguments{Team: noTeam, Config: config}, finding: pod},
+		{name: "resource types", args: Arguments{Resources: []string{"pods"}}, finding: pod, resolvable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := newCounter()
+			if tt.modify != nil {
+				tt.modify(counter)
+			}
+			args := tt.args
+			if args.Config == nil {
+				args.Config = &Config{}
+			}
+			f := tt.finding
+			if got := counter.resolvable(&args)(&f); got != tt.resolvable {
+				t.Errorf("resolvable = %t, want %t", got, tt.resolvable)
+			}
+			if got := closeResolved(&args, counter, "test"); got != tt.closeIssues {
+				t.Errorf("closeResolved = %t, want %t", got, tt.closeIssues)
+			}
+		})
+	}
+}
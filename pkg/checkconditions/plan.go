@@ -0,0 +1,142 @@
+package checkconditions
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RunPlan only does discovery, and prints which resource types would be listed and which would be
+// skipped, together with an estimation of the number of objects. No resource objects get listed.
+func RunPlan(args Arguments) {
+	config, err := newRestConfig(&args)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	serverResources, _, err := discoverResources(clientset, args.Resources, args.diagnostics())
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	estimates, source := estimateObjectCounts(clientset, &args)
+	listCalls, skipped, knownObjects := 0, 0, 0
+	for _, resourceList := range serverResources {
+		groupVersion, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to parse group version: %v\n", err)
+			continue
+		}
+		for i := range resourceList.APIResources {
+			r := &resourceList.APIResources[i]
+			if containsSlash(r.Name) {
+				continue
+			}
+			if reason := skipReason(&args, r.Name, r.Namespaced); reason != "" {
+				skipped++
+				fmt.Printf("  SKIP %s %s (%s)\n", resourceList.GroupVersion, r.Name, reason)
+				continue
+			}
+			if !slices.Contains(r.Verbs, "list") {
+				fmt.Printf("  LIST %s %s (no list verb, the call will fail)\n", resourceList.GroupVersion, r.Name)
+				listCalls++
+				continue
+			}
+			listCalls++
+			estimate := "?"
+			if n, ok := estimates[estimateKey(groupVersion.Group, r.Name)]; ok {
+				estimate = strconv.Itoa(n)
+				knownObjects += n
+			}
+			fmt.Printf("  LIST %s %s objects=%s\n", resourceList.GroupVersion, r.Name, estimate)
+		}
+	}
+	fmt.Printf("Plan: %d LIST calls, %d skipped resource types. Estimated objects: %d (source: %s)\n",
+		listCalls, skipped, knownObjects, source)
+}
+
+// estimateKey returns the key used by the apiserver metrics and by resource quotas: "pods" or "deployments.apps".
+func estimateKey(group, resource string) string {
+	if group == "" {
+		return resource
+	}
+	return resource + "." + group
+}
+
+// estimateObjectCounts returns the number of objects per resource type. The metric apiserver_storage_objects
+// of the api-server is used, if it is readable. Otherwise the "count/..." values of resource quotas are used.
+// The quotas only cover the namespaces which have a quota, so this is a lower bound.
+func estimateObjectCounts(clientset *kubernetes.Clientset, args *Arguments) (map[string]int, string) {
+	if !args.namespaceLimited() {
+		data, err := clientset.Discovery().RESTClient().Get().AbsPath("/metrics").DoRaw(context.TODO())
+		if err == nil {
+			if counts := parseStorageObjects(data); len(counts) > 0 {
+				return counts, "apiserver metrics"
+			}
+		}
+	}
+	namespaces := args.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{args.Namespace}
+	}
+	var quotas []corev1.ResourceQuota
+	for _, namespace := range namespaces {
+		list, err := clientset.CoreV1().ResourceQuotas(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, "none"
+		}
+		quotas = append(quotas, list.Items...)
+	}
+	counts := make(map[string]int)
+	for _, quota := range quotas {
+		for name, quantity := range quota.Status.Used {
+			key, ok := strings.CutPrefix(string(name), "count/")
+			if !ok {
+				continue
+			}
+			counts[key] += int(quantity.Value())
+		}
+	}
+	if len(counts) == 0 {
+		return nil, "none"
+	}
+	return counts, "resource quotas, lower bound"
+}
+
+// parseStorageObjects parses lines like `apiserver_storage_objects{resource="pods"} 42`.
+func parseStorageObjects(data []byte) map[string]int {
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) //nolint:gomnd
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), `apiserver_storage_objects{resource="`)
+		if !ok {
+			continue
+		}
+		resource, value, ok := strings.Cut(line, `"} `)
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || n < 0 {
+			continue
+		}
+		counts[resource] = int(n)
+	}
+	return counts
+}
@@ -0,0 +1,116 @@
+package checkconditions
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Categories of finished pods. Evicted pods are failed pods with reason "Evicted".
+const (
+	podsFailed    = "Failed"
+	podsEvicted   = "Evicted"
+	podsCompleted = "Completed"
+)
+
+// DefaultPodThresholds are the default values of Arguments.PodThresholds.
+var DefaultPodThresholds = map[string]int{
+	podsFailed:    50,
+	podsEvicted:   20,
+	podsCompleted: 200,
+}
+
+// resolvePodThresholds checks the categories of --pod-thresholds. Missing categories get the default.
+func (args *Arguments) resolvePodThresholds() error {
+	thresholds := make(map[string]int, len(DefaultPodThresholds))
+	for category, n := range DefaultPodThresholds {
+		thresholds[category] = n
+	}
+	for category, n := range args.PodThresholds {
+		if _, ok := DefaultPodThresholds[category]; !ok {
+			return fmt.Errorf("invalid category for --pod-thresholds: %q. Valid values: %s, %s, %s", category,
+				podsFailed, podsEvicted, podsCompleted)
+		}
+		thresholds[category] = n
+	}
+	args.PodThresholds = thresholds
+	return nil
+}
+
+// podCategoryKey is a category of finished pods in a namespace.
+type podCategoryKey struct {
+	namespace string
+	category  string
+}
+
+// collectFinishedPods counts the failed, evicted and completed pods per namespace.
+func collectFinishedPods(_ *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	if gvr.Group != "" || gvr.Resource != "pods" {
+		return nil
+	}
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	reason, _, _ := unstructured.NestedString(obj.Object, "status", "reason")
+	var category string
+	switch {
+	case phase == "Failed" && reason == "Evicted":
+		category = podsEvicted
+	case phase == "Failed":
+		category = podsFailed
+	case phase == "Succeeded":
+		category = podsCompleted
+	default:
+		return nil
+	}
+	if output.finishedPods == nil {
+		output.finishedPods = make(map[podCategoryKey]int)
+	}
+	output.finishedPods[podCategoryKey{obj.GetNamespace(), category}]++
+	return nil
+}
+
+// podAccumulation reports namespaces with more finished pods than the threshold of the category
+// (Arguments.PodThresholds). They inflate etcd and confuse dashboards.
+func podAccumulation(args *Arguments, counter *Counter) []Finding {
+	keys := make([]podCategoryKey, 0, len(counter.finishedPods))
+	for key := range counter.finishedPods {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].namespace != keys[j].namespace {
+			return keys[i].namespace < keys[j].namespace
+		}
+		return keys[i].category < keys[j].category
+	})
+	var findings []Finding
+	for _, key := range keys {
+		n := counter.finishedPods[key]
+		threshold, ok := args.PodThresholds[key.category]
+		if !ok || threshold <= 0 || n <= threshold {
+			continue
+		}
+		selector := "status.phase==Failed"
+		if key.category == podsCompleted {
+			selector = "status.phase==Succeeded"
+		}
+		findings = append(findings, Finding{
+			Group:              "",
+			Version:            "v1",
+			Resource:           "namespaces",
+			Kind:               "Namespace",
+			Name:               key.namespace,
+			ConditionType:      key.category + "Pods",
+			ConditionStatus:    "True",
+			ConditionReason:    "TooManyPods",
+			ConditionMessage:   fmt.Sprintf("%d %s pods (threshold %d). Cleanup: kubectl delete pods -n %s --field-selector=%s", n, key.category, threshold, key.namespace, selector),
+			Severity:           SeverityWarning,
+			MessageFingerprint: fmt.Sprintf("<n> %s pods (threshold <n>)", key.category),
+			Code:               CodePodsAccumulated,
+			Check:              checkPodAccumulation,
+		})
+	}
+	return findings
+}
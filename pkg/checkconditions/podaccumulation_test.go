@@ -0,0 +1,68 @@
+package checkconditions
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPodAccumulation(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	pod := func(namespace, phase, reason string, i int) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":   "Pod",
+			"status": map[string]interface{}{"phase": phase, "reason": reason},
+		}}
+		obj.SetNamespace(namespace)
+		obj.SetName(fmt.Sprintf("p%d", i))
+		return obj
+	}
+	args := &Arguments{PodThresholds: map[string]int{podsEvicted: 2}}
+	if err := args.resolvePodThresholds(); err != nil {
+		t.Fatal(err)
+	}
+	if args.PodThresholds[podsFailed] != DefaultPodThresholds[podsFailed] {
+		t.Errorf("missing categories do not get the default: %v", args.PodThresholds)
+	}
+	if err := (&Arguments{PodThresholds: map[string]int{"Pending": 1}}).resolvePodThresholds(); err == nil {
+		t.Error("invalid category was accepted")
+	}
+
+	output := &handleResourceTypeOutput{}
+	for _, p := range []struct {
+		namespace, phase, reason string
+		n                        int
+	}{
+		{"a", "Failed", "Evicted", 3},
+		{"a", "Failed", "", 3},
+		{"a", "Running", "", 100},
+		{"b", "Failed", "Evicted", 2},
+		{"c", "Succeeded", "", 201},
+	} {
+		for i := 0; i < p.n; i++ {
+			collectFinishedPods(args, pods, pod(p.namespace, p.phase, p.reason, i), output)
+		}
+	}
+	collectFinishedPods(args, schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "pods"},
+		pod("a", "Failed", "Evicted", 0), output)
+	if n := output.finishedPods[podCategoryKey{"a", podsEvicted}]; n != 3 {
+		t.Errorf("counted %d evicted pods in namespace a, want 3", n)
+	}
+
+	var got []string
+	for _, f := range podAccumulation(args, &Counter{finishedPods: output.finishedPods}) {
+		if f.Kind != "Namespace" || f.Code != CodePodsAccumulated || f.Check != checkPodAccumulation {
+			t.Errorf("unexpected finding %+v", f)
+		}
+		got = append(got, f.Name+" "+f.ConditionType+": "+f.ConditionMessage)
+	}
+	want := []string{
+		"a EvictedPods: 3 Evicted pods (threshold 2). Cleanup: kubectl delete pods -n a --field-selector=status.phase==Failed",
+		"c CompletedPods: 201 Completed pods (threshold 200). Cleanup: kubectl delete pods -n c --field-selector=status.phase==Succeeded",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
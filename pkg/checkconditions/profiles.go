@@ -0,0 +1,133 @@
+package checkconditions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// profile bundles settings for a common scenario. A profile only changes settings which were not
+// set via command-line flags.
+type profile struct {
+	name        string
+	description string
+	apply       func(args *Arguments)
+}
+
+var profiles = []profile{
+	{
+		name:        "ci",
+		description: "For pipelines. Skips slow resource types, only prints the findings, writes a JUnit report and fails on warnings",
+		apply: func(args *Arguments) {
+			if !args.flagChanged("per-type-timeout") {
+				args.PerTypeTimeout = 30 * time.Second //nolint:gomnd
+			}
+			if !args.flagChanged("quiet") {
+				args.Quiet = true
+			}
+			if !args.flagChanged("no-color") {
+				args.NoColor = true
+			}
+			if !args.flagChanged("output-file") {
+				args.OutputFile = "check-conditions-junit.xml"
+				if !args.flagChanged("output-file-format") {
+					args.OutputFileFormat = OutputJUnit
+				}
+			}
+			if !args.flagChanged("exit-policy") {
+				args.ExitPolicy = "fail-on-warning"
+			}
+		},
+	},
+	{
+		name:        "operator",
+		description: "Daily work: findings in sections, grouped by owner, with a grace period for self-resolving conditions",
+		apply: func(args *Arguments) {
+			if !args.flagChanged("group-by") {
+				args.GroupBy = GroupByOwner
+			}
+			if !args.flagChanged("grace-period") {
+				args.GracePeriod = 2 * time.Minute //nolint:gomnd
+			}
+			if !args.flagChanged("sections") {
+				args.Sections = true
+			}
+		},
+	},
+	{
+		name:        "incident",
+		description: "Findings rolled up to their root owner, identical findings collapsed, with the chain of owners, the related Events and the age of the conditions, most severe first",
+		apply: func(args *Arguments) {
+			if !args.flagChanged("group-by") {
+				args.GroupBy = GroupByOwner
+			}
+			if !args.flagChanged("dedup") {
+				args.Dedup = true
+			}
+			if !args.flagChanged("owner-chain") {
+				args.OwnerChain = true
+			}
+			if !args.flagChanged("attach-events") {
+				args.AttachEvents = true
+			}
+			if !args.flagChanged("output") {
+				args.Output = OutputWide
+			}
+			if !args.flagChanged("sort-by") {
+				args.SortBy = []string{SortBySeverity, SortByAge}
+			}
+		},
+	},
+	{
+		name:        "audit",
+		description: "All checks, with a JSON report of the scan",
+		apply: func(args *Arguments) {
+			if !args.flagChanged("enable-checks") {
+				args.EnableChecks = []string{"*"}
+			}
+			if !args.flagChanged("output-file") {
+				args.OutputFile = "check-conditions-audit.json"
+				if !args.flagChanged("output-file-format") {
+					args.OutputFileFormat = OutputJSON
+				}
+			}
+		},
+	},
+}
+
+// flagChanged returns true if the command-line flag was set explicitly.
+func (args *Arguments) flagChanged(name string) bool {
+	return args.FlagChanged != nil && args.FlagChanged(name)
+}
+
+// ProfileNames returns the names of all profiles.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// applyProfile applies the profile given via --profile.
+func (args *Arguments) applyProfile() error {
+	if args.Profile == "" {
+		return nil
+	}
+	for _, p := range profiles {
+		if p.name == args.Profile {
+			p.apply(args)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value for --profile: %q. Valid values: %s", args.Profile, strings.Join(ProfileNames(), ", "))
+}
+
+// ProfilesHelp returns one line per profile, for the help of the --profile flag.
+func ProfilesHelp() string {
+	lines := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		lines = append(lines, fmt.Sprintf("%s: %s", p.name, p.description))
+	}
+	return strings.Join(lines, ". ")
+}
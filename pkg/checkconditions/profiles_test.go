@@ -0,0 +1,54 @@
+package checkconditions
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyProfile(t *testing.T) {
+	tests := []struct {
+		profile string
+		changed []string
+		check   func(args *Arguments) bool
+	}{
+		{profile: "ci", check: func(args *Arguments) bool {
+			return args.Quiet && args.NoColor && args.OutputFileFormat == OutputJUnit && args.ExitPolicy == "fail-on-warning" &&
+				args.PerTypeTimeout == 30*time.Second
+		}},
+		{profile: "operator", check: func(args *Arguments) bool {
+			return args.GroupBy == GroupByOwner && args.Sections && args.GracePeriod == 2*time.Minute
+		}},
+		{profile: "incident", check: func(args *Arguments) bool {
+			return args.GroupBy == GroupByOwner && args.Dedup && args.OwnerChain && args.AttachEvents &&
+				args.Output == OutputWide && slices.Equal(args.SortBy, []string{SortBySeverity, SortByAge})
+		}},
+		{profile: "audit", check: func(args *Arguments) bool {
+			return slices.Equal(args.EnableChecks, []string{"*"}) && args.OutputFileFormat == OutputJSON
+		}},
+		// Flags on the command-line take precedence, even with their default value.
+		{profile: "incident", changed: []string{"group-by", "attach-events", "dedup"}, check: func(args *Arguments) bool {
+			return args.GroupBy == "" && !args.AttachEvents && !args.Dedup && args.OwnerChain
+		}},
+		{profile: "ci", changed: []string{"output-file"}, check: func(args *Arguments) bool {
+			return args.OutputFile == "" && args.OutputFileFormat == "" && args.Quiet
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile+" "+strings.Join(tt.changed, ","), func(t *testing.T) {
+			args := &Arguments{Profile: tt.profile, FlagChanged: func(name string) bool { return slices.Contains(tt.changed, name) }}
+			if err := args.applyProfile(); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(args) {
+				t.Errorf("unexpected arguments %+v", args)
+			}
+		})
+	}
+
+	err := (&Arguments{Profile: "fast"}).applyProfile()
+	if err == nil || !strings.Contains(err.Error(), "ci, operator, incident, audit") {
+		t.Errorf("error %v, want the valid profiles", err)
+	}
+}
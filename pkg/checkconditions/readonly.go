@@ -0,0 +1,60 @@
+package checkconditions
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// readOnlyReviews are the resources which get created via POST, but which do not change the
+// cluster: the api-server only answers, whether the current user may do something.
+var readOnlyReviews = []string{"/selfsubjectaccessreviews", "/selfsubjectrulesreviews", "/selfsubjectreviews"}
+
+// wrapReadOnly returns a RoundTripper, which rejects all requests to the api-server which could
+// change the cluster. It gets used with --read-only, so that no code path can write, even if a
+// feature (like Kubernetes Events) is configured.
+func wrapReadOnly(rt http.RoundTripper) http.RoundTripper {
+	return readOnlyRoundTripper{rt: rt}
+}
+
+type readOnlyRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func (t readOnlyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.rt.RoundTrip(req)
+	case http.MethodPost:
+		for _, suffix := range readOnlyReviews {
+			if strings.HasSuffix(req.URL.Path, suffix) {
+				return t.rt.RoundTrip(req)
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s %s rejected: --read-only is set", req.Method, req.URL.Path)
+}
+
+// validateNoWrite checks --no-write, the safety flag for scanners which must not change anything
+// outside of their own files: it implies --read-only, which can not be disabled then, and it
+// refuses the flags which push to other systems. The sinks and Grafana of the config file get
+// ignored, see createWriters.
+func (args *Arguments) validateNoWrite() error {
+	if !args.NoWrite {
+		return nil
+	}
+	if args.flagChanged("read-only") && !args.ReadOnly {
+		return fmt.Errorf("--no-write can not be combined with --read-only=false")
+	}
+	args.ReadOnly = true
+	for _, f := range []struct{ flag, value string }{
+		{"--remote-write-url", args.RemoteWriteURL},
+		{"--push-gateway", args.PushGateway},
+		{"--otlp-endpoint", args.OTLPEndpoint},
+	} {
+		if f.value != "" {
+			return fmt.Errorf("--no-write can not be combined with %s", f.flag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package checkconditions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyRoundTripper(t *testing.T) {
+	rt := wrapReadOnly(http.DefaultTransport)
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+	for _, tt := range []struct {
+		method, path string
+		allowed      bool
+	}{
+		{http.MethodGet, "/api/v1/pods", true},
+		{http.MethodPost, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", true},
+		{http.MethodPost, "/api/v1/namespaces/default/events", false},
+		{http.MethodPatch, "/api/v1/namespaces/default/events/e1", false},
+		{http.MethodDelete, "/api/v1/namespaces/default/pods/p1", false},
+	} {
+		req := httptest.NewRequest(tt.method, server.URL+tt.path, nil)
+		req.RequestURI = ""
+		resp, err := rt.RoundTrip(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("%s %s: allowed %t, want %t (%v)", tt.method, tt.path, allowed, tt.allowed, err)
+		}
+	}
+}
+
+func TestNoWrite(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    Arguments
+		changed []string
+		err     string
+	}{
+		{name: "implies --read-only", args: Arguments{NoWrite: true}},
+		{name: "--read-only=false", args: Arguments{NoWrite: true}, changed: []string{"read-only"}, err: "--read-only=false"},
+		{name: "--remote-write-url", args: Arguments{NoWrite: true, RemoteWriteURL: "http://prometheus"}, err: "--remote-write-url"},
+		{name: "--push-gateway", args: Arguments{NoWrite: true, PushGateway: "http://pushgateway"}, err: "--push-gateway"},
+		{name: "--otlp-endpoint", args: Arguments{NoWrite: true, OTLPEndpoint: "http://otel"}, err: "--otlp-endpoint"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			args.FlagChanged = func(name string) bool {
+				for _, c := range tt.changed {
+					if c == name {
+						return true
+					}
+				}
+				return false
+			}
+			err := args.validateNoWrite()
+			if tt.err == "" {
+				if err != nil || !args.ReadOnly {
+					t.Errorf("error %v, read-only %t", err, args.ReadOnly)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel")
+	config := &Config{Jira: &JiraConfig{URL: "http://jira"}, Grafana: &GrafanaConfig{URL: "http://grafana"}}
+	var log strings.Builder
+	args := &Arguments{Config: config, NoWrite: true}
+	args.createWriters(&log)
+	if len(args.sinks) != 0 || args.grafana != nil || args.otlpEndpoint() != "" {
+		t.Errorf("--no-write: sinks %v, grafana %v, OTLP endpoint %q", args.sinks, args.grafana, args.otlpEndpoint())
+	}
+	if !strings.Contains(log.String(), "--no-write") {
+		t.Errorf("no warning about the ignored sinks: %q", log.String())
+	}
+	args.NoWrite = false
+	args.createWriters(&log)
+	if len(args.sinks) != 1 || args.grafana == nil {
+		t.Errorf("without --no-write: sinks %v, grafana %v", args.sinks, args.grafana)
+	}
+}
@@ -0,0 +1,57 @@
+package checkconditions
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// defaultRedactionReplacement replaces matches of a RedactionConfig without replacement.
+const defaultRedactionReplacement = "<redacted>"
+
+// RedactionConfig replaces the matches of a regular expression in condition messages, before the
+// findings reach reports, history and sinks (Jira, GitHub, Opsgenie, ...). This keeps tokens, IPs
+// and customer identifiers in error messages of operators out of other systems.
+type RedactionConfig struct {
+	// Regex is a regular expression (Go syntax), like "token=[^ ]+".
+	Regex string `json:"regex"`
+
+	// Replacement replaces the matches. It may refer to groups of the regex: "token=$1".
+	// Defaults to "<redacted>". An empty string removes the matches.
+	Replacement *string `json:"replacement"`
+
+	re          *regexp.Regexp
+	replacement string
+}
+
+// parse compiles the regex.
+func (r *RedactionConfig) parse() error {
+	if r.Regex == "" {
+		return fmt.Errorf("regex is missing")
+	}
+	re, err := regexp.Compile(r.Regex)
+	if err != nil {
+		return fmt.Errorf("invalid regex %q: %w", r.Regex, err)
+	}
+	r.re = re
+	r.replacement = defaultRedactionReplacement
+	if r.Replacement != nil {
+		r.replacement = *r.Replacement
+	}
+	return nil
+}
+
+// redactFindings applies the redactions in the order of the config to the condition messages,
+// their fingerprints and the details.
+func redactFindings(redactions []RedactionConfig, findings []Finding) {
+	if len(redactions) == 0 {
+		return
+	}
+	for i := range findings {
+		f := &findings[i]
+		for _, r := range redactions {
+			f.ConditionMessage = r.re.ReplaceAllString(f.ConditionMessage, r.replacement)
+			f.MessageFingerprint = r.re.ReplaceAllString(f.MessageFingerprint, r.replacement)
+			f.Details = r.re.ReplaceAllString(f.Details, r.replacement)
+		}
+	}
+}
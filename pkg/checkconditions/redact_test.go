@@ -0,0 +1,86 @@
+package checkconditions
+
+import (
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestRedactFindings(t *testing.T) {
+	tests := []struct {
+		name       string
+		redactions string
+		message    string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "default replacement",
+			redactions: `[{regex: 'token=[^ ]+'}]`,
+			message:    "login failed: token=abc123 expired",
+			want:       "login failed: <redacted> expired",
+		},
+		{
+			name:       "replacement with group",
+			redactions: `[{regex: '(token|password)=[^ ]+', replacement: '$1=***'}]`,
+			message:    "password=secret token=abc",
+			want:       "password=*** token=***",
+		},
+		{
+			name:       "empty replacement removes the matches",
+			redactions: `[{regex: ' \(request id [0-9a-f-]+\)', replacement: ''}]`,
+			message:    "backend unavailable (request id 3f2a-11)",
+			want:       "backend unavailable",
+		},
+		{
+			name:       "null replacement is the default",
+			redactions: `[{regex: 'secret', replacement: null}]`,
+			message:    "a secret",
+			want:       "a <redacted>",
+		},
+		{
+			name:       "redactions are applied in order",
+			redactions: `[{regex: 'a', replacement: 'b'}, {regex: 'b', replacement: 'c'}]`,
+			message:    "ab",
+			want:       "cc",
+		},
+		{
+			name:       "missing regex",
+			redactions: `[{replacement: 'x'}]`,
+			wantErr:    true,
+		},
+		{
+			name:       "invalid regex",
+			redactions: `[{regex: '('}]`,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var redactions []RedactionConfig
+			if err := yaml.UnmarshalStrict([]byte(tt.redactions), &redactions); err != nil {
+				t.Fatal(err)
+			}
+			var err error
+			for i := range redactions {
+				if err = redactions[i].parse(); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse error %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			findings := []Finding{{ConditionMessage: tt.message, MessageFingerprint: tt.message}}
+			redactFindings(redactions, findings)
+			if findings[0].ConditionMessage != tt.want {
+				t.Errorf("message %q, want %q", findings[0].ConditionMessage, tt.want)
+			}
+			if findings[0].MessageFingerprint != tt.want {
+				t.Errorf("fingerprint %q, want %q", findings[0].MessageFingerprint, tt.want)
+			}
+		})
+	}
+}
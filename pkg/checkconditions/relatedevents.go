@@ -0,0 +1,129 @@
+package checkconditions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxRelatedEvents is the number of Events which get attached to a finding with --attach-events.
+const maxRelatedEvents = 3
+
+// eventsPageSize is the page size of the LISTs of Events.
+const eventsPageSize = 500
+
+// RelatedEvent is a core/v1 Event of the object of a finding. See attachEvents.
+type RelatedEvent struct {
+	Type          string    `json:"type"`
+	Reason        string    `json:"reason"`
+	Message       string    `json:"message"`
+	Count         int32     `json:"count,omitempty"`
+	LastTimestamp time.Time `json:"lastTimestamp"`
+}
+
+// attachEvents sets Finding.Events to the newest Events of the object of each finding. The Events
+// get listed once per namespace of the findings. Events of cluster-scoped objects like nodes are in
+// the namespace "default". Events created by the Events sink are skipped, since they only repeat the
+// finding. The redactions of the config apply to the messages of the Events, too. Failing to list
+// Events is no scan error, the findings just have no Events.
+func attachEvents(ctx context.Context, args *Arguments, findings []Finding, clientset kubernetes.Interface) {
+	byUID := make(map[types.UID][]int)
+	namespaces := make(map[string]bool)
+	for i := range findings {
+		f := &findings[i]
+		if f.UID == "" {
+			continue
+		}
+		byUID[f.UID] = append(byUID[f.UID], i)
+		namespace := f.Namespace
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		namespaces[namespace] = true
+	}
+	sortedNamespaces := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		sortedNamespaces = append(sortedNamespaces, namespace)
+	}
+	sort.Strings(sortedNamespaces)
+	related := make(map[types.UID][]RelatedEvent)
+	for _, namespace := range sortedNamespaces {
+		err := listEvents(ctx, clientset, namespace, func(e *corev1.Event) {
+			if _, ok := byUID[e.InvolvedObject.UID]; !ok || strings.HasPrefix(e.Name, eventNamePrefix) {
+				return
+			}
+			r := newRelatedEvent(e)
+			for _, redaction := range args.Config.Redactions {
+				r.Message = redaction.re.ReplaceAllString(r.Message, redaction.replacement)
+			}
+			related[e.InvolvedObject.UID] = append(related[e.InvolvedObject.UID], r)
+		})
+		if err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: listing the Events of namespace %s failed: %s\n", namespace, err.Error())
+		}
+	}
+	for uid, events := range related {
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].LastTimestamp.After(events[j].LastTimestamp)
+		})
+		if len(events) > maxRelatedEvents {
+			events = events[:maxRelatedEvents]
+		}
+		for _, i := range byUID[uid] {
+			findings[i].Events = events
+		}
+	}
+}
+
+// listEvents calls fn for each Event of the namespace, page by page.
+func listEvents(ctx context.Context, clientset kubernetes.Interface, namespace string, fn func(e *corev1.Event)) error {
+	opts := metav1.ListOptions{Limit: eventsPageSize}
+	for {
+		list, err := clientset.CoreV1().Events(namespace).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for i := range list.Items {
+			fn(&list.Items[i])
+		}
+		if list.Continue == "" {
+			return nil
+		}
+		opts.Continue = list.Continue
+	}
+}
+
+// newRelatedEvent returns the RelatedEvent of the Event. Events of the events.k8s.io API only
+// have eventTime and series instead of lastTimestamp and count.
+func newRelatedEvent(e *corev1.Event) RelatedEvent {
+	r := RelatedEvent{Type: e.Type, Reason: e.Reason, Message: e.Message, Count: e.Count, LastTimestamp: e.LastTimestamp.Time}
+	if e.Series != nil {
+		r.Count = e.Series.Count
+		r.LastTimestamp = e.Series.LastObservedTime.Time
+	}
+	if r.LastTimestamp.IsZero() {
+		r.LastTimestamp = e.EventTime.Time
+	}
+	return r
+}
+
+// eventLines returns the output lines of the Events of a finding.
+func eventLines(f *Finding, indent string, now time.Time) []string {
+	lines := make([]string, 0, len(f.Events))
+	for _, e := range f.Events {
+		count := ""
+		if e.Count > 1 {
+			count = fmt.Sprintf(" (x%d)", e.Count)
+		}
+		lines = append(lines, fmt.Sprintf("%s    event: %s %s %q%s %s ago", indent, e.Type, e.Reason, e.Message, count,
+			now.Sub(e.LastTimestamp).Round(time.Second)))
+	}
+	return lines
+}
@@ -0,0 +1,75 @@
+package checkconditions
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAttachEvents(t *testing.T) {
+	now := time.Now()
+	event := func(namespace, name string, uid types.UID, reason string, ago time.Duration) runtime.Object {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: namespace, Name: name},
+			InvolvedObject: corev1.ObjectReference{UID: uid},
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			Message:        reason + " for user alice@example.com",
+			Count:          2,
+			LastTimestamp:  metav1.NewTime(now.Add(-ago)),
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		event("a", "e1", "pod-uid", "Pulling", 4*time.Minute),
+		event("a", "e2", "pod-uid", "BackOff", time.Minute),
+		event("a", "e3", "pod-uid", "Failed", 2*time.Minute),
+		event("a", "e4", "pod-uid", "Scheduled", 10*time.Minute),
+		event("a", "e5", "other-uid", "Killing", time.Minute),
+		event("a", eventNamePrefix+"0123", "pod-uid", "ReadyFalse", 0),
+		event("default", "n1", "node-uid", "NodeNotReady", time.Minute),
+	)
+	findings := []Finding{
+		{Namespace: "a", Resource: "pods", Name: "p1", UID: "pod-uid", ConditionType: "Ready"},
+		{Namespace: "a", Resource: "pods", Name: "p1", UID: "pod-uid", ConditionType: "ContainersReady"},
+		{Resource: "nodes", Name: "n1", UID: "node-uid", ConditionType: "Ready"},
+		{Namespace: "b", Resource: "pods", Name: "p2", UID: "quiet-uid", ConditionType: "Ready"},
+	}
+	replacement := "<email>"
+	config := &Config{Redactions: []RedactionConfig{{Regex: `\S+@\S+`, Replacement: &replacement}}}
+	if err := config.Redactions[0].parse(); err != nil {
+		t.Fatal(err)
+	}
+	var diagnostics bytes.Buffer
+	attachEvents(t.Context(), &Arguments{Config: config, stderr: &diagnostics}, findings, clientset)
+
+	reasons := func(f *Finding) string {
+		var r []string
+		for _, e := range f.Events {
+			r = append(r, e.Reason)
+		}
+		return strings.Join(r, ",")
+	}
+	for i, want := range []string{"BackOff,Failed,Pulling", "BackOff,Failed,Pulling", "NodeNotReady", ""} {
+		if got := reasons(&findings[i]); got != want {
+			t.Errorf("finding %d: events %q, want %q (newest first, without the Events of the sink)", i, got, want)
+		}
+	}
+	if msg := findings[0].Events[0].Message; msg != "BackOff for user <email>" {
+		t.Errorf("message %q is not redacted", msg)
+	}
+	lines := eventLines(&findings[2], "", now)
+	if len(lines) != 1 || !regexp.MustCompile(`^    event: Warning NodeNotReady ".*" \(x2\) 1m0s ago$`).MatchString(lines[0]) {
+		t.Errorf("unexpected lines %q", lines)
+	}
+	if diagnostics.Len() != 0 {
+		t.Errorf("unexpected diagnostics %q", diagnostics.String())
+	}
+}
@@ -0,0 +1,132 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// configPollInterval is the time between two checks of the config file for changes in serve mode.
+const configPollInterval = 10 * time.Second
+
+// watchConfig checks the config file for changes, and triggers a scan, which reloads the config first.
+func (s *server) watchConfig() {
+	hash := s.args.configHash
+	for {
+		time.Sleep(configPollInterval)
+		data, err := os.ReadFile(s.args.ConfigFile)
+		if err != nil {
+			continue
+		}
+		if h := hashConfig(data); h != hash {
+			hash = h
+			select {
+			case s.configChanged <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// reloadConfig reads the config file or the ClusterCheckConfig again, if it changed. Rules,
+// filters and sinks of the new config are used for the next scans, and the changed blocks get
+// logged. The new config gets validated together with the flags (like startup does), before it
+// replaces the previous one. If it is invalid, the previous config stays active. The in-memory
+// state (history, notifications) is kept.
+func (s *server) reloadConfig() {
+	data, err := s.readConfig()
+	if err != nil || data == nil {
+		return
+	}
+	s.scanMutex.Lock()
+	defer s.scanMutex.Unlock()
+	if hashConfig(data) == s.args.configHash {
+		return
+	}
+	next := s.args
+	err = next.parseConfig(data)
+	if err == nil {
+		err = next.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(s.args.diagnostics(), "WARNING: config changed, but the previous config stays active: %s\n", err.Error())
+		// Remember the hash, so that the warning is printed once per change.
+		s.args.configHash = next.configHash
+		return
+	}
+	for _, line := range configDiff(s.args.Config, next.Config) {
+		fmt.Fprintln(s.args.diagnostics(), line)
+	}
+	next.createWriters(next.diagnostics())
+	s.args = next
+}
+
+// readConfig returns the content of the config file, or the spec of the ClusterCheckConfig. It
+// returns nil without config.
+func (s *server) readConfig() ([]byte, error) {
+	if s.args.ConfigResource != "" {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return s.configResource, nil
+	}
+	if s.args.ConfigFile == "" {
+		return nil, nil
+	}
+	return os.ReadFile(s.args.ConfigFile)
+}
+
+// configDiff returns a line for each top-level block of the config which was added, removed or changed.
+func configDiff(previous, next *Config) []string {
+	a, b := configBlocks(previous), configBlocks(next)
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	var lines []string
+	for k := range keys {
+		var change string
+		switch {
+		case a[k] == "":
+			change = "added"
+		case b[k] == "":
+			change = "removed"
+		case a[k] != b[k]:
+			change = "changed"
+		default:
+			continue
+		}
+		if k == "serve" {
+			change += " (needs a restart)"
+		}
+		lines = append(lines, fmt.Sprintf("Config reloaded: %s %s", k, change))
+	}
+	sort.Strings(lines)
+	if len(lines) == 0 {
+		lines = append(lines, "Config reloaded: no changes")
+	}
+	return lines
+}
+
+// configBlocks returns the JSON of the top-level blocks of the config, which are not empty.
+func configBlocks(config *Config) map[string]string {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	blocks := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s := string(v); s != "null" && s != "[]" && s != "{}" {
+			blocks[k] = s
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,125 @@
+package checkconditions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestReloadConfigConditionRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hasRule := func(s *server, conditionType string) bool {
+		return slices.Contains(s.args.conditionRules().positive["widgets"], conditionType)
+	}
+	writeConfig("conditionRules: [{resource: widgets, type: Spinning, meaning: positive}]\n")
+	s := &server{args: Arguments{ConfigFile: path}}
+	if err := s.args.ReadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	if !hasRule(s, "Spinning") {
+		t.Fatal("rule of the config file is missing")
+	}
+
+	// Invalid: grafana needs url.
+	writeConfig("conditionRules: [{resource: widgets, type: Rolling, meaning: positive}]\ngrafana: {}\n")
+	s.reloadConfig()
+	if hasRule(s, "Rolling") || !hasRule(s, "Spinning") {
+		t.Fatal("rules of a rejected config got applied")
+	}
+	if slices.Contains(builtinConditionRules.positive["widgets"], "Rolling") {
+		t.Fatal("built-in rules got modified")
+	}
+
+	writeConfig("conditionRules: [{resource: widgets, type: Rolling, meaning: positive}]\n")
+	s.reloadConfig()
+	if !hasRule(s, "Rolling") || hasRule(s, "Spinning") {
+		t.Fatal("rules of the reloaded config are not active")
+	}
+}
+
+func TestReloadConfigResource(t *testing.T) {
+	newConfig := func(rules ...interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"conditionRules": rules},
+		}}
+		obj.SetAPIVersion(clusterCheckConfigGVR.GroupVersion().String())
+		obj.SetKind("ClusterCheckConfig")
+		obj.SetName("default")
+		return obj
+	}
+	rule := func(conditionType string) interface{} {
+		return map[string]interface{}{"resource": "widgets", "type": conditionType, "meaning": "positive"}
+	}
+	hasRule := func(s *server, conditionType string) bool {
+		return slices.Contains(s.args.conditionRules().positive["widgets"], conditionType)
+	}
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{clusterCheckConfigGVR: "ClusterCheckConfigList"}, newConfig(rule("Spinning")))
+	configs := dynClient.Resource(clusterCheckConfigGVR)
+
+	args := Arguments{ConfigResource: "default"}
+	if err := args.readConfigResource(dynClient); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{args: args, configChanged: make(chan struct{}, 1)}
+	if !hasRule(s, "Spinning") {
+		t.Fatal("rule of the ClusterCheckConfig is missing")
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.watchConfigResource(dynClient, stop)
+	waitChanged := func() {
+		t.Helper()
+		select {
+		case <-s.configChanged:
+		case <-time.After(10 * time.Second):
+			t.Fatal("the change of the ClusterCheckConfig was not noticed")
+		}
+	}
+	waitChanged()
+	s.reloadConfig()
+	if !hasRule(s, "Spinning") {
+		t.Fatal("rule got lost by reloading the unchanged ClusterCheckConfig")
+	}
+
+	// Invalid: the rule needs a type.
+	if _, err := configs.Update(context.Background(), newConfig(rule("")), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitChanged()
+	s.reloadConfig()
+	if !hasRule(s, "Spinning") {
+		t.Fatal("rules of a rejected ClusterCheckConfig got applied")
+	}
+
+	if _, err := configs.Update(context.Background(), newConfig(rule("Rolling")), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitChanged()
+	s.reloadConfig()
+	if !hasRule(s, "Rolling") || hasRule(s, "Spinning") {
+		t.Fatal("rules of the changed ClusterCheckConfig are not active")
+	}
+
+	if err := configs.Delete(context.Background(), "default", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	s.reloadConfig()
+	if !hasRule(s, "Rolling") {
+		t.Fatal("rules got lost after the ClusterCheckConfig was deleted")
+	}
+}
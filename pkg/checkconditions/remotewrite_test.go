@@ -0,0 +1,157 @@
+package checkconditions
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodedSeries is a TimeSeries of a decoded WriteRequest.
+type decodedSeries struct {
+	labels    [][2]string
+	value     float64
+	timestamp int64
+}
+
+// consumeFields calls fn for each field of the protobuf message.
+func consumeFields(t *testing.T, b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("invalid tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		n = fn(num, typ, b)
+		if n < 0 {
+			t.Fatalf("invalid field %d: %v", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+}
+
+func decodeWriteRequest(t *testing.T, b []byte) []decodedSeries {
+	t.Helper()
+	var series []decodedSeries
+	consumeFields(t, b, func(_ protowire.Number, _ protowire.Type, b []byte) int {
+		ts, n := protowire.ConsumeBytes(b)
+		var s decodedSeries
+		consumeFields(t, ts, func(num protowire.Number, _ protowire.Type, b []byte) int {
+			msg, n := protowire.ConsumeBytes(b)
+			switch num {
+			case 1:
+				var label [2]string
+				consumeFields(t, msg, func(num protowire.Number, _ protowire.Type, b []byte) int {
+					v, n := protowire.ConsumeString(b)
+					label[num-1] = v
+					return n
+				})
+				s.labels = append(s.labels, label)
+			case 2:
+				consumeFields(t, msg, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					if num == 1 {
+						v, n := protowire.ConsumeFixed64(b)
+						s.value = math.Float64frombits(v)
+						return n
+					}
+					v, n := protowire.ConsumeVarint(b)
+					s.timestamp = int64(v)
+					return n
+				})
+			}
+			return n
+		})
+		series = append(series, s)
+		return n
+	})
+	return series
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 12, 3, 0, time.UTC)
+	tests := []struct {
+		name    string
+		metrics []metric
+		want    []decodedSeries
+	}{
+		{
+			name: "no metrics",
+		},
+		{
+			name:    "gauge without labels",
+			metrics: []metric{{name: "check_conditions_health_score", samples: []sample{{value: 97}}}},
+			want: []decodedSeries{{
+				labels:    [][2]string{{"__name__", "check_conditions_health_score"}, {"job", "check-conditions"}},
+				value:     97,
+				timestamp: now.UnixMilli(),
+			}},
+		},
+		{
+			name: "labels get sorted by name",
+			metrics: []metric{{name: "check_conditions_findings", samples: []sample{
+				{labels: [][2]string{{"severity", "critical"}, {"namespace", "default"}}, value: 2},
+				{labels: [][2]string{{"severity", "warning"}, {"namespace", "kube-system"}}, value: 0.5},
+			}}},
+			want: []decodedSeries{
+				{
+					labels: [][2]string{
+						{"__name__", "check_conditions_findings"}, {"job", "check-conditions"},
+						{"namespace", "default"}, {"severity", "critical"},
+					},
+					value:     2,
+					timestamp: now.UnixMilli(),
+				},
+				{
+					labels: [][2]string{
+						{"__name__", "check_conditions_findings"}, {"job", "check-conditions"},
+						{"namespace", "kube-system"}, {"severity", "warning"},
+					},
+					value:     0.5,
+					timestamp: now.UnixMilli(),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodeWriteRequest(t, encodeWriteRequest(tt.metrics, now))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d time series, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !slices.Equal(got[i].labels, tt.want[i].labels) {
+					t.Errorf("series %d: labels %v, want %v", i, got[i].labels, tt.want[i].labels)
+				}
+				if got[i].value != tt.want[i].value || got[i].timestamp != tt.want[i].timestamp {
+					t.Errorf("series %d: sample %v@%d, want %v@%d", i, got[i].value, got[i].timestamp,
+						tt.want[i].value, tt.want[i].timestamp)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRemoteWriteHeader(t *testing.T) {
+	tests := []struct {
+		header  string
+		wantErr bool
+	}{
+		{header: "X-Scope-OrgID=tenant1"},
+		{header: "Authorization=Basic dXNlcjpwYXNz"},
+		{header: "X-Scope-OrgID", wantErr: true},
+		{header: "=tenant1", wantErr: true},
+		{header: "X-Scope-OrgID=", wantErr: true},
+		{header: "X Scope=tenant1", wantErr: true},
+		{header: "content-type=text/plain", wantErr: true},
+		{header: "Content-Encoding=gzip", wantErr: true},
+		{header: "X-Prometheus-Remote-Write-Version=2.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateRemoteWriteHeader(tt.header); (err != nil) != tt.wantErr {
+			t.Errorf("validateRemoteWriteHeader(%q) = %v, wantErr %t", tt.header, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package checkconditions
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// report is the structured result of a scan. It gets written to --output-file.
+type report struct {
+	Build                 buildMetadata   `json:"build"`
+	Scope                 reportScope     `json:"scope"`
+	ScanTime              time.Time       `json:"scanTime"`
+	Duration              string          `json:"duration"`
+	CheckedResourceTypes  int32           `json:"checkedResourceTypes"`
+	CheckedResources      int32           `json:"checkedResources"`
+	CheckedConditions     int32           `json:"checkedConditions"`
+	Score                 int             `json:"score"`
+	Findings              []Finding       `json:"findings"`
+	Pending               []Finding       `json:"pending"`
+	Skipped               []reportSkipped `json:"skipped"`
+	Errors                []scanError     `json:"errors"`
+	UnknownConditionTypes map[string]int  `json:"unknownConditionTypes,omitempty"`
+
+	// counter is the scan of the report. It is used by --output prometheus.
+	counter *Counter
+}
+
+// reportSkipped is a resource type which was not listed completely.
+type reportSkipped struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	Reason   string `json:"reason"`
+}
+
+func newReport(args *Arguments, counter *Counter, findings []Finding) *report {
+	r := &report{
+		Build:                 newBuildMetadata(),
+		Scope:                 newReportScope(args, counter),
+		ScanTime:              counter.startTime,
+		Duration:              time.Since(counter.startTime).Round(time.Millisecond).String(),
+		CheckedResourceTypes:  counter.checkedResourceTypes,
+		CheckedResources:      counter.checkedResources,
+		CheckedConditions:     counter.checkedConditions,
+		Score:                 counter.score,
+		Findings:              findings,
+		Pending:               counter.pending,
+		Skipped:               make([]reportSkipped, 0, len(counter.skipped)),
+		Errors:                counter.errors,
+		UnknownConditionTypes: counter.unknownConditionTypes,
+		counter:               counter,
+	}
+	if r.Findings == nil {
+		r.Findings = []Finding{}
+	}
+	if r.Pending == nil {
+		r.Pending = []Finding{}
+	}
+	if r.Errors == nil {
+		r.Errors = []scanError{}
+	}
+	for _, s := range counter.skipped {
+		r.Skipped = append(r.Skipped, reportSkipped{
+			Group:    s.gvr.Group,
+			Version:  s.gvr.Version,
+			Resource: s.gvr.Resource,
+			Reason:   s.reason,
+		})
+	}
+	return r
+}
+
+// writeOutputFile writes the report in the format to the file. See writeFileAtomic.
+func writeOutputFile(path, format string, r *report) error {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, format, r); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("writing output file failed: %w", err)
+	}
+	return nil
+}
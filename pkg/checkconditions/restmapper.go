@@ -0,0 +1,98 @@
+package checkconditions
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/restmapper"
+)
+
+// newRESTMapper returns a RESTMapper which resolves resources, kinds and short names (like kubectl)
+// via discovery. Groups which fail discovery are missing.
+func newRESTMapper(dc discovery.DiscoveryInterface) (meta.RESTMapper, error) {
+	groupResources, err := restmapper.GetAPIGroupResources(dc)
+	if err != nil && len(groupResources) == 0 {
+		return nil, err
+	}
+	return restmapper.NewShortcutExpander(restmapper.NewDiscoveryRESTMapper(groupResources), dc), nil
+}
+
+// resolveResource resolves an argument like "deploy", "Deployment", "deployments.apps" or
+// "deployments.v1.apps" to a resource. Without version, the preferred version gets used.
+func resolveResource(mapper meta.RESTMapper, arg string) (schema.GroupVersionResource, error) {
+	fullySpecified, groupResource := schema.ParseResourceArg(arg)
+	if fullySpecified != nil {
+		if gvr, err := mapper.ResourceFor(*fullySpecified); err == nil {
+			return gvr, nil
+		}
+	}
+	if gvr, err := mapper.ResourceFor(groupResource.WithVersion("")); err == nil {
+		return gvr, nil
+	}
+	fullySpecifiedKind, groupKind := schema.ParseKindArg(arg)
+	if fullySpecifiedKind == nil {
+		gvk := groupKind.WithVersion("")
+		fullySpecifiedKind = &gvk
+	}
+	mapping, err := mapper.RESTMapping(fullySpecifiedKind.GroupKind(), fullySpecifiedKind.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("unknown resource type %q", arg)
+	}
+	return mapping.Resource, nil
+}
+
+// selectResources returns only the resource types given as arguments, in the resolved version.
+func selectResources(dc discovery.DiscoveryInterface, serverResources []*metav1.APIResourceList,
+	args []string,
+) ([]*metav1.APIResourceList, error) {
+	mapper, err := newRESTMapper(dc)
+	if err != nil {
+		return nil, err
+	}
+	var selected []*metav1.APIResourceList
+	for _, arg := range args {
+		gvr, err := resolveResource(mapper, arg)
+		if err != nil {
+			return nil, err
+		}
+		resource, err := findAPIResource(dc, serverResources, gvr)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, &metav1.APIResourceList{
+			GroupVersion: gvr.GroupVersion().String(),
+			APIResources: []metav1.APIResource{*resource},
+		})
+	}
+	return selected, nil
+}
+
+// findAPIResource returns the APIResource of the preferred resources. For other versions, the
+// resources of the version get discovered.
+func findAPIResource(dc discovery.DiscoveryInterface, serverResources []*metav1.APIResourceList,
+	gvr schema.GroupVersionResource,
+) (*metav1.APIResource, error) {
+	var list *metav1.APIResourceList
+	for _, l := range serverResources {
+		if l.GroupVersion == gvr.GroupVersion().String() {
+			list = l
+			break
+		}
+	}
+	if list == nil {
+		var err error
+		list, err = dc.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range list.APIResources {
+		if list.APIResources[i].Name == gvr.Resource {
+			return &list.APIResources[i], nil
+		}
+	}
+	return nil, fmt.Errorf("resource %s not found via discovery", gvr.String())
+}
@@ -0,0 +1,143 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SARIF 2.1.0, reduced to the fields needed for GitHub code scanning.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          *sarifProperties  `json:"properties,omitempty"`
+}
+
+// sarifProperties is the property bag of a result. It contains the fields of --history-file.
+type sarifProperties struct {
+	FirstSeen   *time.Time `json:"firstSeen,omitempty"`
+	LastSeen    *time.Time `json:"lastSeen,omitempty"`
+	Occurrences int        `json:"occurrences,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifLevels maps the severity of a finding to the level of a SARIF result.
+var sarifLevels = map[string]string{
+	SeverityCritical: "error",
+	SeverityWarning:  "warning",
+}
+
+// selfLink returns the path of the object of the finding in the API of the api-server.
+func (f *Finding) selfLink() string {
+	parts := []string{"/apis", f.Group, f.Version}
+	if f.Group == "" {
+		parts = []string{"/api", f.Version}
+	}
+	if f.Namespace != "" {
+		parts = append(parts, "namespaces", f.Namespace)
+	}
+	parts = append(parts, f.Resource, f.Name)
+	return strings.Join(parts, "/")
+}
+
+// writeSARIF writes the findings as SARIF 2.1.0 log. The condition type is the rule ID, and the
+// self-link of the object is the location.
+func writeSARIF(w io.Writer, r *report) error {
+	rules := make(map[string]bool)
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "check-conditions",
+			Version:        r.Build.Version,
+			InformationURI: "https://github.com/guettli/check-conditions",
+			Rules:          []sarifRule{},
+		}},
+		Results: make([]sarifResult, 0, len(r.Findings)),
+	}
+	for i := range r.Findings {
+		f := &r.Findings[i]
+		rules[f.ConditionType] = true
+		message := strings.TrimSpace(strings.Join([]string{
+			f.Kind, f.Name, f.ConditionType + "=" + f.ConditionStatus, f.ConditionReason, f.ConditionMessage,
+		}, " "))
+		var properties *sarifProperties
+		if f.FirstSeen != nil {
+			properties = &sarifProperties{FirstSeen: f.FirstSeen, LastSeen: f.LastSeen, Occurrences: f.Occurrences}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  f.ConditionType,
+			Level:   sarifLevels[f.Severity],
+			Message: sarifMessage{Text: message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: strings.TrimPrefix(f.selfLink(), "/")},
+			}}},
+			PartialFingerprints: map[string]string{"findingID/v1": f.hash()},
+			Properties:          properties,
+		})
+	}
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: "Condition " + id + " needs attention"},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
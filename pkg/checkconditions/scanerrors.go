@@ -0,0 +1,94 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+const (
+	scanErrorDiscovery = "discovery"
+	scanErrorForbidden = "forbidden"
+	scanErrorTimeout   = "timeout"
+	scanErrorList      = "list"
+)
+
+// scanError is an error which prevented checking a resource type. Scan errors get written as JSON
+// lines to stderr (or --errors-file), so that wrappers can distinguish "cluster unhealthy" (findings
+// on stdout) from "scan broken".
+type scanError struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Group    string    `json:"group"`
+	Version  string    `json:"version"`
+	Resource string    `json:"resource,omitempty"`
+	Message  string    `json:"message"`
+
+	// Namespace is set, if the LIST of a single namespace failed. See listNamespaces.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func newScanError(gvr schema.GroupVersionResource, err error) scanError {
+	errorType := scanErrorList
+	if apierrors.IsForbidden(err) {
+		errorType = scanErrorForbidden
+	}
+	return scanError{
+		Time:     time.Now(),
+		Type:     errorType,
+		Group:    gvr.Group,
+		Version:  gvr.Version,
+		Resource: gvr.Resource,
+		Message:  err.Error(),
+	}
+}
+
+// discoveryErrors returns one scan error per group version which failed during discovery.
+func discoveryErrors(err error) []scanError {
+	var failed *discovery.ErrGroupDiscoveryFailed
+	if !errors.As(err, &failed) {
+		return nil
+	}
+	var result []scanError
+	for gv, groupErr := range failed.Groups {
+		result = append(result, scanError{
+			Time:    time.Now(),
+			Type:    scanErrorDiscovery,
+			Group:   gv.Group,
+			Version: gv.Version,
+			Message: groupErr.Error(),
+		})
+	}
+	return result
+}
+
+// writeScanErrors writes the scan errors as JSON lines to --errors-file, or to stderr.
+func writeScanErrors(args *Arguments, scanErrors []scanError) {
+	if len(scanErrors) == 0 {
+		return
+	}
+	var w io.Writer = os.Stderr
+	if args.ErrorsFile != "" {
+		file, err := os.OpenFile(args.ErrorsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gomnd
+		if err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: writing errors file failed: %s\n", err.Error())
+			return
+		}
+		defer file.Close()
+		w = file
+	}
+	enc := json.NewEncoder(w)
+	for i := range scanErrors {
+		if err := enc.Encode(&scanErrors[i]); err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: writing scan errors failed: %s\n", err.Error())
+			return
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package checkconditions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// reportScope is the effective scope of a scan. If the fingerprints of two reports differ, changed
+// numbers can be caused by the changed scope, not by drift of the cluster.
+type reportScope struct {
+	Fingerprint string `json:"fingerprint"`
+
+	// Cluster is the cluster of the context of the kubeconfig. ClusterID is the UID of the
+	// namespace kube-system, which identifies the cluster independent of the kubeconfig.
+	// ClusterIDError tells why ClusterID is missing, for example missing RBAC. It is not part of
+	// the fingerprint.
+	Cluster        string    `json:"cluster,omitempty"`
+	ClusterID      types.UID `json:"clusterID,omitempty"`
+	ClusterIDError string    `json:"clusterIDError,omitempty"`
+
+	Namespace     string   `json:"namespace,omitempty"`
+	Namespaces    []string `json:"namespaces,omitempty"`
+	LabelSelector string   `json:"labelSelector,omitempty"`
+	Team          string   `json:"team,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+	AllVersions   []string `json:"allVersions,omitempty"`
+	Profile       string   `json:"profile,omitempty"`
+	Strict        bool     `json:"strict,omitempty"`
+	MaxObjects    int64    `json:"maxObjects,omitempty"`
+
+	// The durations limit, which findings are reported and which resource types are listed.
+	GracePeriod    string   `json:"gracePeriod,omitempty"`
+	MaxDuration    string   `json:"maxDuration,omitempty"`
+	PerTypeTimeout string   `json:"perTypeTimeout,omitempty"`
+	Checks         []string `json:"checks"`
+
+	// ConfigHash is the sha256 of the config file, which contains the rules. The built-in rules
+	// depend on the version of the binary.
+	ConfigHash string `json:"configHash,omitempty"`
+	Version    string `json:"version"`
+}
+
+func newReportScope(args *Arguments, counter *Counter) reportScope {
+	scope := reportScope{
+		Cluster:        args.clusterName,
+		ClusterID:      counter.clusterID,
+		Namespace:      args.Namespace,
+		Namespaces:     args.Namespaces,
+		LabelSelector:  args.LabelSelector,
+		Team:           args.Team,
+		Resources:      args.Resources,
+		AllVersions:    args.AllVersions,
+		Profile:        args.Profile,
+		Strict:         args.Strict,
+		MaxObjects:     args.MaxObjects,
+		Checks:         []string{},
+		GracePeriod:    durationString(args.GracePeriod),
+		MaxDuration:    durationString(args.MaxDuration),
+		PerTypeTimeout: durationString(args.PerTypeTimeout),
+		ConfigHash:     args.configHash,
+		Version:        newBuildMetadata().Version,
+	}
+	for _, c := range checkDefinitions {
+		if args.checkEnabled(c.id) {
+			scope.Checks = append(scope.Checks, c.id)
+		}
+	}
+	data, _ := json.Marshal(scope)
+	sum := sha256.Sum256(data)
+	scope.Fingerprint = hex.EncodeToString(sum[:8])
+	scope.ClusterIDError = counter.clusterIDError
+	return scope
+}
+
+// durationString returns the duration for the scope. It is empty for zero.
+func durationString(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// getClusterID gets the namespace kube-system, if the scan did not list it. This happens with
+// --namespace or --resources. The error is returned as message, since a missing ClusterID does
+// not make the scan fail.
+func getClusterID(clientset kubernetes.Interface) (types.UID, string) {
+	ns, err := clientset.CoreV1().Namespaces().Get(context.TODO(), "kube-system", metav1.GetOptions{})
+	if err != nil {
+		return "", err.Error()
+	}
+	return ns.UID, ""
+}
@@ -0,0 +1,61 @@
+package checkconditions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReportScopeFingerprint(t *testing.T) {
+	base := func() (*Arguments, *Counter) {
+		return &Arguments{
+			Namespaces:    []string{"a", "b"},
+			LabelSelector: "app=web",
+			GracePeriod:   time.Minute,
+			MaxObjects:    1000,
+			clusterName:   "prod",
+			configHash:    "abc",
+		}, &Counter{
+			clusterID: "uid-1",
+		}
+	}
+	fingerprint := func(modify func(*Arguments, *Counter)) string {
+		args, counter := base()
+		modify(args, counter)
+		return newReportScope(args, counter).Fingerprint
+	}
+	unchanged := fingerprint(func(*Arguments, *Counter) {})
+	if again := fingerprint(func(*Arguments, *Counter) {}); again != unchanged {
+		t.Fatalf("fingerprint is not stable: %s != %s", again, unchanged)
+	}
+	tests := []struct {
+		name    string
+		modify  func(*Arguments, *Counter)
+		changed bool
+	}{
+		{name: "cluster name", modify: func(a *Arguments, _ *Counter) { a.clusterName = "staging" }, changed: true},
+		{name: "cluster ID", modify: func(_ *Arguments, c *Counter) { c.clusterID = "uid-2" }, changed: true},
+		{name: "namespaces", modify: func(a *Arguments, _ *Counter) { a.Namespaces = []string{"a"} }, changed: true},
+		{name: "label selector", modify: func(a *Arguments, _ *Counter) { a.LabelSelector = "" }, changed: true},
+		{name: "max objects", modify: func(a *Arguments, _ *Counter) { a.MaxObjects = 0 }, changed: true},
+		{name: "grace period", modify: func(a *Arguments, _ *Counter) { a.GracePeriod = 0 }, changed: true},
+		{name: "max duration", modify: func(a *Arguments, _ *Counter) { a.MaxDuration = time.Minute }, changed: true},
+		{name: "per-type timeout", modify: func(a *Arguments, _ *Counter) { a.PerTypeTimeout = time.Second }, changed: true},
+		{name: "config", modify: func(a *Arguments, _ *Counter) { a.configHash = "def" }, changed: true},
+		{
+			name:    "checks",
+			modify:  func(a *Arguments, _ *Counter) { a.enabledChecks = map[string]bool{checkConditions: true} },
+			changed: true,
+		},
+		{name: "cluster ID error", modify: func(_ *Arguments, c *Counter) { c.clusterIDError = "forbidden" }},
+		{name: "findings", modify: func(_ *Arguments, c *Counter) { c.findings = []Finding{{Name: "x"}} }},
+		{name: "output", modify: func(a *Arguments, _ *Counter) { a.Output = OutputJSON }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fingerprint(tt.modify)
+			if (got != unchanged) != tt.changed {
+				t.Errorf("fingerprint %s, unchanged %s, want changed %t", got, unchanged, tt.changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package checkconditions
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// ScoreConfig configures the weights of the health score. Values which are not set use the defaults.
+type ScoreConfig struct {
+	// Scale is the penalty which results in a score of 50. A score of 100 means no findings. Default: 100.
+	Scale float64 `json:"scale"`
+
+	// SeverityWeights is the penalty of a finding, depending on its severity. Default: critical 10, warning 3.
+	SeverityWeights map[string]float64 `json:"severityWeights"`
+
+	// KindWeights multiplies the penalty of findings of some kinds. Default: Node 3. Other kinds have the weight 1.
+	KindWeights map[string]float64 `json:"kindWeights"`
+
+	// CheckWeights multiplies the penalty of findings of a check. Checks which are missing have the weight 1.
+	CheckWeights map[string]float64 `json:"checkWeights"`
+}
+
+func defaultScoreConfig() ScoreConfig {
+	return ScoreConfig{
+		Scale: 100, //nolint:gomnd
+		SeverityWeights: map[string]float64{
+			SeverityCritical: 10, //nolint:gomnd
+			SeverityWarning:  3,  //nolint:gomnd
+		},
+		KindWeights: map[string]float64{
+			"Node": 3, //nolint:gomnd
+		},
+	}
+}
+
+// withDefaults returns the config, with defaults for the values which are not set.
+func (c *ScoreConfig) withDefaults() ScoreConfig {
+	result := defaultScoreConfig()
+	if c == nil {
+		return result
+	}
+	if c.Scale > 0 {
+		result.Scale = c.Scale
+	}
+	for severity, w := range c.SeverityWeights {
+		result.SeverityWeights[severity] = w
+	}
+	for kind, w := range c.KindWeights {
+		result.KindWeights[kind] = w
+	}
+	result.CheckWeights = c.CheckWeights
+	return result
+}
+
+// problem is a group of findings with the same kind, condition type, status and reason.
+type problem struct {
+	kind, conditionType, conditionStatus, reason string
+	findings                                     int
+	penalty                                      float64
+}
+
+// healthScore returns a score between 0 (very bad) and 100 (no findings), and the problems
+// sorted by their penalty.
+func healthScore(config *ScoreConfig, findings []Finding) (int, []problem) {
+	weights := config.withDefaults()
+	problems := make(map[problem]*problem)
+	total := 0.0
+	for i := range findings {
+		f := &findings[i]
+		penalty := weights.SeverityWeights[f.Severity]
+		if w, ok := weights.KindWeights[f.Kind]; ok {
+			penalty *= w
+		}
+		if w, ok := weights.CheckWeights[f.Check]; ok {
+			penalty *= w
+		}
+		total += penalty
+		key := problem{kind: f.Kind, conditionType: f.ConditionType, conditionStatus: f.ConditionStatus, reason: f.ConditionReason}
+		p, ok := problems[key]
+		if !ok {
+			p = &key
+			problems[key] = p
+		}
+		p.findings++
+		p.penalty += penalty
+	}
+	result := make([]problem, 0, len(problems))
+	for _, p := range problems {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].penalty != result[j].penalty {
+			return result[i].penalty > result[j].penalty
+		}
+		return fmt.Sprint(result[i]) < fmt.Sprint(result[j])
+	})
+	score := int(math.Round(100 * weights.Scale / (weights.Scale + total))) //nolint:gomnd
+	return score, result
+}
+
+// maxTopProblems is the number of problems which get printed by the doctor command.
+const maxTopProblems = 10
+
+// RunDoctor checks all conditions once and prints a health score with the top contributing problems.
+func RunDoctor(args Arguments) {
+	if args.Plan {
+		RunPlan(args)
+		return
+	}
+	if args.Config == nil {
+		args.Config = &Config{}
+	}
+	config, err := newRestConfig(&args)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	counter, err := scan(config, &args)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	printSkipped(args.diagnostics(), counter.skipped)
+	findings := counter.findings
+	score, problems := healthScore(args.Config.Score, findings)
+	fmt.Printf("Health score: %d/100 (%d findings in %d resources)\n", score, len(findings), counter.checkedResources)
+	if len(problems) == 0 {
+		return
+	}
+	fmt.Println("Top problems:")
+	total := 0.0
+	for _, p := range problems {
+		total += p.penalty
+	}
+	for i, p := range problems {
+		if i == maxTopProblems {
+			fmt.Printf("  ... and %d more\n", len(problems)-maxTopProblems)
+			break
+		}
+		fmt.Printf("  %3.0f%% %s %s=%s %s (count: %d)\n", 100*p.penalty/total, p.kind, p.conditionType, //nolint:gomnd
+			p.conditionStatus, p.reason, p.findings)
+	}
+}
@@ -0,0 +1,153 @@
+package checkconditions
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSeenReport returns testReport, where the pod finding has the fields of --history-file.
+func testSeenReport() *report {
+	r := testReport()
+	firstSeen, lastSeen := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC), time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	r.Findings[0].FirstSeen, r.Findings[0].LastSeen, r.Findings[0].Occurrences = &firstSeen, &lastSeen, 3
+	return r
+}
+
+func TestSeenFieldsInReports(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		check  func(t *testing.T, out string)
+	}{
+		{OutputJSON, func(t *testing.T, out string) {
+			wantContains(t, out, `"firstSeen": "2026-10-15T08:00:00Z"`, `"lastSeen": "2026-10-16T08:00:00Z"`, `"occurrences": 3`)
+		}},
+		{OutputYAML, func(t *testing.T, out string) {
+			wantContains(t, out, "firstSeen: \"2026-10-15T08:00:00Z\"", "occurrences: 3")
+		}},
+		{OutputMarkdown, func(t *testing.T, out string) {
+			wantContains(t, out, "| Since | First seen | Last seen | Occurrences |\n",
+				"| 2026-10-15 08:00 | 2026-10-16 08:00 | 3 |\n", "|  |  |  |\n")
+		}},
+		{OutputGitHub, func(t *testing.T, out string) {
+			wantContains(t, out, "(first seen 2026-10-15T08:00:00Z, 3 scans)\n")
+		}},
+		{OutputJUnit, func(t *testing.T, out string) {
+			var got junitTestSuites
+			if err := xml.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("invalid XML: %v", err)
+			}
+			for _, s := range got.Suites {
+				for _, c := range s.Cases {
+					if c.Failure != nil && strings.Contains(c.Name, "web-1") {
+						wantContains(t, c.Failure.Text, ", 3 scans]")
+						return
+					}
+				}
+			}
+			t.Errorf("no failure of web-1:\n%s", out)
+		}},
+		{OutputSARIF, func(t *testing.T, out string) {
+			var got sarifLog
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			results := got.Runs[0].Results
+			if p := results[0].Properties; p == nil || p.Occurrences != 3 || !p.FirstSeen.Equal(time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)) ||
+				!p.LastSeen.Equal(time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)) {
+				t.Errorf("unexpected properties %+v", p)
+			}
+			if results[1].Properties != nil {
+				t.Errorf("properties of a finding without history: %+v", results[1].Properties)
+			}
+		}},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeReport(&buf, tc.format, testSeenReport()); err != nil {
+				t.Fatal(err)
+			}
+			tc.check(t, buf.String())
+		})
+	}
+
+	// Without --history-file the markdown table has no columns for it.
+	var buf bytes.Buffer
+	if err := writeReport(&buf, OutputMarkdown, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "First seen") {
+		t.Errorf("markdown without history has the column First seen:\n%s", buf.String())
+	}
+}
+
+func TestSeenFieldsInText(t *testing.T) {
+	r := testSeenReport()
+	var buf bytes.Buffer
+	printFindings(&buf, &Arguments{Config: &Config{}}, r.Findings, &Counter{})
+	wantContains(t, buf.String(), ", 3 scans]\n")
+
+	buf.Reset()
+	printFindings(&buf, &Arguments{Config: &Config{}, Output: OutputWide}, r.Findings, &Counter{})
+	wantContains(t, buf.String(), " firstSeen=2026-10-15T08:00:00Z lastSeen=2026-10-16T08:00:00Z")
+
+	buf.Reset()
+	if err := dashboardTemplate.Execute(&buf, dashboardData{Scanned: true, scanResponse: scanResponse{Findings: r.Findings}}); err != nil {
+		t.Fatal(err)
+	}
+	wantContains(t, buf.String(), "<td>2026-10-15 08:00:00 (3 scans)</td>")
+
+	msg := newFindingMessage(&r.Findings[0])
+	if !msg.GetFirstSeen().AsTime().Equal(*r.Findings[0].FirstSeen) || !msg.GetLastSeen().AsTime().Equal(*r.Findings[0].LastSeen) ||
+		msg.GetOccurrences() != 3 {
+		t.Errorf("unexpected gRPC finding %v", msg)
+	}
+	if msg := newFindingMessage(&r.Findings[1]); msg.GetFirstSeen() != nil || msg.GetOccurrences() != 0 {
+		t.Errorf("gRPC finding without history has seen fields: %v", msg)
+	}
+}
+
+func TestSeenFieldsInStream(t *testing.T) {
+	firstSeen := time.Now().Add(-time.Hour)
+	open := Finding{Namespace: "shop", Version: "v1", Resource: "pods", Kind: "Pod", Name: "open", ConditionType: "Ready"}
+	found := Finding{Namespace: "shop", Version: "v1", Resource: "pods", Kind: "Pod", Name: "new", ConditionType: "Ready"}
+	var buf bytes.Buffer
+	args := &Arguments{
+		Output: OutputNDJSON,
+		Config: &Config{},
+		stdout: &buf,
+		history: &history{open: map[string]*openFinding{
+			open.ID(): {finding: open, firstSeen: firstSeen, lastSeen: firstSeen, occurrences: 4},
+		}},
+	}
+	streamFindings(args, []Finding{open, found})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("streamed %d findings, want 2", len(lines))
+	}
+	for i, want := range []struct {
+		firstSeen   time.Time
+		occurrences int
+	}{{firstSeen, 5}, {time.Time{}, 1}} {
+		var f Finding
+		if err := json.Unmarshal([]byte(lines[i]), &f); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", lines[i], err)
+		}
+		if f.FirstSeen == nil || f.LastSeen == nil || f.Occurrences != want.occurrences ||
+			(!want.firstSeen.IsZero() && !f.FirstSeen.Equal(want.firstSeen)) {
+			t.Errorf("finding %s: got %s, want firstSeen %s and %d occurrences", f.Name, lines[i], want.firstSeen, want.occurrences)
+		}
+	}
+}
+
+func wantContains(t *testing.T, out string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Errorf("missing %q in output:\n%s", w, out)
+		}
+	}
+}
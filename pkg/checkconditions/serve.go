@@ -0,0 +1,434 @@
+package checkconditions
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	restclient "k8s.io/client-go/rest"
+)
+
+// server runs scans periodically and serves the results via HTTP.
+type server struct {
+	args   Arguments
+	config *restclient.Config
+
+	// scanMutex ensures that only one scan runs at a time.
+	scanMutex sync.Mutex
+
+	mutex       sync.Mutex
+	last        *Counter
+	lastTime    time.Time
+	subscribers map[chan *Counter]struct{}
+
+	// configChanged triggers a scan, after the config file or the ClusterCheckConfig changed.
+	configChanged chan struct{}
+
+	// configResource is the spec of the ClusterCheckConfig of --config-resource, as seen by
+	// watchConfigResource. Nil until the informer saw it.
+	configResource []byte
+
+	// schedule is the parsed --schedule. Nil means every args.Interval.
+	schedule *cronSchedule
+
+	// firstSeen contains the time each finding of the last periodic scan was seen first. See escalate.
+	firstSeen map[string]time.Time
+}
+
+// scanRequest is the body of POST /scan.
+type scanRequest struct {
+	Namespace     string `json:"namespace"`
+	LabelSelector string `json:"labelSelector"`
+}
+
+// scanResponse is the body returned by POST /scan and GET /findings.
+type scanResponse struct {
+	ScanTime             time.Time `json:"scanTime"`
+	CheckedResourceTypes int32     `json:"checkedResourceTypes"`
+	CheckedResources     int32     `json:"checkedResources"`
+	CheckedConditions    int32     `json:"checkedConditions"`
+	Findings             []Finding `json:"findings"`
+}
+
+// RunServe scans the cluster every args.Interval (or according to args.Schedule) and serves the results via HTTP on args.Listen.
+// Sinks, Grafana annotations, the textfile and remote-write are used after each periodic scan.
+func RunServe(args Arguments) {
+	if args.Plan {
+		RunPlan(args)
+		return
+	}
+	if args.Config == nil {
+		args.Config = &Config{}
+	}
+	if args.ConfigResource != "" {
+		if err := args.loadConfigResource(); err != nil {
+			fmt.Fprintln(args.diagnostics(), err.Error())
+			os.Exit(1)
+		}
+	}
+	args.applyServeConfig()
+	listen, tlsConfig, token, err := args.httpAuth().resolve()
+	if err != nil {
+		fmt.Fprintf(args.diagnostics(), "HTTP server: %s\n", err.Error())
+		os.Exit(1)
+	}
+	var schedule *cronSchedule
+	if args.Schedule != "" {
+		var err error
+		schedule, err = parseCronSchedule(args.Schedule)
+		if err != nil {
+			fmt.Fprintf(args.diagnostics(), "invalid --schedule %q: %s\n", args.Schedule, err.Error())
+			os.Exit(1)
+		}
+	}
+	if args.Jitter < 0 {
+		fmt.Fprintln(args.diagnostics(), "--jitter must not be negative")
+		os.Exit(1)
+	}
+	if err := args.loadHistory(); err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	args.createWriters(args.diagnostics())
+	// The sinks only send new and changed findings, see Arguments.sinkDue.
+	args.sinkNotifiers = make(map[string]*notifier)
+	config, err := newRestConfig(&args)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	if args.Informers && args.FromDir != "" {
+		fmt.Fprintf(args.diagnostics(), "WARNING: --informers does not work with --from-dir. Listing resource objects instead.\n")
+		args.Informers = false
+	}
+	if args.Informers {
+		dynClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			fmt.Fprintln(args.diagnostics(), err.Error())
+			os.Exit(1)
+		}
+		args.informers = newInformerCache(dynClient)
+	}
+	s := &server{
+		args:          args,
+		config:        config,
+		subscribers:   make(map[chan *Counter]struct{}),
+		configChanged: make(chan struct{}, 1),
+		schedule:      schedule,
+	}
+	if args.ConfigFile != "" {
+		go s.watchConfig()
+	}
+	if args.ConfigResource != "" {
+		dynClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			fmt.Fprintln(args.diagnostics(), err.Error())
+			os.Exit(1)
+		}
+		go s.watchConfigResource(dynClient, nil)
+	}
+	go s.scanPeriodically()
+	if args.GRPCListen != "" {
+		go s.serveGRPC()
+	}
+
+	fmt.Fprintf(args.diagnostics(), "Listening on %s\n", listen)
+	srv := &http.Server{
+		Addr:              listen,
+		Handler:           s.httpHandler(token),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if tlsConfig != nil {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+}
+
+// httpHandler returns the handler of the HTTP server. With a token, all requests need the header
+// "Authorization: Bearer TOKEN". See serverAuth.
+func (s *server) httpHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleDashboard)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/scan", s.handleScan)
+	mux.HandleFunc("/findings", s.handleFindings)
+	mux.HandleFunc("/findings/", s.handleFindings)
+	mux.HandleFunc("/healthz/namespace/", s.handleNamespaceHealth)
+	if token == "" {
+		return mux
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) scanPeriodically() {
+	for {
+		s.reloadConfig()
+		s.scanMutex.Lock()
+		if s.args.grafana != nil {
+			s.args.grafana.start(time.Now())
+		}
+		counter, err := scan(s.config, &s.args)
+		s.scanMutex.Unlock()
+		if err != nil {
+			fmt.Fprintf(s.args.diagnostics(), "WARNING: scan failed: %s\n", err.Error())
+		} else {
+			s.escalate(counter, time.Now())
+			resolved := updateHistory(&s.args, counter)
+			s.mutex.Lock()
+			s.last = counter
+			s.lastTime = time.Now()
+			for ch := range s.subscribers {
+				select {
+				case ch <- counter:
+				default:
+					// The subscriber is still busy with the previous scan.
+				}
+			}
+			s.mutex.Unlock()
+			for _, line := range resolved {
+				fmt.Fprintln(s.args.diagnostics(), line)
+			}
+			printSkipped(s.args.diagnostics(), counter.skipped)
+			afterScan(&s.args, counter)
+			fmt.Fprintf(s.args.diagnostics(), "Checked %d conditions of %d resources of %d types. Found %d conditions which need attention. Duration: %s\n",
+				counter.checkedConditions, counter.checkedResources, counter.checkedResourceTypes, len(counter.findings),
+				time.Since(counter.startTime).Round(time.Millisecond))
+		}
+		s.waitForNextScan()
+	}
+}
+
+// minScanDistance is the minimum time between two scans, if informers trigger scans.
+const minScanDistance = 10 * time.Second
+
+// waitForNextScan waits for the interval, or until the next time of the schedule. The jitter
+// gets added, so that many clusters with the same schedule do not scan at the same time.
+// With informers, a change of a resource object triggers the next scan earlier, so that
+// findings are nearly real-time. A change of the config file triggers the next scan, too.
+func (s *server) waitForNextScan() {
+	wait := s.nextScanDelay(time.Now())
+	if s.args.informers == nil {
+		select {
+		case <-s.configChanged:
+		case <-time.After(wait):
+		}
+		return
+	}
+	time.Sleep(minScanDistance)
+	select {
+	case <-s.args.informers.changed:
+	case <-s.configChanged:
+	case <-time.After(wait - minScanDistance):
+	}
+}
+
+// nextScanDelay returns the time until the next periodic scan.
+func (s *server) nextScanDelay(now time.Time) time.Duration {
+	wait := s.args.Interval
+	if s.schedule != nil {
+		next, ok := s.schedule.next(now)
+		if !ok {
+			fmt.Fprintf(s.args.diagnostics(), "WARNING: schedule %q matches no time within a year. Using interval %s\n",
+				s.args.Schedule, s.args.Interval)
+		} else {
+			wait = next.Sub(now)
+		}
+	}
+	if s.args.Jitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(s.args.Jitter))) //nolint:gosec
+	}
+	return wait
+}
+
+// handleScan runs a scan, limited to the namespace and label selector of the request body.
+// The result is returned, but it does not replace the result of the periodic scan.
+func (s *server) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req scanRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid body: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	if _, err := labels.Parse(req.LabelSelector); err != nil {
+		http.Error(w, fmt.Sprintf("invalid labelSelector: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	counter, err := s.scanNow(req.Namespace, req.LabelSelector, scanTrigger{name: "POST /scan", remoteAddr: r.RemoteAddr})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, newScanResponse(counter, time.Now(), ""))
+}
+
+// scanNow runs a scan, limited to the namespace and the label selector. The scan gets written to
+// the audit log together with the trigger.
+func (s *server) scanNow(namespace, labelSelector string, trigger scanTrigger) (*Counter, error) {
+	s.scanMutex.Lock()
+	defer s.scanMutex.Unlock()
+	args := s.args
+	args.Namespace = namespace
+	if namespace != "" {
+		args.Namespaces = nil
+	}
+	args.LabelSelector = labelSelector
+	args.trigger = trigger
+	counter, err := scan(s.config, &args)
+	if err != nil {
+		return nil, err
+	}
+	if args.AuditLog != "" {
+		if err := writeAuditLog(&args, counter, time.Now()); err != nil {
+			fmt.Fprintf(s.args.diagnostics(), "WARNING: writing audit log failed: %s\n", err.Error())
+		}
+	}
+	return counter, nil
+}
+
+// subscribe returns a channel which receives the result of each periodic scan.
+// The result of the last scan is sent immediately, if there is one.
+func (s *server) subscribe() chan *Counter {
+	ch := make(chan *Counter, 1)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.last != nil {
+		ch <- s.last
+	}
+	s.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (s *server) unsubscribe(ch chan *Counter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.subscribers, ch)
+}
+
+// handleFindings returns the findings of the last periodic scan: GET /findings or GET /findings/{namespace}.
+func (s *server) handleFindings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace := strings.Trim(strings.TrimPrefix(r.URL.Path, "/findings"), "/")
+	s.mutex.Lock()
+	last, lastTime := s.last, s.lastTime
+	s.mutex.Unlock()
+	if last == nil {
+		http.Error(w, "first scan is not finished yet", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, newScanResponse(last, lastTime, namespace))
+}
+
+// namespaceHealth is the body returned by GET /healthz/namespace/{name}.
+type namespaceHealth struct {
+	Namespace string    `json:"namespace"`
+	Healthy   bool      `json:"healthy"`
+	Severity  string    `json:"severity"`
+	ScanTime  time.Time `json:"scanTime"`
+	Findings  []Finding `json:"findings"`
+}
+
+// handleNamespaceHealth returns 200 if the last periodic scan found no findings in the namespace, otherwise 503.
+// Only findings with the severity given by the query parameter "severity" (or higher) are taken into account.
+// Default is "critical". Load balancers and deployment tools can use this to gate on the health of a namespace.
+func (s *server) handleNamespaceHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace := strings.Trim(strings.TrimPrefix(r.URL.Path, "/healthz/namespace"), "/")
+	if namespace == "" {
+		http.Error(w, "namespace is missing: /healthz/namespace/{name}", http.StatusBadRequest)
+		return
+	}
+	severity := r.URL.Query().Get("severity")
+	if severity == "" {
+		severity = SeverityCritical
+	}
+	if _, ok := severityRank[severity]; !ok {
+		http.Error(w, fmt.Sprintf("invalid severity %q", severity), http.StatusBadRequest)
+		return
+	}
+	s.mutex.Lock()
+	last, lastTime := s.last, s.lastTime
+	s.mutex.Unlock()
+	if last == nil {
+		http.Error(w, "first scan is not finished yet", http.StatusServiceUnavailable)
+		return
+	}
+	health := namespaceHealth{
+		Namespace: namespace,
+		Severity:  severity,
+		ScanTime:  lastTime,
+		Findings:  []Finding{},
+	}
+	for i := range last.findings {
+		f := &last.findings[i]
+		if f.Namespace == namespace && severityAtLeast(f.Severity, severity) {
+			health.Findings = append(health.Findings, *f)
+		}
+	}
+	health.Healthy = len(health.Findings) == 0
+	if !health.Healthy {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if err := json.NewEncoder(w).Encode(health); err != nil {
+			fmt.Fprintf(s.args.diagnostics(), "WARNING: writing response failed: %s\n", err.Error())
+		}
+		return
+	}
+	writeJSON(w, health)
+}
+
+func newScanResponse(counter *Counter, scanTime time.Time, namespace string) scanResponse {
+	resp := scanResponse{
+		ScanTime:             scanTime,
+		CheckedResourceTypes: counter.checkedResourceTypes,
+		CheckedResources:     counter.checkedResources,
+		CheckedConditions:    counter.checkedConditions,
+		Findings:             []Finding{},
+	}
+	for i := range counter.findings {
+		if namespace != "" && counter.findings[i].Namespace != namespace {
+			continue
+		}
+		resp.Findings = append(resp.Findings, counter.findings[i])
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: writing response failed: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,107 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPAPI(t *testing.T) {
+	args := Arguments{Config: &Config{}, FromDir: writeTestSnapshot(t)}
+	config, err := newRestConfig(&args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{args: args, config: config, subscribers: make(map[chan *Counter]struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scan", s.handleScan)
+	mux.HandleFunc("/findings", s.handleFindings)
+	mux.HandleFunc("/findings/", s.handleFindings)
+	do := func(method, path, body string) (*httptest.ResponseRecorder, scanResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var resp scanResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%s %s: invalid JSON: %v\n%s", method, path, err, rec.Body.String())
+			}
+		}
+		return rec, resp
+	}
+
+	if rec, _ := do(http.MethodGet, "/findings", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /findings before the first scan: status %d, want 503", rec.Code)
+	}
+
+	rec, resp := do(http.MethodPost, "/scan", `{"namespace": "a"}`)
+	if rec.Code != http.StatusOK || len(resp.Findings) != 1 || resp.Findings[0].Name != "p1" {
+		t.Errorf("POST /scan: status %d, response %+v", rec.Code, resp)
+	}
+	if rec, resp := do(http.MethodPost, "/scan", `{"namespace": "b"}`); rec.Code != http.StatusOK || len(resp.Findings) != 0 {
+		t.Errorf("POST /scan of namespace b: status %d, response %+v", rec.Code, resp)
+	}
+	if rec, _ := do(http.MethodPost, "/scan", `{"labelSelector": "app in (web"}`); rec.Code != http.StatusBadRequest ||
+		!strings.Contains(rec.Body.String(), "invalid labelSelector") {
+		t.Errorf("POST /scan with invalid labelSelector: status %d, body %q", rec.Code, rec.Body.String())
+	}
+	if rec, _ := do(http.MethodPost, "/scan", `{"namespace":`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST /scan with invalid body: status %d, want 400", rec.Code)
+	}
+	if rec, _ := do(http.MethodGet, "/scan", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /scan: status %d, want 405", rec.Code)
+	}
+	if s.last != nil {
+		t.Error("POST /scan replaced the result of the periodic scan")
+	}
+
+	// GET /findings returns the result of the last periodic scan.
+	counter, err := scan(s.config, &s.args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.last, s.lastTime = counter, time.Now()
+	for path, want := range map[string]int{"/findings": 1, "/findings/a": 1, "/findings/b": 0} {
+		rec, resp := do(http.MethodGet, path, "")
+		if rec.Code != http.StatusOK || len(resp.Findings) != want {
+			t.Errorf("GET %s: status %d, %d findings, want %d", path, rec.Code, len(resp.Findings), want)
+		}
+	}
+	if rec, _ := do(http.MethodPost, "/findings", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /findings: status %d, want 405", rec.Code)
+	}
+}
+
+func TestHTTPTokenAuth(t *testing.T) {
+	s := &server{args: Arguments{Config: &Config{}}, subscribers: make(map[chan *Counter]struct{})}
+	handler := s.httpHandler("secret")
+	for _, tt := range []struct {
+		authorization string
+		want          int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"secret", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusServiceUnavailable},
+	} {
+		for _, path := range []string{"/findings", "/healthz/namespace/a"} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET %s with authorization %q: status %d, want %d", path, tt.authorization, rec.Code, tt.want)
+			}
+		}
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/scan", strings.NewReader("{}")))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("POST /scan without token: status %d, header %v", rec.Code, rec.Header())
+	}
+}
@@ -0,0 +1,130 @@
+package checkconditions
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// serverAuth contains the authentication flags of a server of serve. The HTTP API and the gRPC
+// API run scans with the credentials of check-conditions and return all findings, so they need
+// authentication: a bearer token (--grpc-token-file, --http-token-file) or a client certificate
+// (--grpc-client-ca, --http-client-ca). A token needs TLS, so that it can not be sniffed. Without
+// authentication only localhost is allowed, and an address without host like ":8080" listens on
+// localhost only.
+type serverAuth struct {
+	// name is the name of the server in messages, flag the prefix of its flags, like "grpc".
+	name       string
+	flag       string
+	listenFlag string
+	listen     string
+	tlsCert    string
+	tlsKey     string
+	clientCA   string
+	tokenFile  string
+}
+
+func (args *Arguments) grpcAuth() serverAuth {
+	return serverAuth{
+		name: "gRPC", flag: "grpc", listenFlag: "grpc-listen", listen: args.GRPCListen, tlsCert: args.GRPCTLSCert, tlsKey: args.GRPCTLSKey,
+		clientCA: args.GRPCClientCA, tokenFile: args.GRPCTokenFile,
+	}
+}
+
+func (args *Arguments) httpAuth() serverAuth {
+	return serverAuth{
+		name: "HTTP", flag: "http", listenFlag: "listen", listen: args.Listen, tlsCert: args.HTTPTLSCert, tlsKey: args.HTTPTLSKey,
+		clientCA: args.HTTPClientCA, tokenFile: args.HTTPTokenFile,
+	}
+}
+
+// resolve returns the address of the server, the TLS config (nil without TLS) and the token
+// (empty without token).
+func (a serverAuth) resolve() (string, *tls.Config, string, error) {
+	if (a.tlsCert == "") != (a.tlsKey == "") {
+		return "", nil, "", fmt.Errorf("--%s-tls-cert and --%s-tls-key must be set together", a.flag, a.flag)
+	}
+	var tlsConfig *tls.Config
+	if a.tlsCert != "" {
+		var err error
+		tlsConfig, err = a.tlsConfig()
+		if err != nil {
+			return "", nil, "", err
+		}
+	} else if a.clientCA != "" {
+		return "", nil, "", fmt.Errorf("--%s-client-ca needs --%s-tls-cert and --%s-tls-key", a.flag, a.flag, a.flag)
+	}
+	token := ""
+	if a.tokenFile != "" {
+		data, err := os.ReadFile(a.tokenFile)
+		if err != nil {
+			return "", nil, "", err
+		}
+		token = strings.TrimSpace(string(data))
+		if token == "" {
+			return "", nil, "", fmt.Errorf("--%s-token-file %s is empty", a.flag, a.tokenFile)
+		}
+	}
+	listen, err := a.listenAddress(a.tokenFile != "" || a.clientCA != "", tlsConfig != nil)
+	if err != nil {
+		return "", nil, "", err
+	}
+	return listen, tlsConfig, token, nil
+}
+
+// listenAddress returns the address of the server. See serverAuth.
+func (a serverAuth) listenAddress(authenticated, tlsEnabled bool) (string, error) {
+	host, port, err := net.SplitHostPort(a.listen)
+	if err != nil {
+		return "", fmt.Errorf("invalid --%s %q: %w", a.listenFlag, a.listen, err)
+	}
+	if isLoopback(host) {
+		return a.listen, nil
+	}
+	if !authenticated {
+		if host == "" {
+			return net.JoinHostPort("localhost", port), nil
+		}
+		return "", fmt.Errorf("refusing to serve %s on %s without authentication: set --%s-token-file or --%s-client-ca, or listen on localhost",
+			a.name, a.listen, a.flag, a.flag)
+	}
+	if !tlsEnabled {
+		return "", fmt.Errorf("refusing to accept tokens on %s without TLS: set --%s-tls-cert and --%s-tls-key, or listen on localhost",
+			a.listen, a.flag, a.flag)
+	}
+	return a.listen, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// tlsConfig returns the TLS config of the server. With a client CA, clients need a certificate
+// signed by it.
+func (a serverAuth) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(a.tlsCert, a.tlsKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if a.clientCA != "" {
+		data, err := os.ReadFile(a.clientCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("--%s-client-ca %s contains no PEM certificate", a.flag, a.clientCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
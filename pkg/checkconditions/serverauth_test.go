@@ -0,0 +1,67 @@
+package checkconditions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenAddress(t *testing.T) {
+	tests := []struct {
+		listen        string
+		authenticated bool
+		tls           bool
+		want          string
+		wantErr       bool
+	}{
+		{listen: ":9090", want: "localhost:9090"},
+		{listen: "127.0.0.1:9090", want: "127.0.0.1:9090"},
+		{listen: "[::1]:9090", want: "[::1]:9090"},
+		{listen: "0.0.0.0:9090", wantErr: true},
+		{listen: "10.0.0.1:9090", authenticated: true, wantErr: true},
+		{listen: "localhost:9090", authenticated: true, want: "localhost:9090"},
+		{listen: ":9090", authenticated: true, tls: true, want: ":9090"},
+		{listen: "9090", wantErr: true},
+	}
+	for _, tt := range tests {
+		a := serverAuth{name: "gRPC", flag: "grpc", listenFlag: "grpc-listen", listen: tt.listen}
+		got, err := a.listenAddress(tt.authenticated, tt.tls)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%q authenticated %t tls %t: got %q, %v, want %q", tt.listen, tt.authenticated, tt.tls, got, err, tt.want)
+		}
+	}
+}
+
+func TestHTTPAuth(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args      Arguments
+		want      string
+		wantToken string
+		wantErr   string
+	}{
+		{args: Arguments{Listen: ":8080"}, want: "localhost:8080"},
+		{args: Arguments{Listen: "0.0.0.0:8080"},
+			wantErr: "refusing to serve HTTP on 0.0.0.0:8080 without authentication: set --http-token-file or --http-client-ca, or listen on localhost"},
+		{args: Arguments{Listen: ":8080", HTTPTokenFile: tokenFile},
+			wantErr: "refusing to accept tokens on :8080 without TLS: set --http-tls-cert and --http-tls-key, or listen on localhost"},
+		{args: Arguments{Listen: "localhost:8080", HTTPTokenFile: tokenFile}, want: "localhost:8080", wantToken: "secret"},
+		{args: Arguments{Listen: ":8080", HTTPTLSCert: "tls.crt"}, wantErr: "--http-tls-cert and --http-tls-key must be set together"},
+		{args: Arguments{Listen: ":8080", HTTPClientCA: "ca.crt"}, wantErr: "--http-client-ca needs --http-tls-cert and --http-tls-key"},
+		{args: Arguments{Listen: "8080"}, wantErr: `invalid --listen "8080": address 8080: missing port in address`},
+	}
+	for _, tt := range tests {
+		listen, tlsConfig, token, err := tt.args.httpAuth().resolve()
+		errString := ""
+		if err != nil {
+			errString = err.Error()
+		}
+		if errString != tt.wantErr || listen != tt.want || token != tt.wantToken || tlsConfig != nil {
+			t.Errorf("--listen %s: got %q, token %q, error %q, want %q, token %q, error %q", tt.args.Listen, listen, token, errString,
+				tt.want, tt.wantToken, tt.wantErr)
+		}
+	}
+}
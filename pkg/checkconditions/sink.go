@@ -0,0 +1,181 @@
+package checkconditions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sink gets all findings after each run. Sinks send the findings to external systems.
+type sink interface {
+	name() string
+	send(ctx context.Context, args *Arguments, counter *Counter) error
+}
+
+// createWriters sets the sinks and the Grafana annotator of the config file. With --no-write they
+// are ignored with a warning to log, since they write to other systems.
+func (args *Arguments) createWriters(log io.Writer) {
+	args.sinks, args.grafana = nil, nil
+	c := args.Config
+	if args.NoWrite {
+		if c.Jira != nil || c.GitHub != nil || c.Opsgenie != nil || c.Events != nil || c.Backstage != nil || c.Grafana != nil {
+			fmt.Fprintln(log, "WARNING: the sinks and grafana of the config file are ignored, since --no-write is set")
+		}
+		return
+	}
+	args.sinks = createSinks(c, args.ReadOnly, log)
+	if c.Grafana != nil {
+		args.grafana = newGrafanaAnnotator(c.Grafana, log)
+	}
+}
+
+// createSinks returns the sinks which are configured in the config file. With readOnly the sinks
+// which write to the cluster are not created, with a warning to log.
+func createSinks(config *Config, readOnly bool, log io.Writer) []sink {
+	var sinks []sink
+	if config.Jira != nil {
+		sinks = append(sinks, newJiraSink(config.Jira))
+	}
+	if config.GitHub != nil {
+		sinks = append(sinks, newGitHubSink(config.GitHub))
+	}
+	if config.Opsgenie != nil {
+		sinks = append(sinks, newOpsgenieSink(config.Opsgenie))
+	}
+	if config.Events != nil {
+		if readOnly {
+			fmt.Fprintf(log, "WARNING: events of the config file are ignored, since --read-only is set\n")
+		} else {
+			sinks = append(sinks, newEventsSink(config.Events))
+		}
+	}
+	if config.Backstage != nil {
+		sinks = append(sinks, newBackstageSink(config.Backstage))
+	}
+	return sinks
+}
+
+func sendToSinks(args *Arguments, counter *Counter) {
+	for _, s := range args.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := s.send(ctx, args, counter)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: sending findings to %s failed: %s\n", s.name(), err.Error())
+		}
+		if n := args.sinkNotifiers[s.name()]; n != nil {
+			// Pending findings (--grace-period) are not gone.
+			current := append(append([]Finding(nil), counter.findings...), counter.pending...)
+			n.forget(current, counter.resolvable(args))
+		}
+	}
+}
+
+// sinkDue returns true, if the sink should send the finding. Without sink notifiers (see
+// Arguments.sinkNotifiers) all findings get sent after each scan. Otherwise only new and changed
+// findings, and unchanged findings after --renotify-interval. Sinks call it after their own
+// filters (like severity), so that a finding which gets eligible later is still new for the sink.
+func (args *Arguments) sinkDue(sinkName string, f *Finding) bool {
+	n := args.sinkNotifier(sinkName)
+	return n == nil || n.due(f, time.Now())
+}
+
+// sinkSent remembers that the sink sent the finding. Sinks call it only after sending succeeded,
+// so that a failed finding gets sent again after the next scan.
+func (args *Arguments) sinkSent(sinkName string, f *Finding) {
+	if n := args.sinkNotifier(sinkName); n != nil {
+		n.mark(f, time.Now())
+	}
+}
+
+// sinkNotifier returns the notifier of the sink, or nil without sink notifiers.
+func (args *Arguments) sinkNotifier(sinkName string) *notifier {
+	if args.sinkNotifiers == nil {
+		return nil
+	}
+	n := args.sinkNotifiers[sinkName]
+	if n == nil {
+		n = newNotifier(args.RenotifyInterval)
+		args.sinkNotifiers[sinkName] = n
+	}
+	return n
+}
+
+// sendFailed returns an error, if a sink failed to send some findings. The errors of the single
+// findings are logged by the sink, which continues with the other findings.
+func sendFailed(failed int, what string) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to create %d %s, they get sent again after the next scan", failed, what)
+}
+
+// hash returns a short hash of the finding ID. It is used as key in external systems,
+// since the ID contains characters which are not allowed there.
+func (f *Finding) hash() string {
+	sum := sha256.Sum256([]byte(f.ID()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// sinkKey returns the key of the finding in Jira, GitHub and Opsgenie: the hash of the cluster
+// name and the hash of the finding ID. Several clusters can use the same Jira project, GitHub repo
+// or Opsgenie account, since their findings have different keys.
+func (args *Arguments) sinkKey(f *Finding) string {
+	return args.clusterKey() + "-" + f.hash()
+}
+
+// clusterKey returns a short hash of the cluster name.
+func (args *Arguments) clusterKey() string {
+	sum := sha256.Sum256([]byte(args.clusterName))
+	return hex.EncodeToString(sum[:4])
+}
+
+// ownKey returns true, if the key of an issue or alert (with the prefix of the sink) was created
+// for this cluster. Issues and alerts of other clusters never get closed.
+func (args *Arguments) ownKey(prefix, key string) bool {
+	return strings.HasPrefix(key, prefix+args.clusterKey()+"-")
+}
+
+// doJSON sends the request body as JSON and decodes the JSON response into out. Body and out can be nil.
+func doJSON(ctx context.Context, method, url string, header http.Header, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, string(data))
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,225 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSinkKeyClusters(t *testing.T) {
+	f := Finding{Namespace: "a", Version: "v1", Resource: "pods", Kind: "Pod", Name: "p1", ConditionType: "Ready", Severity: SeverityCritical}
+	prod := &Arguments{clusterName: "prod"}
+	staging := &Arguments{clusterName: "staging"}
+	if prod.sinkKey(&f) == staging.sinkKey(&f) {
+		t.Fatalf("the same finding in two clusters has the same key %s", prod.sinkKey(&f))
+	}
+	if !prod.ownKey(opsgenieTag+"-", opsgenieTag+"-"+prod.sinkKey(&f)) {
+		t.Errorf("key of prod is not owned by prod")
+	}
+	for _, key := range []string{staging.sinkKey(&f), f.hash()} {
+		if prod.ownKey(opsgenieTag+"-", opsgenieTag+"-"+key) {
+			t.Errorf("key %s is owned by prod", key)
+		}
+	}
+
+	// A scan of prod without findings closes only the alert of prod.
+	server, posts := recordingServer(t, func(string) string {
+		return `{"data": [{"alias": "check-conditions-` + prod.sinkKey(&f) + `"}, {"alias": "check-conditions-` +
+			staging.sinkKey(&f) + `"}, {"alias": "check-conditions-` + f.hash() + `"}]}`
+	})
+	prod.Config = &Config{Opsgenie: &OpsgenieConfig{APIURL: server.URL}}
+	counter := &Counter{listedKinds: map[schema.GroupKind]bool{{Kind: "Pod"}: true}}
+	if err := newOpsgenieSink(prod.Config.Opsgenie).send(t.Context(), prod, counter); err != nil {
+		t.Fatal(err)
+	}
+	var closed []string
+	for path := range posts() {
+		if strings.HasSuffix(path, "/close") {
+			closed = append(closed, path)
+		}
+	}
+	if want := "POST /v2/alerts/check-conditions-" + prod.sinkKey(&f) + "/close"; len(closed) != 1 || closed[0] != want {
+		t.Errorf("closed %v, want %s", closed, want)
+	}
+}
+
+func TestJiraAfter(t *testing.T) {
+	now := time.Now()
+	hoursAgo := func(h int) *time.Time {
+		ts := now.Add(-time.Duration(h) * time.Hour)
+		return &ts
+	}
+	tests := []struct {
+		name               string
+		firstSeen          *time.Time
+		lastTransitionTime *time.Time
+		created            bool
+	}{
+		{name: "first seen long ago", firstSeen: hoursAgo(3), lastTransitionTime: hoursAgo(1), created: true},
+		{name: "first seen recently", firstSeen: hoursAgo(1), lastTransitionTime: hoursAgo(3)},
+		{name: "without history", lastTransitionTime: hoursAgo(3), created: true},
+		{name: "without history, recent transition", lastTransitionTime: hoursAgo(1)},
+		{name: "no time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Finding{Version: "v1", Resource: "nodes", Kind: "Node", Name: "n1", ConditionType: "Ready", FirstSeen: tt.firstSeen}
+			if tt.lastTransitionTime != nil {
+				f.LastTransitionTime = *tt.lastTransitionTime
+			}
+			server, posts := recordingServer(t, func(string) string { return `{"issues": []}` })
+			config := &Config{Jira: &JiraConfig{URL: server.URL, Project: "OPS"}}
+			config.Jira.After.Duration = 2 * time.Hour
+			args := &Arguments{Config: config}
+			counter := &Counter{findings: []Finding{f}}
+			if err := newJiraSink(config.Jira).send(t.Context(), args, counter); err != nil {
+				t.Fatal(err)
+			}
+			issues := posts()["POST /rest/api/2/issue"]
+			if created := len(issues) == 1; created != tt.created {
+				t.Fatalf("created %t, want %t", created, tt.created)
+			}
+			if tt.created && !strings.Contains(issues[0], jiraLabel+"-"+args.sinkKey(&f)) {
+				t.Errorf("issue has not the label of the key: %s", issues[0])
+			}
+		})
+	}
+}
+
+// TestSinkCreateRetry checks the error path of the sinks: a failed create is logged, the other
+// findings still get sent, and the failed finding gets sent again after the next scan.
+func TestSinkCreateRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		createPath string
+		list       string
+		sink       func(url string) sink
+	}{
+		{
+			name:       "Jira",
+			createPath: "/rest/api/2/issue",
+			list:       `{"issues": []}`,
+			sink:       func(url string) sink { return newJiraSink(&JiraConfig{URL: url, Project: "OPS"}) },
+		},
+		{
+			name:       "GitHub",
+			createPath: "/repos/org/cluster/issues",
+			list:       `[]`,
+			sink:       func(url string) sink { return newGitHubSink(&GitHubConfig{APIURL: url, Repo: "org/cluster"}) },
+		},
+		{
+			name:       "Opsgenie",
+			createPath: "/v2/alerts",
+			list:       `{"data": []}`,
+			sink:       func(url string) sink { return newOpsgenieSink(&OpsgenieConfig{APIURL: url}) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var creates []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					_, _ = w.Write([]byte(tt.list))
+					return
+				}
+				if r.Method != http.MethodPost || r.URL.Path != tt.createPath {
+					_, _ = w.Write([]byte(`{}`))
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				mutex.Lock()
+				defer mutex.Unlock()
+				creates = append(creates, string(body))
+				if len(creates) == 1 {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			findings := []Finding{
+				{Namespace: "a", Version: "v1", Resource: "pods", Kind: "Pod", Name: "p1", ConditionType: "Ready", ConditionStatus: "False", Severity: SeverityCritical},
+				{Namespace: "a", Version: "v1", Resource: "pods", Kind: "Pod", Name: "p2", ConditionType: "Ready", ConditionStatus: "False", Severity: SeverityCritical},
+			}
+			var diagnostics strings.Builder
+			args := &Arguments{Config: &Config{}, stderr: &diagnostics, sinkNotifiers: make(map[string]*notifier)}
+			s := tt.sink(server.URL)
+			counter := &Counter{findings: findings, listedKinds: map[schema.GroupKind]bool{{Kind: "Pod"}: true}}
+
+			err := s.send(t.Context(), args, counter)
+			if err == nil || !strings.Contains(err.Error(), "failed to create 1 ") {
+				t.Fatalf("error %v, want one failed create", err)
+			}
+			if len(creates) != 2 {
+				t.Fatalf("%d creates after the first scan, want 2: the failure must not stop the other findings", len(creates))
+			}
+			if !strings.Contains(creates[0], "p1") || !strings.Contains(diagnostics.String(), findings[0].ID()) {
+				t.Errorf("the failed finding p1 is not logged: %q", diagnostics.String())
+			}
+
+			if err := s.send(t.Context(), args, counter); err != nil {
+				t.Fatal(err)
+			}
+			if len(creates) != 3 || !strings.Contains(creates[2], "p1") {
+				t.Fatalf("the second scan created %v, want only the failed finding p1 again", creates[2:])
+			}
+			if err := s.send(t.Context(), args, counter); err != nil {
+				t.Fatal(err)
+			}
+			if len(creates) != 3 {
+				t.Errorf("the third scan created %v, want nothing", creates[3:])
+			}
+		})
+	}
+}
+
+// TestGitHubPullRequests checks that pull requests, which the issues API returns too, are neither
+// taken as the issue of a finding nor closed, and that findings without kind get no "kind:" label.
+func TestGitHubPullRequests(t *testing.T) {
+	args := &Arguments{Config: &Config{}, stderr: io.Discard, sinkNotifiers: make(map[string]*notifier)}
+	open := Finding{Namespace: "a", Version: "v1", Resource: "pods", Kind: "Pod", Name: "p1", ConditionType: "Ready", ConditionStatus: "False", Severity: SeverityCritical}
+	rollup := Finding{Namespace: "a", Name: "web", ConditionType: "Ready", ConditionStatus: "False", Severity: SeverityCritical}
+	resolved := Finding{Namespace: "a", Version: "v1", Resource: "pods", Kind: "Pod", Name: "p0", ConditionType: "Ready", ConditionStatus: "False"}
+	list := fmt.Sprintf(`[
+		{"number": 1, "body": "<!-- check-conditions-id: %s -->", "pull_request": {"url": "x"}},
+		{"number": 2, "body": "<!-- check-conditions-id: %s -->", "pull_request": {"url": "x"}}]`,
+		args.sinkKey(&open), args.sinkKey(&resolved))
+	server, posts := recordingServer(t, func(string) string { return list })
+	s := newGitHubSink(&GitHubConfig{APIURL: server.URL, Repo: "org/cluster"})
+	counter := &Counter{findings: []Finding{open, rollup}, listedKinds: map[schema.GroupKind]bool{{Kind: "Pod"}: true}}
+	if err := s.send(t.Context(), args, counter); err != nil {
+		t.Fatal(err)
+	}
+	created := posts()["POST /repos/org/cluster/issues"]
+	if len(created) != 2 {
+		t.Fatalf("created %d issues, want 2: a pull request is no issue of a finding", len(created))
+	}
+	for _, body := range created {
+		var issue struct {
+			Labels []string `json:"labels"`
+		}
+		if err := json.Unmarshal([]byte(body), &issue); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range issue.Labels {
+			if label == "kind:" {
+				t.Errorf("empty kind label in %s", body)
+			}
+		}
+	}
+	for path := range posts() {
+		if strings.Contains(path, "/issues/") {
+			t.Errorf("pull request got closed: %s", path)
+		}
+	}
+}
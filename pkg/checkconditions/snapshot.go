@@ -0,0 +1,251 @@
+package checkconditions
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+)
+
+// A snapshot is a tar.gz file with the raw objects of a scan and its findings. After extracting it,
+// the objects can be checked again via --from-dir, without access to the cluster.
+const (
+	// snapshotMetaFile contains the snapshotMeta.
+	snapshotMetaFile = "snapshot.json"
+
+	// snapshotReportFile contains the report of the scan, like --output-file.
+	snapshotReportFile = "report.json"
+
+	// snapshotObjectsDir contains one list per resource type: objects/GROUP/VERSION/RESOURCE.json.
+	// The core group is "core".
+	snapshotObjectsDir = "objects"
+
+	// snapshotFileMode is the mode of the snapshot and of the files in it. They contain raw objects
+	// of the cluster, with --redact=false even the data of Secrets.
+	snapshotFileMode = 0o600
+)
+
+// lastAppliedAnnotation is removed from the objects of a redacted snapshot, since it contains the
+// data of Secrets which were applied with kubectl.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// snapshotMeta describes the content of a snapshot.
+type snapshotMeta struct {
+	Time          time.Time                 `json:"time"`
+	Cluster       string                    `json:"cluster,omitempty"`
+	ServerVersion *version.Info             `json:"serverVersion,omitempty"`
+	Redacted      bool                      `json:"redacted"`
+	Build         buildMetadata             `json:"build"`
+	Resources     []*metav1.APIResourceList `json:"resources"`
+}
+
+// snapshotCollector collects the listed objects of all workers.
+type snapshotCollector struct {
+	redact bool
+
+	mutex     sync.Mutex
+	lists     map[schema.GroupVersionResource]*unstructured.UnstructuredList
+	resources map[schema.GroupVersionResource]metav1.APIResource
+}
+
+func newSnapshotCollector(redact bool) *snapshotCollector {
+	return &snapshotCollector{
+		redact:    redact,
+		lists:     make(map[schema.GroupVersionResource]*unstructured.UnstructuredList),
+		resources: make(map[schema.GroupVersionResource]metav1.APIResource),
+	}
+}
+
+// add stores a copy of the listed objects. With redact the data of Secrets and ConfigMaps, the
+// last-applied-configuration and the managedFields are removed.
+func (c *snapshotCollector) add(gvr schema.GroupVersionResource, kind string, namespaced bool,
+	list *unstructured.UnstructuredList,
+) {
+	items := make([]unstructured.Unstructured, len(list.Items))
+	for i := range list.Items {
+		obj := list.Items[i].DeepCopy()
+		if c.redact {
+			redactObject(gvr, obj)
+		}
+		items[i] = *obj
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.lists[gvr] = &unstructured.UnstructuredList{
+		Object: map[string]interface{}{
+			"apiVersion": gvr.GroupVersion().String(),
+			"kind":       kind + "List",
+			"metadata":   map[string]interface{}{},
+		},
+		Items: items,
+	}
+	c.resources[gvr] = metav1.APIResource{
+		Name:       gvr.Resource,
+		Namespaced: namespaced,
+		Kind:       kind,
+		Verbs:      metav1.Verbs{"get", "list"},
+	}
+}
+
+func redactObject(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) {
+	if gvr.Group == "" && (gvr.Resource == "secrets" || gvr.Resource == "configmaps") {
+		for _, field := range []string{"data", "stringData", "binaryData"} {
+			unstructured.RemoveNestedField(obj.Object, field)
+		}
+	}
+	if annotations := obj.GetAnnotations(); annotations[lastAppliedAnnotation] != "" {
+		delete(annotations, lastAppliedAnnotation)
+		obj.SetAnnotations(annotations)
+	}
+	obj.SetManagedFields(nil)
+}
+
+// apiResourceLists returns the collected resource types like the discovery of the api-server.
+func (c *snapshotCollector) apiResourceLists() []*metav1.APIResourceList {
+	byGroupVersion := make(map[string]*metav1.APIResourceList)
+	for gvr, resource := range c.resources {
+		gv := gvr.GroupVersion().String()
+		list, ok := byGroupVersion[gv]
+		if !ok {
+			list = &metav1.APIResourceList{GroupVersion: gv}
+			byGroupVersion[gv] = list
+		}
+		list.APIResources = append(list.APIResources, resource)
+	}
+	result := make([]*metav1.APIResourceList, 0, len(byGroupVersion))
+	for _, list := range byGroupVersion {
+		sort.Slice(list.APIResources, func(i, j int) bool {
+			return list.APIResources[i].Name < list.APIResources[j].Name
+		})
+		result = append(result, list)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GroupVersion < result[j].GroupVersion
+	})
+	return result
+}
+
+func snapshotObjectsPath(gvr schema.GroupVersionResource) string {
+	group := gvr.Group
+	if group == "" {
+		group = "core"
+	}
+	return path.Join(snapshotObjectsDir, group, gvr.Version, gvr.Resource+".json")
+}
+
+// RunSnapshot scans the cluster, and writes the listed objects and the findings to the tar.gz
+// file --out.
+func RunSnapshot(args Arguments) {
+	if args.Config == nil {
+		args.Config = &Config{}
+	}
+	config, err := newRestConfig(&args)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	serverVersion, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	collector := newSnapshotCollector(args.SnapshotRedact)
+	args.snapshot = collector
+	counter, err := scan(config, &args)
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	meta := snapshotMeta{
+		Time:          counter.startTime,
+		Cluster:       args.clusterName,
+		ServerVersion: serverVersion,
+		Redacted:      args.SnapshotRedact,
+		Build:         newBuildMetadata(),
+		Resources:     collector.apiResourceLists(),
+	}
+	data, objects, err := writeSnapshot(&meta, collector, newReport(&args, counter, counter.findings))
+	if err != nil {
+		fmt.Fprintln(args.diagnostics(), err.Error())
+		os.Exit(1)
+	}
+	if err := writeFileAtomicMode(args.SnapshotOut, data, snapshotFileMode); err != nil {
+		fmt.Fprintf(args.diagnostics(), "writing snapshot failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %d objects of %d resource types and %d findings to %s\n", objects, len(collector.lists),
+		len(counter.findings), args.SnapshotOut)
+}
+
+// writeSnapshot returns the tar.gz file of the snapshot and the number of objects.
+func writeSnapshot(meta *snapshotMeta, collector *snapshotCollector, r *report) ([]byte, int, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name: name, Mode: int64(snapshotFileMode), Size: int64(len(data)), ModTime: meta.Time.Truncate(time.Second),
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := add(snapshotMetaFile, data); err != nil {
+		return nil, 0, err
+	}
+	var report bytes.Buffer
+	if err := writeReport(&report, OutputJSON, r); err != nil {
+		return nil, 0, err
+	}
+	if err := add(snapshotReportFile, report.Bytes()); err != nil {
+		return nil, 0, err
+	}
+	gvrs := make([]schema.GroupVersionResource, 0, len(collector.lists))
+	for gvr := range collector.lists {
+		gvrs = append(gvrs, gvr)
+	}
+	sort.Slice(gvrs, func(i, j int) bool {
+		return gvrs[i].String() < gvrs[j].String()
+	})
+	objects := 0
+	for _, gvr := range gvrs {
+		list := collector.lists[gvr]
+		objects += len(list.Items)
+		data, err := list.MarshalJSON()
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := add(snapshotObjectsPath(gvr), data); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), objects, nil
+}
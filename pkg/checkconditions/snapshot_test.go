@@ -0,0 +1,64 @@
+package checkconditions
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSnapshotFileMode(t *testing.T) {
+	meta := &snapshotMeta{Time: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)}
+	args := &Arguments{Config: &Config{}}
+	counter := &Counter{startTime: meta.Time}
+	data, _, err := writeSnapshot(meta, newSnapshotCollector(false), newReport(args, counter, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	entries := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries++
+		if header.Mode != snapshotFileMode {
+			t.Errorf("%s has mode %o, want %o", header.Name, header.Mode, snapshotFileMode)
+		}
+	}
+	if entries == 0 {
+		t.Fatal("the snapshot has no entries")
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// An existing snapshot with a wider mode gets replaced by a file with snapshotFileMode.
+	path := filepath.Join(t.TempDir(), "dump.tar.gz")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+	if err := writeFileAtomicMode(path, data, snapshotFileMode); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != snapshotFileMode {
+		t.Errorf("snapshot has mode %o, want %o", info.Mode().Perm(), snapshotFileMode)
+	}
+}
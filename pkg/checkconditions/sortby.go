@@ -0,0 +1,104 @@
+package checkconditions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// SortByNamespace sorts the findings by namespace. Cluster-scoped resources come first.
+	SortByNamespace = "namespace"
+
+	// SortByResource sorts the findings by resource type: "deployments.apps".
+	SortByResource = "resource"
+
+	// SortByName sorts the findings by the name of the resource object.
+	SortByName = "name"
+
+	// SortByCondition sorts the findings by condition type.
+	SortByCondition = "condition"
+
+	// SortBySeverity sorts critical findings before warnings.
+	SortBySeverity = "severity"
+
+	// SortByAge sorts the findings by lastTransitionTime. The oldest come first.
+	SortByAge = "age"
+
+	// SortByCode sorts the findings by code. See codes.go.
+	SortByCode = "code"
+)
+
+// SortByValues contains the valid values of Arguments.SortBy.
+var SortByValues = []string{
+	SortByNamespace, SortByResource, SortByName, SortByCondition, SortBySeverity, SortByAge, SortByCode,
+}
+
+// DefaultSortBy is the default of Arguments.SortBy.
+var DefaultSortBy = []string{SortByNamespace, SortByResource, SortByName}
+
+func validateSortBy(keys []string) error {
+	for _, key := range keys {
+		if !slices.Contains(SortByValues, key) {
+			return fmt.Errorf("invalid value for --sort-by: %q. Valid values: %s", key,
+				strings.Join(SortByValues, ", "))
+		}
+	}
+	return nil
+}
+
+// sortFindings sorts the findings by the keys. Findings which are equal in all keys are sorted by
+// their text, so that the order does not depend on the order in which the workers finished.
+func sortFindings(findings []Finding, keys []string) {
+	if len(keys) == 0 {
+		keys = DefaultSortBy
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := &findings[i], &findings[j]
+		for _, key := range keys {
+			if c := compareFindings(a, b, key); c != 0 {
+				return c < 0
+			}
+		}
+		return a.String() < b.String()
+	})
+}
+
+func compareFindings(a, b *Finding, key string) int {
+	switch key {
+	case SortByNamespace:
+		return strings.Compare(a.Namespace, b.Namespace)
+	case SortByResource:
+		return strings.Compare(findingGroupResource(a), findingGroupResource(b))
+	case SortByName:
+		return strings.Compare(a.Name, b.Name)
+	case SortByCondition:
+		return strings.Compare(a.ConditionType, b.ConditionType)
+	case SortBySeverity:
+		return severityRank[b.Severity] - severityRank[a.Severity]
+	case SortByAge:
+		switch {
+		case a.LastTransitionTime.Equal(b.LastTransitionTime):
+			return 0
+		case a.LastTransitionTime.IsZero():
+			return 1
+		case b.LastTransitionTime.IsZero():
+			return -1
+		case a.LastTransitionTime.Before(b.LastTransitionTime):
+			return -1
+		}
+		return 1
+	case SortByCode:
+		return strings.Compare(a.Code, b.Code)
+	}
+	return 0
+}
+
+func findingGroupResource(f *Finding) string {
+	if f.Group == "" {
+		return f.Resource
+	}
+	return f.Resource + "." + f.Group
+}
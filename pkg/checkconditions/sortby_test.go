@@ -0,0 +1,83 @@
+package checkconditions
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestSortFindings(t *testing.T) {
+	old := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	findings := []Finding{
+		{Namespace: "b", Resource: "pods", Name: "p1", ConditionType: "Ready", Severity: SeverityWarning, LastTransitionTime: recent, Code: "C2"},
+		{Namespace: "a", Group: "apps", Resource: "deployments", Name: "d1", ConditionType: "Available", Severity: SeverityCritical, Code: "C1"},
+		{Namespace: "", Resource: "nodes", Name: "n1", ConditionType: "Ready", Severity: SeverityCritical, LastTransitionTime: old, Code: "C3"},
+		{Namespace: "a", Resource: "pods", Name: "p0", ConditionType: "Ready", Severity: SeverityWarning, LastTransitionTime: old, Code: "C1"},
+	}
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{
+			name: "default is namespace, resource, name",
+			want: []string{"n1", "d1", "p0", "p1"},
+		},
+		{
+			name: "severity before namespace",
+			keys: []string{SortBySeverity, SortByNamespace},
+			want: []string{"n1", "d1", "p0", "p1"},
+		},
+		{
+			name: "age, findings without time last",
+			keys: []string{SortByAge, SortByName},
+			want: []string{"n1", "p0", "p1", "d1"},
+		},
+		{
+			name: "name",
+			keys: []string{SortByName},
+			want: []string{"d1", "n1", "p0", "p1"},
+		},
+		{
+			name: "code, then text",
+			keys: []string{SortByCode},
+			want: []string{"d1", "p0", "p1", "n1"},
+		},
+		{
+			name: "resource contains the group",
+			keys: []string{SortByResource, SortByName},
+			want: []string{"d1", "n1", "p0", "p1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted := slices.Clone(findings)
+			sortFindings(sorted, tt.keys)
+			var got []string
+			for i := range sorted {
+				got = append(got, sorted[i].Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSortBy(t *testing.T) {
+	tests := []struct {
+		keys    []string
+		wantErr bool
+	}{
+		{keys: nil},
+		{keys: []string{SortBySeverity, SortByAge}},
+		{keys: []string{"size"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateSortBy(tt.keys); (err != nil) != tt.wantErr {
+			t.Errorf("validateSortBy(%v) = %v, wantErr %t", tt.keys, err, tt.wantErr)
+		}
+	}
+}
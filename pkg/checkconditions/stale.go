@@ -0,0 +1,120 @@
+package checkconditions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxStaleNames is the number of object names in the details of a staleReconcile finding.
+const maxStaleNames = 5
+
+// staleCandidate is an object whose spec was changed, but the controller did not reconcile it.
+type staleCandidate struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+
+	// generation and observedGeneration tell how far the controller is behind.
+	generation         int64
+	observedGeneration int64
+}
+
+// observedGeneration returns status.observedGeneration. If it does not exist, the highest
+// observedGeneration of the conditions gets used, like Gateway API does. found is false, if the
+// controller does not write an observedGeneration at all.
+func observedGeneration(obj *unstructured.Unstructured) (generation int64, found bool) {
+	generation, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err == nil && found {
+		return generation, true
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		g, ok := mapOf(c)["observedGeneration"].(int64)
+		if ok && (!found || g > generation) {
+			generation, found = g, true
+		}
+	}
+	return generation, found
+}
+
+// collectStaleReconcile records custom resources whose observedGeneration is lower than
+// metadata.generation. Objects created less than Arguments.StaleReconcileAge ago are skipped, since
+// their controller might not have seen them yet. Controllers which do not write an
+// observedGeneration are not checked.
+func collectStaleReconcile(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	if !args.customResource(gvr) || obj.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	if time.Since(obj.GetCreationTimestamp().Time) < args.StaleReconcileAge {
+		return nil
+	}
+	observed, found := observedGeneration(obj)
+	if !found || observed >= obj.GetGeneration() {
+		return nil
+	}
+	output.staleObjects = append(output.staleObjects, staleCandidate{
+		gvr:                gvr,
+		namespace:          obj.GetNamespace(),
+		name:               obj.GetName(),
+		generation:         obj.GetGeneration(),
+		observedGeneration: observed,
+	})
+	return nil
+}
+
+// staleReconcileConditionType is the condition type of the findings of staleReconcile. It is not
+// "Reconciled", since the ID of the finding would collide with a real condition of the CRD.
+const staleReconcileConditionType = "StaleReconcile"
+
+// staleReconcileMessage is the message of the findings of staleReconcile. It does not contain the
+// count or the names of the objects, since a changed message notifies the sinks again.
+const staleReconcileMessage = "objects were not reconciled after their spec changed"
+
+// staleReconcile returns one finding per CRD with objects which were not reconciled, so that the
+// broken controller is obvious. The objects are listed in Finding.Details.
+func staleReconcile(_ *Arguments, counter *Counter) []Finding {
+	groups := make(map[schema.GroupResource][]staleCandidate)
+	for _, c := range counter.staleObjects {
+		gr := c.gvr.GroupResource()
+		groups[gr] = append(groups[gr], c)
+	}
+	var findings []Finding
+	for gr, candidates := range groups {
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].namespace+"/"+candidates[i].name < candidates[j].namespace+"/"+candidates[j].name
+		})
+		names := make([]string, 0, maxStaleNames)
+		for i, c := range candidates {
+			if i == maxStaleNames {
+				names = append(names, "...")
+				break
+			}
+			names = append(names, fmt.Sprintf("%s (generation %d, observed %d)",
+				strings.TrimPrefix(c.namespace+"/"+c.name, "/"), c.generation, c.observedGeneration))
+		}
+		findings = append(findings, Finding{
+			Group:              "apiextensions.k8s.io",
+			Version:            "v1",
+			Resource:           "customresourcedefinitions",
+			Kind:               "CustomResourceDefinition",
+			Name:               gr.String(),
+			ConditionType:      staleReconcileConditionType,
+			ConditionStatus:    "False",
+			ConditionReason:    "ControllerStale",
+			ConditionMessage:   staleReconcileMessage,
+			Details:            fmt.Sprintf("%d objects: %s", len(candidates), strings.Join(names, ", ")),
+			Severity:           SeverityWarning,
+			MessageFingerprint: staleReconcileMessage,
+			Code:               CodeGenerationLag,
+			Check:              checkStaleReconcile,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,76 @@
+package checkconditions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestStaleReconcile(t *testing.T) {
+	widgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	args := &Arguments{StaleReconcileAge: 10 * time.Minute, crds: map[schema.GroupResource]*crdInfo{widgets.GroupResource(): {}}}
+	hourAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+	object := func(name string, generation int64, status map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetGeneration(generation)
+		obj.SetCreationTimestamp(hourAgo)
+		return obj
+	}
+	fresh := object("fresh", 3, map[string]interface{}{"observedGeneration": int64(1)})
+	fresh.SetCreationTimestamp(metav1.Now())
+	deleting := object("deleting", 3, map[string]interface{}{"observedGeneration": int64(1)})
+	deleting.SetDeletionTimestamp(&hourAgo)
+	tests := []struct {
+		name  string
+		gvr   schema.GroupVersionResource
+		obj   *unstructured.Unstructured
+		stale bool
+	}{
+		{"behind", widgets, object("a", 4, map[string]interface{}{"observedGeneration": int64(3)}), true},
+		{"reconciled", widgets, object("b", 2, map[string]interface{}{"observedGeneration": int64(2)}), false},
+		{"observedGeneration of the conditions", widgets, object("c", 7, map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "observedGeneration": int64(5)},
+			map[string]interface{}{"type": "Synced", "observedGeneration": int64(4)},
+		}}), true},
+		{"no observedGeneration", widgets, object("d", 7, map[string]interface{}{}), false},
+		{"created recently", widgets, fresh, false},
+		{"terminating", widgets, deleting, false},
+		{"built-in resource", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			object("e", 4, map[string]interface{}{"observedGeneration": int64(3)}), false},
+	}
+	output := &handleResourceTypeOutput{}
+	for _, tt := range tests {
+		before := len(output.staleObjects)
+		collectStaleReconcile(args, tt.gvr, tt.obj, output)
+		if got := len(output.staleObjects) > before; got != tt.stale {
+			t.Errorf("%s: stale %t, want %t", tt.name, got, tt.stale)
+		}
+	}
+
+	findings := staleReconcile(args, &Counter{staleObjects: output.staleObjects})
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want one per CRD", len(findings))
+	}
+	f := findings[0]
+	if f.ID() != "apiextensions.k8s.io/customresourcedefinitions//widgets.example.com/StaleReconcile" {
+		t.Errorf("unexpected ID %q", f.ID())
+	}
+	if want := "2 objects: default/a (generation 4, observed 3), default/c (generation 7, observed 5)"; f.Details != want {
+		t.Errorf("details %q, want %q", f.Details, want)
+	}
+
+	// Another set of stale objects does not change the message, so the sinks do not notify again.
+	other := staleReconcile(args, &Counter{staleObjects: output.staleObjects[:1]})
+	if (&notifier{}).changed(&f, &other[0]) || other[0].ID() != f.ID() {
+		t.Errorf("finding changed with the set of objects: %q %q", f.ConditionMessage, other[0].ConditionMessage)
+	}
+	if !strings.Contains(f.String(), "[2 objects: default/a") {
+		t.Errorf("the details are missing in the text output: %s", f.String())
+	}
+}
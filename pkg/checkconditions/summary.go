@@ -0,0 +1,75 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// runSummary contains the final counters of a run. It gets written as JSON to stderr
+// (--summary-json) and to --summary-file, so that wrappers do not need to parse the summary line.
+type runSummary struct {
+	StartTime            time.Time `json:"startTime"`
+	Duration             string    `json:"duration"`
+	DurationSeconds      float64   `json:"durationSeconds"`
+	CheckedResourceTypes int32     `json:"checkedResourceTypes"`
+	CheckedResources     int32     `json:"checkedResources"`
+	CheckedConditions    int32     `json:"checkedConditions"`
+	Findings             int       `json:"findings"`
+	Critical             int       `json:"critical"`
+	Warning              int       `json:"warning"`
+	Pending              int       `json:"pending"`
+	Errors               int       `json:"errors"`
+	Skipped              int       `json:"skipped"`
+	Score                int       `json:"score"`
+	ExitCode             int       `json:"exitCode"`
+}
+
+func newRunSummary(counter *Counter, findings []Finding, exitCode int, now time.Time) runSummary {
+	duration := now.Sub(counter.startTime)
+	s := runSummary{
+		StartTime:            counter.startTime,
+		Duration:             duration.Round(time.Millisecond).String(),
+		DurationSeconds:      duration.Seconds(),
+		CheckedResourceTypes: counter.checkedResourceTypes,
+		CheckedResources:     counter.checkedResources,
+		CheckedConditions:    counter.checkedConditions,
+		Findings:             len(findings),
+		Pending:              len(counter.pending),
+		Errors:               len(counter.errors),
+		Skipped:              len(counter.skipped),
+		Score:                counter.score,
+		ExitCode:             exitCode,
+	}
+	for i := range findings {
+		switch findings[i].Severity {
+		case SeverityCritical:
+			s.Critical++
+		case SeverityWarning:
+			s.Warning++
+		}
+	}
+	return s
+}
+
+// writeSummary writes the summary as one JSON line to stderr and to the summary file.
+func writeSummary(args *Arguments, counter *Counter, findings []Finding, exitCode int) {
+	if !args.SummaryJSON && args.SummaryFile == "" {
+		return
+	}
+	data, err := json.Marshal(newRunSummary(counter, findings, exitCode, time.Now()))
+	if err != nil {
+		fmt.Fprintf(args.diagnostics(), "WARNING: %s\n", err.Error())
+		return
+	}
+	data = append(data, '\n')
+	if args.SummaryJSON {
+		_, _ = os.Stderr.Write(data)
+	}
+	if args.SummaryFile != "" {
+		if err := writeFileAtomic(args.SummaryFile, data); err != nil {
+			fmt.Fprintf(args.diagnostics(), "WARNING: writing summary file failed: %s\n", err.Error())
+		}
+	}
+}
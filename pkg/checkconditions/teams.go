@@ -0,0 +1,66 @@
+package checkconditions
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// noTeam is used for findings which don't belong to a team.
+const noTeam = "no-team"
+
+// namespaceMeta contains the labels and annotations of a namespace. They are used to
+// find the team of a namespace.
+type namespaceMeta struct {
+	labels      map[string]string
+	annotations map[string]string
+}
+
+func newNamespaceMeta(obj *unstructured.Unstructured) namespaceMeta {
+	return namespaceMeta{
+		labels:      obj.GetLabels(),
+		annotations: obj.GetAnnotations(),
+	}
+}
+
+// teamOf returns the name of the team of the namespace, or noTeam.
+func teamOf(config *Config, namespaces map[string]namespaceMeta, namespace string) string {
+	if team := teamConfigOf(config, namespaces, namespace); team != nil {
+		return team.Name
+	}
+	return noTeam
+}
+
+// teamConfigOf returns the team of the namespace, or nil. The sinks use it to route the findings
+// to the targets of the team.
+func teamConfigOf(config *Config, namespaces map[string]namespaceMeta, namespace string) *TeamConfig {
+	ns, ok := namespaces[namespace]
+	if !ok {
+		return nil
+	}
+	for i := range config.Teams {
+		if config.Teams[i].matches(ns) {
+			return &config.Teams[i]
+		}
+	}
+	return nil
+}
+
+// filterTeam returns the findings which belong to the given team.
+func filterTeam(config *Config, namespaces map[string]namespaceMeta, findings []Finding, team string) []Finding {
+	var ret []Finding
+	for i := range findings {
+		if teamOf(config, namespaces, findings[i].Namespace) == team {
+			ret = append(ret, findings[i])
+		}
+	}
+	return ret
+}
+
+// applyTeam removes the findings and pending findings of other teams than --team, so that the
+// output, the reports, the history, the sinks and the metrics only contain the findings of the team.
+func applyTeam(args *Arguments, counter *Counter) {
+	if args.Team == "" {
+		return
+	}
+	counter.findings = filterTeam(args.Config, counter.namespaces, counter.findings, args.Team)
+	counter.pending = filterTeam(args.Config, counter.namespaces, counter.pending, args.Team)
+}
@@ -0,0 +1,186 @@
+package checkconditions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// teamRoutingFixture returns a config with the team payments (namespace pay) and findings in
+// the namespaces pay and web. The namespace web has no team.
+func teamRoutingFixture() (*Config, *Counter) {
+	config := &Config{Teams: []TeamConfig{{
+		Name:              "payments",
+		NamespaceLabels:   map[string]string{"team": "payments"},
+		GitHubRepo:        "org/payments",
+		OpsgenieTeam:      "Payments On-Call",
+		EventsMinSeverity: SeverityCritical,
+	}}}
+	finding := func(namespace, name string) Finding {
+		return Finding{
+			Namespace: namespace, Version: "v1", Resource: "pods", Kind: "Pod", Name: name, UID: types.UID(name),
+			ConditionType: "Ready", ConditionStatus: "False", Severity: SeverityCritical,
+		}
+	}
+	counter := &Counter{
+		findings:    []Finding{finding("pay", "checkout"), finding("web", "frontend")},
+		listedKinds: map[schema.GroupKind]bool{{Kind: "Pod"}: true},
+		namespaces: map[string]namespaceMeta{
+			"pay": {labels: map[string]string{"team": "payments", "backstage.io/kubernetes-id": "checkout"}},
+			"web": {labels: map[string]string{"backstage.io/kubernetes-id": "frontend"}},
+		},
+		backstageIDs: map[types.UID]backstageObject{},
+	}
+	return config, counter
+}
+
+// recordingServer records the path and the body of each POST. GET requests get the response of get.
+func recordingServer(t *testing.T, get func(path string) string) (*httptest.Server, func() map[string][]string) {
+	var mutex sync.Mutex
+	posts := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(get(r.URL.Path)))
+			return
+		}
+		var body json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mutex.Lock()
+		posts[r.Method+" "+r.URL.Path] = append(posts[r.Method+" "+r.URL.Path], string(body))
+		mutex.Unlock()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() map[string][]string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return posts
+	}
+}
+
+func TestGitHubTeamRouting(t *testing.T) {
+	config, counter := teamRoutingFixture()
+	args := &Arguments{Config: config}
+	// An open issue of a resolved finding in the repo of the team.
+	server, posts := recordingServer(t, func(path string) string {
+		if path == "/repos/org/payments/issues" {
+			return `[{"number": 7, "body": "<!-- check-conditions-id: ` + args.clusterKey() + `-0123456789abcdef -->"}]`
+		}
+		return `[]`
+	})
+	config.GitHub = &GitHubConfig{Repo: "org/cluster", APIURL: server.URL}
+	if err := newGitHubSink(config.GitHub).send(t.Context(), args, counter); err != nil {
+		t.Fatal(err)
+	}
+	got := posts()
+	for path, want := range map[string]string{
+		"POST /repos/org/payments/issues":            "pay pods checkout",
+		"POST /repos/org/cluster/issues":             "web pods frontend",
+		"POST /repos/org/payments/issues/7/comments": "resolved",
+		"PATCH /repos/org/payments/issues/7":         "closed",
+	} {
+		if len(got[path]) != 1 || !strings.Contains(got[path][0], want) {
+			t.Errorf("%s: got %q, want one request containing %q", path, got[path], want)
+		}
+	}
+}
+
+func TestOpsgenieTeamRouting(t *testing.T) {
+	config, counter := teamRoutingFixture()
+	server, posts := recordingServer(t, func(string) string { return `{"data": []}` })
+	config.Opsgenie = &OpsgenieConfig{APIURL: server.URL}
+	args := &Arguments{Config: config}
+	if err := newOpsgenieSink(config.Opsgenie).send(t.Context(), args, counter); err != nil {
+		t.Fatal(err)
+	}
+	alerts := posts()["POST /v2/alerts"]
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2", len(alerts))
+	}
+	for _, alert := range alerts {
+		team := strings.Contains(alert, "checkout")
+		if got := strings.Contains(alert, `"responders":[{"name":"Payments On-Call","type":"team"}]`); got != team {
+			t.Errorf("responder of the team set %t, want %t: %s", got, team, alert)
+		}
+		if got := strings.Contains(alert, `"team:payments"`); got != team {
+			t.Errorf("tag of the team set %t, want %t: %s", got, team, alert)
+		}
+	}
+}
+
+func TestEventsTeamMinSeverity(t *testing.T) {
+	config, counter := teamRoutingFixture()
+	config.Events = &EventsConfig{}
+	s := newEventsSink(config.Events)
+	for namespace, want := range map[string]string{"pay": SeverityCritical, "web": SeverityWarning, "": SeverityWarning} {
+		f := Finding{Namespace: namespace}
+		if got := s.minSeverity(config, counter, &f); got != want {
+			t.Errorf("namespace %q: minSeverity %q, want %q", namespace, got, want)
+		}
+	}
+}
+
+func TestBackstageTeamRouting(t *testing.T) {
+	config, counter := teamRoutingFixture()
+	server, posts := recordingServer(t, func(string) string { return `` })
+	config.Backstage = &BackstageConfig{URL: server.URL + "/cluster"}
+	config.Backstage.applyDefaults()
+	config.Teams[0].BackstageURL = server.URL + "/payments"
+	args := &Arguments{Config: config}
+	if err := newBackstageSink(config.Backstage).send(t.Context(), args, counter); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"POST /payments": "component:default/checkout",
+		"POST /cluster":  "component:default/frontend",
+	} {
+		got := posts()[path]
+		if len(got) != 1 {
+			t.Fatalf("%s: got %d requests, want 1", path, len(got))
+		}
+		var status backstageStatus
+		if err := json.Unmarshal([]byte(got[0]), &status); err != nil {
+			t.Fatal(err)
+		}
+		var refs []string
+		for _, e := range status.Entities {
+			refs = append(refs, e.EntityRef)
+		}
+		if !slices.Equal(refs, []string{want}) {
+			t.Errorf("%s: entities %v, want %s", path, refs, want)
+		}
+	}
+}
+
+func TestTeamConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		team    TeamConfig
+		config  Config
+		wantErr string
+	}{
+		{name: "valid", team: TeamConfig{Name: "a", GitHubRepo: "org/a"}, config: Config{GitHub: &GitHubConfig{}}},
+		{name: "no name", team: TeamConfig{}, wantErr: "no name"},
+		{name: "target without sink", team: TeamConfig{Name: "a", OpsgenieTeam: "A"}, wantErr: "needs the opsgenie block"},
+		{name: "invalid repo", team: TeamConfig{Name: "a", GitHubRepo: "a"}, config: Config{GitHub: &GitHubConfig{}}, wantErr: "owner/name"},
+		{
+			name: "invalid severity", team: TeamConfig{Name: "a", EventsMinSeverity: "high"}, config: Config{Events: &EventsConfig{}},
+			wantErr: "unknown eventsMinSeverity",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.team.validate(&tt.config)
+			if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
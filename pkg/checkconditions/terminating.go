@@ -0,0 +1,107 @@
+package checkconditions
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// terminatingPod is a pod which is terminating for too long. It gets reported after the scan,
+// since the status of its node is needed.
+type terminatingPod struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+	uid       types.UID
+	nodeName  string
+
+	// deletionTimestamp is the time the pod should be gone: time of deletion plus grace period.
+	deletionTimestamp time.Time
+}
+
+// collectTerminatingPods records pods whose deletionTimestamp is older than
+// Arguments.TerminatingMargin, and the Ready status of all nodes.
+func collectTerminatingPods(args *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	if gvr.Group != "" {
+		return nil
+	}
+	switch gvr.Resource {
+	case "nodes":
+		if output.nodeReady == nil {
+			output.nodeReady = make(map[string]string)
+		}
+		output.nodeReady[obj.GetName()] = nodeReadyStatus(obj)
+	case "pods":
+		deletion := obj.GetDeletionTimestamp()
+		if deletion == nil || time.Since(deletion.Time) < args.TerminatingMargin {
+			return nil
+		}
+		nodeName, _, _ := unstructured.NestedString(obj.Object, "spec", "nodeName")
+		output.terminatingPods = append(output.terminatingPods, terminatingPod{
+			gvr:               gvr,
+			namespace:         obj.GetNamespace(),
+			name:              obj.GetName(),
+			uid:               obj.GetUID(),
+			nodeName:          nodeName,
+			deletionTimestamp: deletion.Time,
+		})
+	}
+	return nil
+}
+
+// nodeReadyStatus returns the status of the Ready condition of the node, or "Unknown".
+func nodeReadyStatus(obj *unstructured.Unstructured) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok || m["type"] != readyString {
+			continue
+		}
+		if status, ok := m["status"].(string); ok {
+			return status
+		}
+	}
+	return "Unknown"
+}
+
+// stuckTerminatingPods reports pods which are terminating much longer than their grace period.
+// They often block PodDisruptionBudgets and the identity of StatefulSet pods.
+func stuckTerminatingPods(_ *Arguments, counter *Counter) []Finding {
+	findings := make([]Finding, 0, len(counter.terminatingPods))
+	for i := range counter.terminatingPods {
+		p := &counter.terminatingPods[i]
+		reason := "StuckTerminating"
+		node := "not scheduled"
+		if p.nodeName != "" {
+			node = "node " + p.nodeName
+			if status, ok := counter.nodeReady[p.nodeName]; ok && status != "True" {
+				reason = "NodeUnreachable"
+				node += " is unreachable (Ready=" + status + ")"
+			}
+		}
+		findings = append(findings, Finding{
+			Namespace:          p.namespace,
+			Group:              p.gvr.Group,
+			Version:            p.gvr.Version,
+			Resource:           p.gvr.Resource,
+			Kind:               "Pod",
+			Name:               p.name,
+			UID:                p.uid,
+			ConditionType:      "Terminating",
+			ConditionStatus:    "True",
+			ConditionReason:    reason,
+			ConditionMessage:   fmt.Sprintf("pod should be gone since %s, %s", p.deletionTimestamp.Format(time.RFC3339), node),
+			LastTransitionTime: p.deletionTimestamp,
+			Severity:           SeverityWarning,
+			MessageFingerprint: "pod should be gone since <time>, " + reason,
+			Code:               CodePodTerminating,
+			Check:              checkTerminatingPods,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,74 @@
+package checkconditions
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestStuckTerminatingPods(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	nodes := schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	node := func(name, ready string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind": "Node",
+			"status": map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": ready},
+			}},
+		}}
+		obj.SetName(name)
+		return obj
+	}
+	pod := func(name, nodeName string, deleted time.Duration) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind": "Pod",
+			"spec": map[string]interface{}{"nodeName": nodeName},
+		}}
+		obj.SetNamespace("a")
+		obj.SetName(name)
+		if deleted != 0 {
+			obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now().Add(-deleted)})
+		}
+		return obj
+	}
+	args := &Arguments{TerminatingMargin: 5 * time.Minute}
+	output := &handleResourceTypeOutput{}
+	for _, o := range []struct {
+		gvr schema.GroupVersionResource
+		obj *unstructured.Unstructured
+	}{
+		{nodes, node("n1", "True")},
+		{nodes, node("n2", "Unknown")},
+		{pods, pod("stuck", "n1", time.Hour)},
+		{pods, pod("unreachable", "n2", time.Hour)},
+		{pods, pod("unscheduled", "", time.Hour)},
+		{pods, pod("terminating", "n1", time.Minute)},
+		{pods, pod("running", "n1", 0)},
+	} {
+		collectTerminatingPods(args, o.gvr, o.obj, output)
+	}
+	counter := &Counter{terminatingPods: output.terminatingPods, nodeReady: output.nodeReady}
+	got := make(map[string]string)
+	for _, f := range stuckTerminatingPods(args, counter) {
+		if f.Code != CodePodTerminating || f.Check != checkTerminatingPods {
+			t.Errorf("unexpected code or check %+v", f)
+		}
+		got[f.Name] = f.ConditionReason + ": " + f.MessageFingerprint
+	}
+	want := map[string]string{
+		"stuck":       "StuckTerminating: pod should be gone since <time>, StuckTerminating",
+		"unreachable": "NodeUnreachable: pod should be gone since <time>, NodeUnreachable",
+		"unscheduled": "StuckTerminating: pod should be gone since <time>, StuckTerminating",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for k, w := range want {
+		if got[k] != w {
+			t.Errorf("%s: got %q, want %q", k, got[k], w)
+		}
+	}
+}
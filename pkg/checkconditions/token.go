@@ -0,0 +1,61 @@
+package checkconditions
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// tokenClaims contains the claims of a service-account token which get checked.
+type tokenClaims struct {
+	Audience audiences `json:"aud"`
+}
+
+// audiences is the "aud" claim of a JWT. It is a string or a list of strings.
+type audiences []string
+
+func (a *audiences) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = audiences{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// checkTokenAudience returns an error, if the token in the file was not issued for one of the
+// audiences. The signature is not verified, this is done by the api-server.
+func checkTokenAudience(path string, expected []string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading token file failed: %w", err)
+	}
+	parts := strings.Split(strings.TrimSpace(string(data)), ".")
+	if len(parts) != 3 { //nolint:gomnd
+		return fmt.Errorf("token file %q does not contain a JWT", path)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("token file %q: invalid JWT payload: %w", path, err)
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("token file %q: invalid JWT claims: %w", path, err)
+	}
+	for _, aud := range claims.Audience {
+		if slices.Contains(expected, aud) {
+			return nil
+		}
+	}
+	return fmt.Errorf("token file %q was issued for the audiences %q, expected one of %q",
+		path, []string(claims.Audience), expected)
+}
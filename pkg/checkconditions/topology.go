@@ -0,0 +1,80 @@
+package checkconditions
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// zoneLabels contain the zone of a node. The first one which is set wins.
+var zoneLabels = []string{
+	"topology.kubernetes.io/zone",
+	"failure-domain.beta.kubernetes.io/zone",
+}
+
+// nodePoolLabels contain the node pool (or instance group) of a node. The first one which is set wins.
+var nodePoolLabels = []string{
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"alpha.eksctl.io/nodegroup-name",
+	"kubernetes.azure.com/agentpool",
+	"karpenter.sh/nodepool",
+	"node.kubernetes.io/instance-group",
+	"cluster.x-k8s.io/deployment-name",
+}
+
+// nodeTopology contains the failure domains of a node.
+type nodeTopology struct {
+	zone     string
+	nodePool string
+}
+
+func newNodeTopology(obj *unstructured.Unstructured) nodeTopology {
+	labels := obj.GetLabels()
+	return nodeTopology{
+		zone:     firstLabel(labels, zoneLabels),
+		nodePool: firstLabel(labels, nodePoolLabels),
+	}
+}
+
+func firstLabel(labels map[string]string, keys []string) string {
+	for _, key := range keys {
+		if v := labels[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// collectTopology records the topology of nodes, and the node of pods.
+func collectTopology(obj *unstructured.Unstructured, resource string, output *handleResourceTypeOutput) {
+	switch resource {
+	case "nodes":
+		output.nodeTopology[obj.GetName()] = newNodeTopology(obj)
+	case "pods":
+		if nodeName, _, _ := unstructured.NestedString(obj.Object, "spec", "nodeName"); nodeName != "" {
+			output.podNodes[obj.GetUID()] = nodeName
+		}
+	}
+}
+
+// addTopology sets zone and node pool of the findings of pods and nodes.
+func addTopology(findings []Finding, nodes map[string]nodeTopology, podNodes map[types.UID]string) {
+	for i := range findings {
+		f := &findings[i]
+		if f.Group != "" {
+			continue
+		}
+		var node string
+		switch f.Resource {
+		case "nodes":
+			node = f.Name
+		case "pods":
+			node = podNodes[f.UID]
+		default:
+			continue
+		}
+		t := nodes[node]
+		f.Zone = t.zone
+		f.NodePool = t.nodePool
+	}
+}
@@ -0,0 +1,174 @@
+package checkconditions
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// podSpecPaths contains the path to the pod spec of workloads.
+var podSpecPaths = map[schema.GroupResource][]string{
+	{Group: "", Resource: "pods"}:                   {"spec"},
+	{Group: "apps", Resource: "deployments"}:        {"spec", "template", "spec"},
+	{Group: "apps", Resource: "statefulsets"}:       {"spec", "template", "spec"},
+	{Group: "apps", Resource: "daemonsets"}:         {"spec", "template", "spec"},
+	{Group: "apps", Resource: "replicasets"}:        {"spec", "template", "spec"},
+	{Group: "batch", Resource: "jobs"}:              {"spec", "template", "spec"},
+	{Group: "batch", Resource: "cronjobs"}:          {"spec", "jobTemplate", "spec", "template", "spec"},
+	{Group: "", Resource: "replicationcontrollers"}: {"spec", "template", "spec"},
+}
+
+// configObject is a ConfigMap or Secret, which might be unreferenced.
+type configObject struct {
+	gvr       schema.GroupVersionResource
+	kind      string
+	namespace string
+	name      string
+	uid       types.UID
+}
+
+// key returns "Kind namespace/name", the same format as configRef.
+func (c *configObject) key() string {
+	return configRef(c.kind, c.namespace, c.name)
+}
+
+func configRef(kind, namespace, name string) string {
+	return kind + " " + namespace + "/" + name
+}
+
+// collectConfigReferences records ConfigMaps and Secrets, and the references to them of workloads
+// (volumes, env, envFrom, imagePullSecrets), ServiceAccounts and Ingresses. ConfigMaps and Secrets
+// with owner, of Helm releases, service account tokens and kube-root-ca.crt are not recorded.
+func collectConfigReferences(_ *Arguments, gvr schema.GroupVersionResource, obj *unstructured.Unstructured,
+	output *handleResourceTypeOutput,
+) []Finding {
+	ns := obj.GetNamespace()
+	addRef := func(kind, name string) {
+		if name != "" {
+			output.configRefs = append(output.configRefs, configRef(kind, ns, name))
+		}
+	}
+	if path, ok := podSpecPaths[gvr.GroupResource()]; ok {
+		spec, found, _ := unstructured.NestedMap(obj.Object, path...)
+		if found {
+			podSpecReferences(spec, addRef)
+		}
+		return nil
+	}
+	if gvr.Group != "" && gvr.Group != "networking.k8s.io" {
+		return nil
+	}
+	switch gvr.Resource {
+	case "serviceaccounts":
+		for _, field := range []string{"imagePullSecrets", "secrets"} {
+			list, _, _ := unstructured.NestedSlice(obj.Object, field)
+			for _, item := range list {
+				addRef("Secret", stringField(item, "name"))
+			}
+		}
+	case "ingresses":
+		list, _, _ := unstructured.NestedSlice(obj.Object, "spec", "tls")
+		for _, item := range list {
+			addRef("Secret", stringField(item, "secretName"))
+		}
+	case "configmaps", "secrets":
+		if gvr.Group != "" || len(obj.GetOwnerReferences()) > 0 || obj.GetName() == "kube-root-ca.crt" {
+			return nil
+		}
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		if helmReleaseStorage(gvr, obj) || secretType == "kubernetes.io/service-account-token" {
+			return nil
+		}
+		output.configObjects = append(output.configObjects, configObject{
+			gvr:       gvr,
+			kind:      obj.GetKind(),
+			namespace: ns,
+			name:      obj.GetName(),
+			uid:       obj.GetUID(),
+		})
+	}
+	return nil
+}
+
+// podSpecReferences calls addRef for each ConfigMap and Secret referenced by the pod spec.
+func podSpecReferences(spec map[string]interface{}, addRef func(kind, name string)) {
+	volumes, _, _ := unstructured.NestedSlice(spec, "volumes")
+	for _, v := range volumes {
+		addRef("ConfigMap", stringField(v, "configMap", "name"))
+		addRef("Secret", stringField(v, "secret", "secretName"))
+		sources, _, _ := unstructured.NestedSlice(mapOf(v), "projected", "sources")
+		for _, s := range sources {
+			addRef("ConfigMap", stringField(s, "configMap", "name"))
+			addRef("Secret", stringField(s, "secret", "name"))
+		}
+	}
+	pullSecrets, _, _ := unstructured.NestedSlice(spec, "imagePullSecrets")
+	for _, s := range pullSecrets {
+		addRef("Secret", stringField(s, "name"))
+	}
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _, _ := unstructured.NestedSlice(spec, field)
+		for _, c := range containers {
+			env, _, _ := unstructured.NestedSlice(mapOf(c), "env")
+			for _, e := range env {
+				addRef("ConfigMap", stringField(e, "valueFrom", "configMapKeyRef", "name"))
+				addRef("Secret", stringField(e, "valueFrom", "secretKeyRef", "name"))
+			}
+			envFrom, _, _ := unstructured.NestedSlice(mapOf(c), "envFrom")
+			for _, e := range envFrom {
+				addRef("ConfigMap", stringField(e, "configMapRef", "name"))
+				addRef("Secret", stringField(e, "secretRef", "name"))
+			}
+		}
+	}
+}
+
+func mapOf(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func stringField(v interface{}, fields ...string) string {
+	s, _, _ := unstructured.NestedString(mapOf(v), fields...)
+	return s
+}
+
+// unreferencedConfigs reports ConfigMaps and Secrets which are not referenced. Only checked if
+// pods were listed completely. Custom resources (for example Issuers of cert-manager) might
+// reference them, too. That's why the check is disabled by default.
+func unreferencedConfigs(args *Arguments, counter *Counter) []Finding {
+	if args.LabelSelector != "" || !counter.listedKinds[podKind] {
+		return nil
+	}
+	refs := make(map[string]bool, len(counter.configRefs))
+	for _, r := range counter.configRefs {
+		refs[r] = true
+	}
+	var findings []Finding
+	for i := range counter.configObjects {
+		c := &counter.configObjects[i]
+		if refs[c.key()] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Namespace:          c.namespace,
+			Group:              c.gvr.Group,
+			Version:            c.gvr.Version,
+			Resource:           c.gvr.Resource,
+			Kind:               c.kind,
+			Name:               c.name,
+			UID:                c.uid,
+			ConditionType:      "Referenced",
+			ConditionStatus:    "False",
+			ConditionReason:    "Unreferenced",
+			ConditionMessage:   fmt.Sprintf("%s is not referenced by workloads, service accounts or ingresses", c.kind),
+			Severity:           SeverityWarning,
+			MessageFingerprint: fmt.Sprintf("%s is not referenced by workloads, service accounts or ingresses", c.kind),
+			Code:               CodeUnreferenced,
+			Check:              checkUnreferenced,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,88 @@
+package checkconditions
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestUnreferencedConfigs(t *testing.T) {
+	object := func(kind, name string, fields map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": kind}}
+		for k, v := range fields {
+			obj.Object[k] = v
+		}
+		obj.SetNamespace("a")
+		obj.SetName(name)
+		return obj
+	}
+	ref := func(fields ...string) map[string]interface{} {
+		m := map[string]interface{}{"name": fields[len(fields)-1]}
+		for i := len(fields) - 2; i >= 0; i-- {
+			m = map[string]interface{}{fields[i]: m}
+		}
+		return m
+	}
+	podSpec := map[string]interface{}{
+		"volumes": []interface{}{
+			ref("configMap", "volume-cm"),
+			map[string]interface{}{"secret": map[string]interface{}{"secretName": "volume-secret"}},
+			map[string]interface{}{"projected": map[string]interface{}{"sources": []interface{}{ref("configMap", "projected-cm")}}},
+		},
+		"initContainers": []interface{}{map[string]interface{}{
+			"envFrom": []interface{}{ref("secretRef", "envfrom-secret")},
+		}},
+		"containers": []interface{}{map[string]interface{}{
+			"env": []interface{}{ref("valueFrom", "configMapKeyRef", "env-cm")},
+		}},
+	}
+	owned := object("ConfigMap", "owned", nil)
+	owned.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Deployment", Name: "web"}})
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	output := &handleResourceTypeOutput{}
+	for _, o := range []struct {
+		gvr schema.GroupVersionResource
+		obj *unstructured.Unstructured
+	}{
+		{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, object("Deployment", "web",
+			map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": podSpec}}})},
+		{schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, object("ServiceAccount", "default",
+			map[string]interface{}{"imagePullSecrets": []interface{}{ref("registry")}})},
+		{schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, object("Ingress", "web",
+			map[string]interface{}{"spec": map[string]interface{}{"tls": []interface{}{map[string]interface{}{"secretName": "web-tls"}}}})},
+		{configMaps, object("ConfigMap", "volume-cm", nil)},
+		{configMaps, object("ConfigMap", "projected-cm", nil)},
+		{configMaps, object("ConfigMap", "env-cm", nil)},
+		{configMaps, object("ConfigMap", "unused-cm", nil)},
+		{configMaps, object("ConfigMap", "kube-root-ca.crt", nil)},
+		{configMaps, owned},
+		{secrets, object("Secret", "volume-secret", nil)},
+		{secrets, object("Secret", "envfrom-secret", nil)},
+		{secrets, object("Secret", "registry", nil)},
+		{secrets, object("Secret", "web-tls", nil)},
+		{secrets, object("Secret", "unused-secret", nil)},
+		{secrets, object("Secret", "token", map[string]interface{}{"type": "kubernetes.io/service-account-token"})},
+		{secrets, object("Secret", "sh.helm.release.v1.web.v1", map[string]interface{}{"type": helmReleaseType})},
+	} {
+		collectConfigReferences(&Arguments{}, o.gvr, o.obj, output)
+	}
+	counter := &Counter{configObjects: output.configObjects, configRefs: output.configRefs, listedKinds: map[schema.GroupKind]bool{podKind: true}}
+	got := make(map[string]bool)
+	for _, f := range unreferencedConfigs(&Arguments{}, counter) {
+		if f.ConditionReason != "Unreferenced" || f.Code != CodeUnreferenced || f.Check != checkUnreferenced {
+			t.Errorf("unexpected finding %+v", f)
+		}
+		got[f.Kind+" "+f.Name] = true
+	}
+	if len(got) != 2 || !got["ConfigMap unused-cm"] || !got["Secret unused-secret"] {
+		t.Errorf("got %v, want ConfigMap unused-cm and Secret unused-secret", got)
+	}
+
+	counter.listedKinds = nil
+	if findings := unreferencedConfigs(&Arguments{}, counter); len(findings) != 0 {
+		t.Errorf("got findings without listing pods: %v", findings)
+	}
+}
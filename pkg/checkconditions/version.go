@@ -0,0 +1,68 @@
+package checkconditions
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version, Commit and BuildDate get set at build time:
+//
+//	go build -ldflags "-X github.com/guettli/check-conditions/pkg/checkconditions.Version=v1.2.3 ..."
+//
+// Without ldflags, the values of the Go build info are used.
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
+)
+
+// buildMetadata identifies the binary. It gets printed by the version command and is part of reports.
+type buildMetadata struct {
+	Version         string `json:"version"`
+	Commit          string `json:"commit"`
+	BuildDate       string `json:"buildDate"`
+	GoVersion       string `json:"goVersion"`
+	ClientGoVersion string `json:"clientGoVersion"`
+}
+
+func newBuildMetadata() buildMetadata {
+	m := buildMetadata{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if m.Version == "" {
+			m.Version = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch {
+			case s.Key == "vcs.revision" && m.Commit == "":
+				m.Commit = s.Value
+			case s.Key == "vcs.time" && m.BuildDate == "":
+				m.BuildDate = s.Value
+			}
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == "k8s.io/client-go" {
+				m.ClientGoVersion = dep.Version
+			}
+		}
+	}
+	if m.Version == "" {
+		m.Version = "(devel)"
+	}
+	return m
+}
+
+// RunVersion prints version, git commit, build date, Go version and the version of client-go.
+func RunVersion() {
+	m := newBuildMetadata()
+	fmt.Printf("Version:    %s\n", m.Version)
+	fmt.Printf("Commit:     %s\n", m.Commit)
+	fmt.Printf("Build date: %s\n", m.BuildDate)
+	fmt.Printf("Go:         %s\n", m.GoVersion)
+	fmt.Printf("client-go:  %s\n", m.ClientGoVersion)
+}
@@ -0,0 +1,40 @@
+package checkconditions
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// setCreationTimestamp sets Finding.CreationTimestamp of the findings of the object.
+func setCreationTimestamp(findings []Finding, obj *unstructured.Unstructured) {
+	for i := range findings {
+		if findings[i].UID == obj.GetUID() {
+			findings[i].CreationTimestamp = obj.GetCreationTimestamp().Time
+		}
+	}
+}
+
+// wideColumns returns the columns which --output wide appends to the line of a finding: the age of
+// the object and the lastTransitionTime of the condition. With --history-file the times of the
+// first and the last scan which found the finding follow. The number of scans is part of the line.
+func (f *Finding) wideColumns(now time.Time) string {
+	age := "-"
+	if !f.CreationTimestamp.IsZero() {
+		age = duration.HumanDuration(now.Sub(f.CreationTimestamp))
+	}
+	lastTransitionTime := "-"
+	if !f.LastTransitionTime.IsZero() {
+		lastTransitionTime = f.LastTransitionTime.UTC().Format(time.RFC3339)
+	}
+	columns := fmt.Sprintf(" age=%s lastTransitionTime=%s", age, lastTransitionTime)
+	if f.FirstSeen != nil {
+		columns += fmt.Sprintf(" firstSeen=%s", f.FirstSeen.UTC().Format(time.RFC3339))
+	}
+	if f.LastSeen != nil {
+		columns += fmt.Sprintf(" lastSeen=%s", f.LastSeen.UTC().Format(time.RFC3339))
+	}
+	return columns
+}